@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots"
+	"github.com/juankohler/crypto-bot/bots/application"
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/bots/infrastructure"
+	"github.com/juankohler/crypto-bot/common"
+	"github.com/juankohler/crypto-bot/libs/go/logs"
+	"github.com/shopspring/decimal"
+)
+
+// backtest runs the backtest subcommand over candles from a Binance kline CSV
+// file or from the database, fetching the missing ones from Binance, and prints
+// the report as JSON:
+//
+//	crypto-bot backtest -strategy GRID -take-profit 0.01 -capital 1000 -delta 100 \
+//		(-csv BTCUSDT-1m-2024-01.csv | -interval 1m -from 2024-01-01 -to 2024-02-01)
+func backtest(cfg *common.Config, args []string) error {
+	ctx := logs.ContextWithLogger(context.Background())
+
+	flags := flag.NewFlagSet("backtest", flag.ContinueOnError)
+	currency := flags.String("currency", domain.CurrencyUSDT, "quote currency")
+	targetCurrency := flags.String("target-currency", domain.CurrencyBTC, "base currency")
+	strategy := flags.String("strategy", domain.StrategyGrid, "strategy of the bot")
+	params := flags.String("params", "", "strategy params as key=value,key=value")
+	takeProfit := flags.String("take-profit", "0.01", "take profit percentage of the bot")
+	capital := flags.String("capital", "1000", "initial capital of the bot")
+	delta := flags.String("delta", "100", "delta of the bot")
+	monitorInterval := flags.String("monitor-interval", "1m", "monitor interval of the bot")
+	stopLoss := flags.String("stop-loss", "", "stop loss of the bot, none if empty")
+	stopLossType := flags.String("stop-loss-type", domain.StopLossTypePercentage, "PERCENTAGE or ABSOLUTE")
+	trailingStop := flags.Bool("trailing-stop", false, "trail the stop loss below the highest price")
+	adaptiveDelta := flags.Bool("adaptive-delta", false, "adapt the delta to the ATR of the candles, starting from -delta")
+	atrInterval := flags.String("atr-interval", "1h", "candle interval of the ATR")
+	atrPeriod := flags.Int("atr-period", 14, "number of candles of the ATR")
+	atrMultiplier := flags.String("atr-multiplier", "1", "delta as a multiple of the ATR")
+	minDelta := flags.String("min-delta", "", "lower bound of the adaptive delta")
+	maxDelta := flags.String("max-delta", "", "upper bound of the adaptive delta")
+	deltaHysteresis := flags.String("delta-hysteresis", "0.1", "minimum change of the adaptive delta, as a fraction of it")
+	csvFile := flags.String("csv", "", "Binance kline CSV file with the candles")
+	interval := flags.String("interval", "1m", "interval of the candles")
+	from := flags.String("from", "", "start date of the candles in the database, 2006-01-02")
+	to := flags.String("to", "", "end date of the candles in the database, 2006-01-02")
+	curve := flags.Bool("curve", false, "include the equity curve in the report")
+	verbose := flags.Bool("verbose", false, "log every order")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	logLevel := logs.LevelWarn
+	if *verbose {
+		logLevel = logs.LevelInfo
+	}
+	logs.InitLogger(&logs.Config{
+		LogLevel: logLevel,
+		Format:   logs.FormatPretty,
+	})
+
+	input := application.BacktestInput{
+		Bot: application.CreateBotInput{
+			Name:            "backtest",
+			Currency:        *currency,
+			TargetCurrency:  *targetCurrency,
+			MonitorInterval: *monitorInterval,
+			Strategy:        *strategy,
+			StrategyParams:  domain.StrategyParams{},
+		},
+	}
+
+	var err error
+	if input.Bot.TakeProfitPercentaje, err = parseDecimalFlag("take-profit", *takeProfit); err != nil {
+		return err
+	}
+	if input.Bot.InitialCapital, err = parseDecimalFlag("capital", *capital); err != nil {
+		return err
+	}
+	if input.Bot.Delta, err = parseDecimalFlag("delta", *delta); err != nil {
+		return err
+	}
+
+	if *stopLoss != "" {
+		value, err := parseDecimalFlag("stop-loss", *stopLoss)
+		if err != nil {
+			return err
+		}
+
+		input.Bot.StopLoss = &application.StopLossInput{
+			Type:     *stopLossType,
+			Value:    value,
+			Trailing: *trailingStop,
+		}
+	}
+
+	if *adaptiveDelta {
+		adaptiveDeltaInput := &application.AdaptiveDeltaInput{
+			Interval: *atrInterval,
+			Period:   *atrPeriod,
+		}
+		if adaptiveDeltaInput.Multiplier, err = parseDecimalFlag("atr-multiplier", *atrMultiplier); err != nil {
+			return err
+		}
+		if adaptiveDeltaInput.MinDelta, err = parseDecimalFlag("min-delta", *minDelta); err != nil {
+			return err
+		}
+		if adaptiveDeltaInput.MaxDelta, err = parseDecimalFlag("max-delta", *maxDelta); err != nil {
+			return err
+		}
+		hysteresis, err := parseDecimalFlag("delta-hysteresis", *deltaHysteresis)
+		if err != nil {
+			return err
+		}
+		adaptiveDeltaInput.Hysteresis = &hysteresis
+
+		input.Bot.AdaptiveDelta = adaptiveDeltaInput
+	}
+
+	if *params != "" {
+		for _, param := range strings.Split(*params, ",") {
+			key, value, ok := strings.Cut(param, "=")
+			if !ok {
+				return fmt.Errorf("invalid param %q, expected key=value", param)
+			}
+			input.Bot.StrategyParams[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	deps, err := common.BuildDependencies(cfg)
+	if err != nil {
+		return err
+	}
+
+	backtestDeps, err := bots.BuildBacktestDependencies(cfg, deps)
+	if err != nil {
+		return err
+	}
+
+	pair, err := domain.NewSymbol(*targetCurrency, *currency)
+	if err != nil {
+		return err
+	}
+
+	/** Candles are stored with the symbol of Binance, where they come from */
+	symbol := pair.Binance()
+	if *csvFile != "" {
+		file, err := os.Open(*csvFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		input.Candles, err = infrastructure.ReadCandlesCSV(file, symbol, *interval)
+		if err != nil {
+			return err
+		}
+	} else {
+		start, err := time.Parse(time.DateOnly, *from)
+		if err != nil {
+			return fmt.Errorf("invalid from %q, expected 2006-01-02", *from)
+		}
+
+		end, err := time.Parse(time.DateOnly, *to)
+		if err != nil {
+			return fmt.Errorf("invalid to %q, expected 2006-01-02", *to)
+		}
+
+		input.Candles, err = backtestDeps.GetCandles.Exec(ctx, &application.GetCandlesInput{
+			Symbol:   symbol,
+			Interval: *interval,
+			Start:    start,
+			End:      end,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	report, err := backtestDeps.Backtest.Exec(ctx, &input)
+	if err != nil {
+		return err
+	}
+
+	if !*curve {
+		report.EquityCurve = nil
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
+
+func parseDecimalFlag(name string, value string) (decimal.Decimal, error) {
+	number, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid %s %q", name, value)
+	}
+
+	return number, nil
+}
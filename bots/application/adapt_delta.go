@@ -0,0 +1,117 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/indicators"
+	"github.com/juankohler/crypto-bot/libs/go/logs"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+)
+
+type AdaptDeltaInput struct {
+	Bot  *domain.Bot
+	Tick domain.Tick
+}
+
+// Candles fetched before the first tick of a bot, in periods of its ATR, so
+// the smoothing of the average has settled when the Delta is first adapted.
+const adaptiveDeltaWarmUpPeriods = 3
+
+// AdaptDelta moves the Delta of the bots with an adaptive delta to the ATR of
+// their pair. The ATR of every bot is kept between ticks and updated with the
+// candles closed since the last one. The bot is not saved.
+type AdaptDelta struct {
+	candleProvider domain.CandleProvider
+
+	mu     sync.Mutex
+	states map[models.ID]*adaptiveDeltaState
+}
+
+type adaptiveDeltaState struct {
+	adaptiveDelta domain.AdaptiveDelta
+	atr           *indicators.ATR
+	// Open time of the next candle to feed the ATR with.
+	next time.Time
+}
+
+func NewAdaptDelta(candleProvider domain.CandleProvider) *AdaptDelta {
+	return &AdaptDelta{
+		candleProvider: candleProvider,
+		states:         make(map[models.ID]*adaptiveDeltaState),
+	}
+}
+
+func (s *AdaptDelta) Exec(ctx context.Context, input *AdaptDeltaInput) error {
+	bot, tick := input.Bot, input.Tick
+	if bot.AdaptiveDelta == nil {
+		return nil
+	}
+
+	duration, err := domain.CandleIntervalDuration(bot.AdaptiveDelta.Interval)
+	if err != nil {
+		return err
+	}
+
+	/** Only the closed candles, the current one would change until it closes */
+	end := tick.Time.Truncate(duration)
+	state, err := s.state(bot, end, duration)
+	if err != nil {
+		return err
+	}
+
+	if state.next.Before(end) {
+		symbol := bot.Symbol().Binance()
+		candles, err := s.candleProvider.GetCandles(ctx, symbol, state.adaptiveDelta.Interval, state.next, end)
+		if err != nil {
+			/** The Delta stays as it is until the candles can be fetched */
+			logs.Warn(ctx, fmt.Sprintf("could not get %s candles to adapt the delta of %s", symbol, bot.Name), logs.NewAttr("error", err))
+			return nil
+		}
+
+		for _, candle := range candles {
+			state.atr.Update(candle.High, candle.Low, candle.Close)
+		}
+		state.next = end
+	}
+
+	if !state.atr.Ready() {
+		return nil
+	}
+
+	previous := bot.Delta
+	if bot.AdaptDelta(state.atr.Value()) {
+		logs.Info(ctx, fmt.Sprintf("%s: Delta ajustado de %s a %s %s (ATR %s: %s)", bot.Name, previous.String(), bot.Delta.String(), bot.Currency, state.adaptiveDelta.Interval, state.atr.Value().StringFixed(2)))
+	}
+
+	return nil
+}
+
+// state returns the ATR of the bot, starting it over if the bot is new or its
+// adaptive delta changed.
+func (s *AdaptDelta) state(bot *domain.Bot, end time.Time, duration time.Duration) (*adaptiveDeltaState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[bot.ID]
+	if ok && state.adaptiveDelta.Equal(bot.AdaptiveDelta) {
+		return state, nil
+	}
+
+	atr, err := indicators.NewATR(bot.AdaptiveDelta.Period)
+	if err != nil {
+		return nil, err
+	}
+
+	state = &adaptiveDeltaState{
+		adaptiveDelta: *bot.AdaptiveDelta,
+		atr:           atr,
+		next:          end.Add(-duration * time.Duration(adaptiveDeltaWarmUpPeriods*bot.AdaptiveDelta.Period)),
+	}
+	s.states[bot.ID] = state
+
+	return state, nil
+}
@@ -0,0 +1,61 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCandles serves hourly candles with a true range of 200, or fails while
+// the exchange is down.
+type fakeCandles struct {
+	down bool
+}
+
+func (p *fakeCandles) GetCandles(ctx context.Context, symbol string, interval string, start time.Time, end time.Time) ([]*domain.Candle, error) {
+	if p.down {
+		return nil, errors.New(domain.ErrInternal, "exchange is down")
+	}
+
+	var candles []*domain.Candle
+	for openTime := start; openTime.Before(end); openTime = openTime.Add(time.Hour) {
+		candles = append(candles, &domain.Candle{
+			Symbol:    symbol,
+			Interval:  interval,
+			OpenTime:  openTime,
+			CloseTime: openTime.Add(time.Hour),
+			Open:      decimal.NewFromInt(50000),
+			High:      decimal.NewFromInt(50100),
+			Low:       decimal.NewFromInt(49900),
+			Close:     decimal.NewFromInt(50000),
+		})
+	}
+
+	return candles, nil
+}
+
+func TestAdaptDeltaMissingCandles(t *testing.T) {
+	ctx := context.Background()
+	candles := &fakeCandles{down: true}
+	adaptDelta := NewAdaptDelta(candles)
+
+	bot, err := domain.CreateBot("test", "USDT", "BTC", decimal.RequireFromString("0.01"), decimal.NewFromInt(1000), decimal.NewFromInt(100), time.Minute, domain.StrategyGrid, nil, domain.BotModePaper, nil, nil)
+	assert.NoError(t, err)
+	bot.AdaptiveDelta, err = domain.NewAdaptiveDelta("1h", 3, decimal.NewFromInt(1), decimal.NewFromInt(50), decimal.NewFromInt(500), decimal.RequireFromString("0.1"))
+	assert.NoError(t, err)
+
+	/** Without candles the Delta stays as it is and the cycle goes on */
+	now := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+	assert.NoError(t, adaptDelta.Exec(ctx, &AdaptDeltaInput{Bot: bot, Tick: domain.NewTick(decimal.NewFromInt(50000), now)}))
+	assert.Equal(t, "100", bot.Delta.String())
+
+	/** The missing candles are fetched on the next tick */
+	candles.down = false
+	assert.NoError(t, adaptDelta.Exec(ctx, &AdaptDeltaInput{Bot: bot, Tick: domain.NewTick(decimal.NewFromInt(50000), now.Add(time.Minute))}))
+	assert.Equal(t, "200", bot.Delta.String())
+}
@@ -0,0 +1,82 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/logs"
+)
+
+type BackfillCandlesInput struct {
+	Symbol   string
+	Interval string
+	Start    time.Time
+	End      time.Time
+}
+
+type BackfillCandlesOutput struct {
+	Gaps    []domain.CandleGap
+	Candles int
+}
+
+// BackfillCandles fetches from the provider only the closed candles of the
+// range that are not stored yet.
+type BackfillCandles struct {
+	candleRepository domain.CandleRepository
+	candleProvider   domain.CandleProvider
+}
+
+func NewBackfillCandles(
+	candleRepository domain.CandleRepository,
+	candleProvider domain.CandleProvider,
+) *BackfillCandles {
+	return &BackfillCandles{
+		candleRepository: candleRepository,
+		candleProvider:   candleProvider,
+	}
+}
+
+func (s *BackfillCandles) Exec(ctx context.Context, input *BackfillCandlesInput) (*BackfillCandlesOutput, error) {
+	duration, err := domain.CandleIntervalDuration(input.Interval)
+	if err != nil {
+		return nil, err
+	}
+
+	/** The current candle is not closed yet */
+	end := input.End
+	if closed := time.Now().Truncate(duration); end.After(closed) {
+		end = closed
+	}
+
+	output := &BackfillCandlesOutput{}
+	if !input.Start.Before(end) {
+		return output, nil
+	}
+
+	output.Gaps, err = s.candleRepository.FindGaps(ctx, input.Symbol, input.Interval, input.Start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, gap := range output.Gaps {
+		candles, err := s.candleProvider.GetCandles(ctx, input.Symbol, input.Interval, gap.Start, gap.End)
+		if err != nil {
+			return nil, errors.Wrap(domain.ErrInternal, err, "could not get candles from provider")
+		}
+
+		if err := s.candleRepository.SaveCandles(ctx, candles); err != nil {
+			return nil, err
+		}
+
+		output.Candles += len(candles)
+	}
+
+	if output.Candles > 0 {
+		logs.Info(ctx, fmt.Sprintf("%d velas %s %s guardadas en %d huecos", output.Candles, input.Symbol, input.Interval, len(output.Gaps)))
+	}
+
+	return output, nil
+}
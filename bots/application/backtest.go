@@ -0,0 +1,246 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/logs"
+	"github.com/shopspring/decimal"
+)
+
+type BacktestInput struct {
+	Bot     CreateBotInput
+	Candles []*domain.Candle
+}
+
+type BacktestReport struct {
+	// Pair of the bot, e.g. BTC-USDT. The amounts are in its quote asset.
+	Symbol         string          `json:"symbol"`
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	Candles        int             `json:"candles"`
+	InitialCapital decimal.Decimal `json:"initial_capital"`
+	TotalCapital   decimal.Decimal `json:"total_capital"`
+	// Net profit of the positions sold, after fees.
+	RealizedPnL decimal.Decimal `json:"realized_pnl"`
+	FeesPaid    decimal.Decimal `json:"fees_paid"`
+	// Value of the bot at the last close price, including the open positions.
+	FinalEquity   decimal.Decimal `json:"final_equity"`
+	Trades        int             `json:"trades"`
+	WinningTrades int             `json:"winning_trades"`
+	// Trades closed by the stop loss.
+	StopLosses int             `json:"stop_losses"`
+	WinRate    decimal.Decimal `json:"win_rate"`
+	// Largest drop of the equity from a previous peak, as a fraction of it.
+	MaxDrawdown decimal.Decimal `json:"max_drawdown"`
+	OpenOrders  int             `json:"open_orders"`
+	EquityCurve []EquityPoint   `json:"equity_curve"`
+}
+
+type EquityPoint struct {
+	Time   time.Time       `json:"time"`
+	Equity decimal.Decimal `json:"equity"`
+}
+
+// NewReplayProvider creates a simulated provider starting with the given
+// balances, one for each backtest.
+type NewReplayProvider func(balances map[string]decimal.Decimal) (domain.ReplayProvider, error)
+
+// Backtest runs a bot over historical candles on a virtual clock: every candle
+// is replayed as the open, low, high and close prices spread over its
+// interval, and the bot is executed, as it would be live, whenever its monitor
+// interval elapses on that clock. Orders are filled by a replay provider, so
+// fees and slippage are simulated as in paper trading.
+type Backtest struct {
+	strategies        *domain.StrategyRegistry
+	newReplayProvider NewReplayProvider
+	// Optional, to round and validate the orders as the exchange would.
+	symbolFilters domain.SymbolFiltersProvider
+	fees          domain.FeeSchedule
+	riskLimits    domain.RiskLimits
+}
+
+func NewBacktest(
+	strategies *domain.StrategyRegistry,
+	newReplayProvider NewReplayProvider,
+	symbolFilters domain.SymbolFiltersProvider,
+	fees domain.FeeSchedule,
+	riskLimits domain.RiskLimits,
+) *Backtest {
+	return &Backtest{
+		strategies:        strategies,
+		newReplayProvider: newReplayProvider,
+		symbolFilters:     symbolFilters,
+		fees:              fees,
+		riskLimits:        riskLimits,
+	}
+}
+
+func (s *Backtest) Exec(ctx context.Context, input *BacktestInput) (*BacktestReport, error) {
+	if len(input.Candles) == 0 {
+		return nil, errors.New(domain.ErrInvalid, "there are no candles to backtest")
+	}
+
+	botInput := input.Bot
+	botInput.Mode = domain.BotModePaper
+	bot, err := newBotFromInput(&botInput, s.strategies)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := s.newReplayProvider(map[string]decimal.Decimal{
+		bot.Currency: bot.InitialCapital,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	/** Fetched once, the backtest can still run without them, e.g. offline */
+	var symbolFilters domain.SymbolFiltersProvider
+	if s.symbolFilters != nil {
+		filters, err := s.symbolFilters.GetSymbolFilters(ctx, bot.TargetCurrency, bot.Currency)
+		if err != nil {
+			logs.Warn(ctx, "running the backtest without the filters of the pair", logs.NewAttr("error", err))
+		} else {
+			symbolFilters = staticSymbolFilters{filters: filters}
+		}
+	}
+
+	providers := domain.NewProviders(provider, provider, true)
+	risk := domain.NewRiskManager(s.riskLimits)
+	executeBot := NewExecuteBot(
+		providers,
+		s.strategies,
+		NewReconcileOrders(providers, symbolFilters, s.fees),
+		NewTriggerStopLosses(providers, symbolFilters, s.fees),
+		NewAdaptDelta(&staticCandles{candles: input.Candles}),
+		symbolFilters,
+		s.fees,
+		risk,
+	)
+
+	report := &BacktestReport{
+		Symbol:         bot.Symbol().String(),
+		From:           input.Candles[0].OpenTime,
+		To:             input.Candles[len(input.Candles)-1].CloseTime,
+		Candles:        len(input.Candles),
+		InitialCapital: bot.InitialCapital,
+	}
+
+	var lastExecution time.Time
+	peak := bot.InitialCapital
+	for _, candle := range input.Candles {
+		path := candle.Path()
+		step := candle.CloseTime.Sub(candle.OpenTime) / time.Duration(len(path)-1)
+
+		for i, price := range path {
+			now := candle.OpenTime.Add(step * time.Duration(i))
+
+			if err := provider.FeedPrice(ctx, bot.TargetCurrency, bot.Currency, price); err != nil {
+				return nil, err
+			}
+
+			if !lastExecution.IsZero() && now.Sub(lastExecution) < bot.MonitorInterval {
+				continue
+			}
+			lastExecution = now
+
+			if err := executeBot.Exec(ctx, &ExecuteBotInput{Bot: bot, Tick: domain.NewTick(price, now)}); err != nil {
+				logs.Error(ctx, fmt.Sprintf("error in %s strategy", bot.Name), logs.NewAttr("time", now), logs.NewAttr("error", err))
+			}
+
+			s.countTrades(bot, report)
+		}
+
+		equity := bot.MarkToMarket(candle.Close)
+		report.EquityCurve = append(report.EquityCurve, EquityPoint{
+			Time:   candle.CloseTime,
+			Equity: equity,
+		})
+
+		if equity.GreaterThan(peak) {
+			peak = equity
+		}
+		if drawdown := peak.Sub(equity).Div(peak); drawdown.GreaterThan(report.MaxDrawdown) {
+			report.MaxDrawdown = drawdown
+		}
+	}
+
+	report.TotalCapital = bot.TotalCapital
+	report.RealizedPnL = bot.RealizedPnL
+	report.FeesPaid = bot.FeesPaid
+	report.FinalEquity = report.EquityCurve[len(report.EquityCurve)-1].Equity
+	report.OpenOrders = len(bot.OpenOrders)
+	if report.Trades > 0 {
+		report.WinRate = decimal.NewFromInt(int64(report.WinningTrades)).Div(decimal.NewFromInt(int64(report.Trades)))
+	}
+
+	return report, nil
+}
+
+// countTrades adds to the report the take profits and stop losses executed
+// since the last call, winning when their net profit is positive, and clears
+// the closed orders, as saving the bot would.
+func (s *Backtest) countTrades(bot *domain.Bot, report *BacktestReport) {
+	for _, order := range bot.ClosedOrders {
+		if !order.IsTakeProfit() || !order.ExecutedQuantity.IsPositive() {
+			continue
+		}
+
+		report.Trades++
+		if order.RealizedPnL.IsPositive() {
+			report.WinningTrades++
+		}
+		if order.IsStopLoss() {
+			report.StopLosses++
+		}
+	}
+
+	bot.ClosedOrders = nil
+}
+
+// staticSymbolFilters returns the same filters for every pair, the backtested
+// one.
+type staticSymbolFilters struct {
+	filters *domain.SymbolFilters
+}
+
+func (p staticSymbolFilters) GetSymbolFilters(ctx context.Context, baseCurrency string, quoteCurrency string) (*domain.SymbolFilters, error) {
+	return p.filters, nil
+}
+
+// staticCandles serves the backtested candles, resampled to the requested
+// interval, which must not be shorter than theirs. The candles of the range
+// before the first one are just missing.
+type staticCandles struct {
+	candles   []*domain.Candle
+	resampled map[string][]*domain.Candle
+}
+
+func (p *staticCandles) GetCandles(ctx context.Context, symbol string, interval string, start time.Time, end time.Time) ([]*domain.Candle, error) {
+	candles, ok := p.resampled[interval]
+	if !ok {
+		var err error
+		candles, err = domain.ResampleCandles(p.candles, interval)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.resampled == nil {
+			p.resampled = make(map[string][]*domain.Candle)
+		}
+		p.resampled[interval] = candles
+	}
+
+	var inRange []*domain.Candle
+	for _, candle := range candles {
+		if !candle.OpenTime.Before(start) && candle.OpenTime.Before(end) {
+			inRange = append(inRange, candle)
+		}
+	}
+
+	return inRange, nil
+}
@@ -0,0 +1,85 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/bots/infrastructure"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBacktestReport(t *testing.T) {
+	strategies, err := domain.NewStrategyRegistry(domain.NewGridStrategy())
+	assert.NoError(t, err)
+	newReplayProvider := func(balances map[string]decimal.Decimal) (domain.ReplayProvider, error) {
+		return infrastructure.NewReplayRepo(infrastructure.PaperConfig{Balances: balances})
+	}
+	/** One position at a time, sold by its take profit or its stop loss */
+	backtest := NewBacktest(strategies, newReplayProvider, nil, domain.FeeSchedule{}, domain.RiskLimits{MaxOpenOrders: 1})
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var candles []*domain.Candle
+	for i, prices := range [][4]int64{
+		/** Open, high, low and close. The bot runs on every open */
+		{10000, 10000, 10000, 10000},
+		{10000, 10150, 9950, 10100},
+		{12500, 12500, 12500, 12500},
+		{12500, 12500, 11900, 11950},
+		{11950, 11950, 11950, 11950},
+		{10000, 10000, 10000, 10000},
+	} {
+		openTime := start.Add(time.Duration(i) * time.Minute)
+		candle, err := domain.NewCandle("BTC-USDT", "1m", openTime, openTime.Add(time.Minute-time.Millisecond), decimal.NewFromInt(prices[0]), decimal.NewFromInt(prices[1]), decimal.NewFromInt(prices[2]), decimal.NewFromInt(prices[3]), decimal.Zero)
+		assert.NoError(t, err)
+		candles = append(candles, candle)
+	}
+
+	input := &BacktestInput{
+		Bot: CreateBotInput{
+			Name:                 "backtest",
+			Currency:             "USDT",
+			TargetCurrency:       "BTC",
+			TakeProfitPercentaje: decimal.RequireFromString("0.01"),
+			InitialCapital:       decimal.NewFromInt(1000),
+			Delta:                decimal.NewFromInt(100),
+			MonitorInterval:      "1m",
+			Strategy:             domain.StrategyGrid,
+			StrategyParams:       domain.StrategyParams{domain.GridParamOrders: "10"},
+			StopLoss:             &StopLossInput{Type: domain.StopLossTypeAbsolute, Value: decimal.NewFromInt(500)},
+		},
+		Candles: candles,
+	}
+	report, err := backtest.Exec(context.Background(), input)
+	assert.NoError(t, err)
+
+	/** Bought at 10000 and sold at 10100, then bought at 12500 and stopped
+	out at 11950, then bought again at 10000 */
+	assert.Equal(t, "BTC-USDT", report.Symbol)
+	assert.Equal(t, start, report.From)
+	assert.Equal(t, 6, report.Candles)
+	assert.Equal(t, 2, report.Trades)
+	assert.Equal(t, 1, report.WinningTrades)
+	assert.Equal(t, 1, report.StopLosses)
+	assert.Equal(t, "0.5", report.WinRate.String())
+	assert.Equal(t, "-3.4", report.RealizedPnL.String())
+	assert.Equal(t, "996.6", report.TotalCapital.String())
+	assert.Equal(t, "996.6", report.FinalEquity.String())
+	assert.Equal(t, 1, report.OpenOrders)
+	/** From the peak of 1001 after the first trade */
+	assert.Equal(t, "0.0043956043956044", report.MaxDrawdown.String())
+
+	equity := make([]string, len(report.EquityCurve))
+	for i, point := range report.EquityCurve {
+		assert.Equal(t, candles[i].CloseTime, point.Time)
+		equity[i] = point.Equity.String()
+	}
+	assert.Equal(t, []string{"1000", "1001", "1001", "996.6", "996.6", "996.6"}, equity)
+
+	/** The same candles give the same report */
+	again, err := backtest.Exec(context.Background(), input)
+	assert.NoError(t, err)
+	assert.Equal(t, report, again)
+}
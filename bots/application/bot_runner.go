@@ -0,0 +1,481 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/logs"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+)
+
+// Variables, not constants, so the tests can shorten them.
+var (
+	// Wait between executions while the bot can't be loaded.
+	idleInterval = 5 * time.Second
+	// Interval to look for bots created, paused, resumed or deleted through
+	// the API.
+	syncInterval = 5 * time.Second
+	// Wait before restarting a worker after a panic, doubled on every
+	// consecutive one.
+	minRestartBackoff = time.Second
+	maxRestartBackoff = time.Minute
+)
+
+const (
+	BotWorkerStateRunning    = "RUNNING"
+	BotWorkerStateRestarting = "RESTARTING"
+	// Stopped through the controls, it is not started again until asked to.
+	BotWorkerStateStopped = "STOPPED"
+	// The bot was paused or deleted, it is started again once it is active.
+	BotWorkerStateFinished = "FINISHED"
+)
+
+type BotWorkerStatus struct {
+	BotID         models.ID
+	BotName       string
+	State         string
+	StartedAt     time.Time
+	LastExecution *time.Time
+	NextExecution *time.Time
+	Restarts      int
+	LastError     *string
+}
+
+// BotRunner runs every active bot in its own worker, executing it each time
+// its monitor interval elapses. Workers that panic are restarted with backoff
+// and, on shutdown, every worker finishes the execution in progress, saving
+// the bot, before it stops. A bot that could not be saved is kept and saved
+// again before it is executed, so the orders it placed are never lost.
+type BotRunner struct {
+	botRepository domain.BotRepository
+	providers     *domain.Providers
+	executeBot    *ExecuteBot
+	risk          *domain.RiskManager
+	// Optional, without it bots only see the price when they are executed.
+	stream domain.MarketDataStream
+
+	mu      sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers map[models.ID]*botWorker
+	wg      sync.WaitGroup
+}
+
+type botWorker struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	// Bot executed but not saved yet, its orders may be placed already. Only
+	// used by the goroutine of the worker, across its restarts.
+	unsaved *domain.Bot
+
+	mu     sync.Mutex
+	status BotWorkerStatus
+}
+
+func NewBotRunner(
+	botRepository domain.BotRepository,
+	providers *domain.Providers,
+	executeBot *ExecuteBot,
+	risk *domain.RiskManager,
+	stream domain.MarketDataStream,
+) *BotRunner {
+	return &BotRunner{
+		botRepository: botRepository,
+		providers:     providers,
+		executeBot:    executeBot,
+		risk:          risk,
+		stream:        stream,
+		workers:       make(map[models.ID]*botWorker),
+	}
+}
+
+// Start starts the workers of the active bots and keeps them in sync with the
+// bots in the repository until Shutdown.
+func (r *BotRunner) Start(ctx context.Context) error {
+	bots, err := r.botRepository.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, bot := range bots {
+		logs.Info(ctx, fmt.Sprintf("Bot %s cargado, estado: %s, modo: %s, estrategia: %s, capital_disponible: %s, capital_invertido: %s, ordenes_abiertas: %d", bot.Name, bot.Status, bot.Mode, bot.Strategy, bot.AvailableCapital.String(), bot.InvestedCapital.String(), len(bot.OpenOrders)))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ctx != nil {
+		return errors.New(domain.ErrConflict, "bot runner already started")
+	}
+	r.ctx, r.cancel = context.WithCancel(ctx)
+
+	for _, bot := range bots {
+		if bot.IsActive() {
+			r.startWorker(bot)
+		}
+	}
+
+	r.wg.Add(1)
+	go r.sync()
+
+	return nil
+}
+
+// Shutdown stops every worker and waits for them to save their bots, until the
+// context is done.
+func (r *BotRunner) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logs.Info(ctx, "Bots detenidos")
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(domain.ErrInternal, ctx.Err(), "bots did not stop in time")
+	}
+}
+
+// StartBot starts the worker of an active bot, also when it was stopped.
+func (r *BotRunner) StartBot(ctx context.Context, id models.ID) error {
+	bot, err := r.botRepository.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !bot.IsActive() {
+		return errors.New(domain.ErrInvalid, "bot is not active", errors.WithMetadata("id", id))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ctx == nil || r.ctx.Err() != nil {
+		return errors.New(domain.ErrInvalid, "bot runner is not running")
+	}
+
+	if worker, ok := r.workers[id]; ok && !worker.finished() {
+		return nil
+	}
+
+	r.startWorker(bot)
+
+	return nil
+}
+
+// StopBot stops the worker of a bot, after the execution in progress, until
+// it is started again through StartBot or the process is restarted.
+func (r *BotRunner) StopBot(ctx context.Context, id models.ID) error {
+	bot, err := r.botRepository.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	worker, ok := r.workers[id]
+	if !ok {
+		worker = &botWorker{done: make(chan struct{})}
+		close(worker.done)
+		r.workers[id] = worker
+	}
+	worker.setState(BotWorkerStateStopped, func(status *BotWorkerStatus) {
+		status.BotID = bot.ID
+		status.BotName = bot.Name
+	})
+	r.mu.Unlock()
+
+	if worker.cancel != nil {
+		worker.cancel()
+	}
+
+	select {
+	case <-worker.done:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(domain.ErrInternal, ctx.Err(), "bot did not stop in time", errors.WithMetadata("id", id))
+	}
+}
+
+// Status returns the state of the worker of every bot started since the
+// runner started, sorted by name.
+func (r *BotRunner) Status() []BotWorkerStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := make([]BotWorkerStatus, 0, len(r.workers))
+	for _, worker := range r.workers {
+		worker.mu.Lock()
+		statuses = append(statuses, worker.status)
+		worker.mu.Unlock()
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].BotName < statuses[j].BotName
+	})
+
+	return statuses
+}
+
+// sync starts the workers of the bots that became active.
+func (r *BotRunner) sync() {
+	defer r.wg.Done()
+
+	ctx := r.ctx
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(syncInterval):
+		}
+
+		bots, err := r.botRepository.FindAll(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				logs.Error(ctx, "could not find bots", logs.NewAttr("error", err))
+			}
+			continue
+		}
+
+		r.mu.Lock()
+		for _, bot := range bots {
+			if !bot.IsActive() || ctx.Err() != nil {
+				continue
+			}
+
+			worker, ok := r.workers[bot.ID]
+			if ok && worker.state() != BotWorkerStateFinished {
+				continue
+			}
+
+			r.startWorker(bot)
+		}
+		r.mu.Unlock()
+	}
+}
+
+// startWorker must be called holding the lock.
+func (r *BotRunner) startWorker(bot *domain.Bot) {
+	ctx, cancel := context.WithCancel(r.ctx)
+	worker := &botWorker{
+		cancel: cancel,
+		done:   make(chan struct{}),
+		status: BotWorkerStatus{
+			BotID:     bot.ID,
+			BotName:   bot.Name,
+			State:     BotWorkerStateRunning,
+			StartedAt: time.Now(),
+		},
+	}
+	r.workers[bot.ID] = worker
+
+	r.wg.Add(1)
+	go r.supervise(ctx, bot.ID, worker)
+}
+
+// supervise runs the worker of a bot, restarting it after a panic, until the
+// bot stops being active or the worker is stopped.
+func (r *BotRunner) supervise(ctx context.Context, id models.ID, worker *botWorker) {
+	defer r.wg.Done()
+	defer close(worker.done)
+	defer worker.cancel()
+
+	restarts := 0
+	for {
+		err := r.run(ctx, id, worker)
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+
+		restarts++
+		backoff := minRestartBackoff << min(restarts-1, 6)
+		if backoff > maxRestartBackoff {
+			backoff = maxRestartBackoff
+		}
+
+		logs.Error(ctx, "bot worker panicked, restarting", logs.NewAttr("id", id), logs.NewAttr("restarts", restarts), logs.NewAttr("error", err))
+		worker.setState(BotWorkerStateRestarting, func(status *BotWorkerStatus) {
+			message := err.Error()
+			status.Restarts = restarts
+			status.LastError = &message
+			status.NextExecution = nil
+		})
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		worker.setState(BotWorkerStateRunning, nil)
+	}
+
+	worker.setState(BotWorkerStateFinished, func(status *BotWorkerStatus) {
+		status.NextExecution = nil
+	})
+}
+
+// run executes the bot every monitor interval, or earlier when the market data
+// stream sees one of its take profits or stop losses reached. It returns nil
+// once the bot is no longer active or the worker is stopped, and an error if
+// it panics.
+func (r *BotRunner) run(ctx context.Context, id models.ID, worker *botWorker) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = errors.New(
+				domain.ErrInternal,
+				fmt.Sprintf("panic: %v", recovered),
+				errors.WithMetadata("id", id),
+				errors.WithMetadata("stack", string(debug.Stack())),
+			)
+		}
+	}()
+
+	var watcher *marketWatcher
+	defer func() {
+		if watcher != nil {
+			watcher.close()
+		}
+	}()
+	wake := make(chan struct{}, 1)
+
+	for {
+		var bot *domain.Bot
+		var err error
+		if worker.unsaved != nil && !r.save(context.WithoutCancel(ctx), worker) {
+			/** The last execution is saved before the bot is loaded and run
+			again, otherwise the orders it placed would be lost */
+			if ctx.Err() != nil {
+				return nil
+			}
+		} else if bot, err = r.botRepository.FindByID(ctx, id); err != nil {
+			/** Reloaded every time to see the changes made through the API */
+			if errors.Is(err, domain.ErrNotFound) {
+				/** Deleted, its exposure no longer counts */
+				r.risk.Forget(id)
+				return nil
+			}
+
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			logs.Error(ctx, "could not find bot", logs.NewAttr("id", id), logs.NewAttr("error", err))
+		} else {
+			if !bot.IsActive() {
+				return nil
+			}
+
+			if watcher == nil && r.stream != nil {
+				watcher, err = newMarketWatcher(ctx, r.stream, bot, r.providers.ForBot(bot), wake)
+				if err != nil {
+					logs.Error(ctx, fmt.Sprintf("could not watch the market of %s bot", bot.Name), logs.NewAttr("error", err))
+				}
+			}
+
+			/** The execution in progress is finished and saved even if the
+			worker is stopped meanwhile, so no order is left half recorded */
+			r.execute(context.WithoutCancel(ctx), bot, worker)
+
+			if watcher != nil {
+				watcher.watchExits(bot)
+			}
+		}
+
+		/** An unsaved bot is saved again soon */
+		interval := idleInterval
+		if bot != nil && worker.unsaved == nil {
+			interval = bot.MonitorInterval
+		}
+
+		now := time.Now()
+		next := now.Add(interval)
+		worker.setState(worker.state(), func(status *BotWorkerStatus) {
+			status.LastExecution = &now
+			status.NextExecution = &next
+		})
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		case <-wake:
+			watcher.takeCrossed()
+		}
+	}
+}
+
+func (r *BotRunner) execute(ctx context.Context, bot *domain.Bot, worker *botWorker) {
+	currentPrice, err := r.providers.ForBot(bot).GetPrice(ctx, bot.TargetCurrency, bot.Currency)
+	if err != nil {
+		logs.Error(ctx, "could not get price", logs.NewAttr("error", err))
+		return
+	}
+
+	/** Kept by the worker until it is saved, also when the execution panics
+	after an order was placed */
+	worker.unsaved = bot
+
+	tick := domain.NewTick(currentPrice.Price, time.Now())
+	if err := r.executeBot.Exec(ctx, &ExecuteBotInput{Bot: bot, Tick: tick}); err != nil {
+		logs.Error(ctx, fmt.Sprintf("error in %s strategy", bot.Name), logs.NewAttr("error", err))
+	}
+
+	r.save(ctx, worker)
+}
+
+// save stores the bot last executed by the worker and reports whether it was
+// saved. It is kept to be saved again otherwise.
+func (r *BotRunner) save(ctx context.Context, worker *botWorker) bool {
+	bot := worker.unsaved
+	if err := r.botRepository.Save(ctx, bot); err != nil {
+		logs.Error(ctx, fmt.Sprintf("could not save %s bot", bot.Name), logs.NewAttr("error", err))
+		return false
+	}
+
+	worker.unsaved = nil
+	return true
+}
+
+func (w *botWorker) state() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.status.State
+}
+
+func (w *botWorker) finished() bool {
+	state := w.state()
+	return state == BotWorkerStateFinished || state == BotWorkerStateStopped
+}
+
+// setState changes the state of the worker and applies the other changes of
+// its status, if any. A stopped worker stays stopped.
+func (w *botWorker) setState(state string, update func(status *BotWorkerStatus)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.status.State != BotWorkerStateStopped || state == BotWorkerStateStopped {
+		w.status.State = state
+	}
+
+	if update != nil {
+		update(&w.status)
+	}
+}
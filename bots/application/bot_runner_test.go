@@ -0,0 +1,247 @@
+package application
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/bots/infrastructure"
+	"github.com/juankohler/crypto-bot/database"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/migrations"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+// hookedBotRepo is a bot repository that panics on the first loads, fails the
+// first saves or holds a save until it is released.
+type hookedBotRepo struct {
+	domain.BotRepository
+
+	mu         sync.Mutex
+	findPanics int
+	panics     []time.Time
+	saveErrs   int
+	// Closed when a save starts, which waits for release.
+	saving  chan struct{}
+	release chan struct{}
+}
+
+func (r *hookedBotRepo) FindByID(ctx context.Context, id models.ID) (*domain.Bot, error) {
+	r.mu.Lock()
+	if r.findPanics > 0 {
+		r.findPanics--
+		r.panics = append(r.panics, time.Now())
+		r.mu.Unlock()
+		panic("bot repository down")
+	}
+	r.mu.Unlock()
+
+	return r.BotRepository.FindByID(ctx, id)
+}
+
+func (r *hookedBotRepo) Save(ctx context.Context, bot *domain.Bot) error {
+	r.mu.Lock()
+	saving, release := r.saving, r.release
+	r.saving = nil
+	if r.saveErrs > 0 {
+		r.saveErrs--
+		r.mu.Unlock()
+		return errors.New(domain.ErrInternal, "database is locked")
+	}
+	r.mu.Unlock()
+
+	if saving != nil {
+		close(saving)
+		<-release
+	}
+
+	return r.BotRepository.Save(ctx, bot)
+}
+
+func newTestDB(t *testing.T) *sqlx.DB {
+	db, err := sqlx.Connect("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	/** Every connection to :memory: is a different database */
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db, database.Migrations())
+	assert.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	assert.NoError(t, err)
+
+	return db
+}
+
+func newTestBotRepo(t *testing.T) domain.BotRepository {
+	repo, err := infrastructure.NewSQLiteBotRepo(newTestDB(t))
+	assert.NoError(t, err)
+
+	return repo
+}
+
+func newTestBotRunner(t *testing.T) (*BotRunner, *hookedBotRepo, *fakeProvider, *domain.Bot) {
+	idle, syncEvery, minBackoff, maxBackoff := idleInterval, syncInterval, minRestartBackoff, maxRestartBackoff
+	idleInterval, syncInterval, minRestartBackoff, maxRestartBackoff = 10*time.Millisecond, 10*time.Millisecond, 20*time.Millisecond, 40*time.Millisecond
+	t.Cleanup(func() {
+		idleInterval, syncInterval, minRestartBackoff, maxRestartBackoff = idle, syncEvery, minBackoff, maxBackoff
+	})
+
+	repo := &hookedBotRepo{BotRepository: newTestBotRepo(t)}
+
+	bot := newTestBot(t)
+	/** The shortest one, for the workers to see the pauses and deletions soon */
+	bot.MonitorInterval = time.Second
+	assert.NoError(t, repo.Save(context.Background(), bot))
+
+	provider := &fakeProvider{}
+	providers := domain.NewProviders(provider, provider, true)
+	strategies, err := domain.NewStrategyRegistry(domain.NewGridStrategy())
+	assert.NoError(t, err)
+	risk := domain.NewRiskManager(domain.RiskLimits{})
+	executeBot := NewExecuteBot(
+		providers,
+		strategies,
+		NewReconcileOrders(providers, nil, domain.FeeSchedule{}),
+		NewTriggerStopLosses(providers, nil, domain.FeeSchedule{}),
+		NewAdaptDelta(nil),
+		nil,
+		domain.FeeSchedule{},
+		risk,
+	)
+
+	runner := NewBotRunner(repo, providers, executeBot, risk, nil)
+	t.Cleanup(func() { runner.Shutdown(context.Background()) })
+
+	return runner, repo, provider, bot
+}
+
+func workerState(runner *BotRunner, id models.ID) string {
+	for _, status := range runner.Status() {
+		if status.BotID == id {
+			return status.State
+		}
+	}
+
+	return ""
+}
+
+func TestBotRunnerRestartsAfterPanic(t *testing.T) {
+	ctx := context.Background()
+	runner, repo, provider, bot := newTestBotRunner(t)
+	repo.findPanics = 2
+
+	assert.NoError(t, runner.Start(ctx))
+
+	/** Executed once the repository stops panicking */
+	assert.Eventually(t, func() bool {
+		stored, err := repo.BotRepository.FindByID(ctx, bot.ID)
+		return err == nil && len(stored.OpenOrders) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	status := runner.Status()[0]
+	assert.Equal(t, BotWorkerStateRunning, status.State)
+	assert.Equal(t, 2, status.Restarts)
+	assert.Contains(t, *status.LastError, "bot repository down")
+
+	/** Waiting longer after every panic */
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	assert.Len(t, repo.panics, 2)
+	assert.GreaterOrEqual(t, repo.panics[1].Sub(repo.panics[0]), minRestartBackoff)
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	assert.Equal(t, 1, provider.created)
+}
+
+func TestBotRunnerStopAndStart(t *testing.T) {
+	ctx := context.Background()
+	runner, repo, _, bot := newTestBotRunner(t)
+	assert.NoError(t, runner.Start(ctx))
+	assert.Equal(t, BotWorkerStateRunning, workerState(runner, bot.ID))
+
+	/** Not started again by the sync until asked to */
+	assert.NoError(t, runner.StopBot(ctx, bot.ID))
+	assert.Equal(t, BotWorkerStateStopped, workerState(runner, bot.ID))
+	time.Sleep(5 * syncInterval)
+	assert.Equal(t, BotWorkerStateStopped, workerState(runner, bot.ID))
+
+	assert.NoError(t, runner.StartBot(ctx, bot.ID))
+	assert.Equal(t, BotWorkerStateRunning, workerState(runner, bot.ID))
+
+	/** Paused, the worker finishes and can't be started */
+	_, err := NewPauseBot(repo).Exec(ctx, &PauseBotInput{ID: bot.ID})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return workerState(runner, bot.ID) == BotWorkerStateFinished
+	}, 2*bot.MonitorInterval, 5*time.Millisecond)
+	assert.ErrorIs(t, runner.StartBot(ctx, bot.ID), domain.ErrInvalid)
+
+	/** Resumed, the sync starts it again */
+	_, err = NewResumeBot(repo).Exec(ctx, &ResumeBotInput{ID: bot.ID})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return workerState(runner, bot.ID) == BotWorkerStateRunning
+	}, time.Second, 5*time.Millisecond)
+
+	/** Deleted, it is gone for the controls too */
+	assert.NoError(t, NewDeleteBot(repo).Exec(ctx, &DeleteBotInput{ID: bot.ID}))
+	assert.Eventually(t, func() bool {
+		return workerState(runner, bot.ID) == BotWorkerStateFinished
+	}, 2*bot.MonitorInterval, 5*time.Millisecond)
+	assert.ErrorIs(t, runner.StartBot(ctx, bot.ID), domain.ErrNotFound)
+	assert.ErrorIs(t, runner.StopBot(ctx, bot.ID), domain.ErrNotFound)
+}
+
+func TestBotRunnerShutdownWaitsForSave(t *testing.T) {
+	ctx := context.Background()
+	runner, repo, _, bot := newTestBotRunner(t)
+	repo.saving, repo.release = make(chan struct{}), make(chan struct{})
+	saving, release := repo.saving, repo.release
+
+	assert.NoError(t, runner.Start(ctx))
+	<-saving
+
+	done := make(chan error)
+	go func() {
+		done <- runner.Shutdown(ctx)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("shutdown did not wait for the save")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	assert.NoError(t, <-done)
+
+	stored, err := repo.BotRepository.FindByID(ctx, bot.ID)
+	assert.NoError(t, err)
+	assert.Len(t, stored.OpenOrders, 1)
+	assert.Equal(t, BotWorkerStateFinished, workerState(runner, bot.ID))
+}
+
+func TestBotRunnerSavesBeforeExecutingAgain(t *testing.T) {
+	ctx := context.Background()
+	runner, repo, provider, bot := newTestBotRunner(t)
+	repo.saveErrs = 3
+
+	assert.NoError(t, runner.Start(ctx))
+	assert.Eventually(t, func() bool {
+		stored, err := repo.BotRepository.FindByID(ctx, bot.ID)
+		return err == nil && len(stored.OpenOrders) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	/** The buy is not placed again from the bot stored before it */
+	time.Sleep(10 * idleInterval)
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	assert.Equal(t, 1, provider.created)
+}
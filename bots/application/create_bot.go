@@ -0,0 +1,180 @@
+package application
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+)
+
+type CreateBotInput struct {
+	Name                 string                `json:"name"`
+	Currency             string                `json:"currency"`
+	TargetCurrency       string                `json:"target_currency"`
+	TakeProfitPercentaje decimal.Decimal       `json:"take_profit_percentaje"`
+	InitialCapital       decimal.Decimal       `json:"initial_capital"`
+	Delta                decimal.Decimal       `json:"delta"`
+	MonitorInterval      string                `json:"monitor_interval"`
+	Strategy             string                `json:"strategy"`
+	StrategyParams       domain.StrategyParams `json:"strategy_params"`
+	Mode                 string                `json:"mode"`
+	StopLoss             *StopLossInput        `json:"stop_loss"`
+	AdaptiveDelta        *AdaptiveDeltaInput   `json:"adaptive_delta"`
+}
+
+type StopLossInput struct {
+	// PERCENTAGE, the default, or ABSOLUTE.
+	Type     string          `json:"type"`
+	Value    decimal.Decimal `json:"value"`
+	Trailing bool            `json:"trailing"`
+}
+
+// AdaptiveDeltaInput makes the Delta follow the ATR of the pair, starting
+// from the Delta of the bot.
+type AdaptiveDeltaInput struct {
+	// Candle interval of the ATR, 1h by default.
+	Interval string `json:"interval"`
+	// 14 candles by default.
+	Period int `json:"period"`
+	// 1 by default.
+	Multiplier decimal.Decimal `json:"multiplier"`
+	MinDelta   decimal.Decimal `json:"min_delta"`
+	MaxDelta   decimal.Decimal `json:"max_delta"`
+	// 0.1 by default.
+	Hysteresis *decimal.Decimal `json:"hysteresis"`
+}
+
+type CreateBot struct {
+	botRepository   domain.BotRepository
+	strategies      *domain.StrategyRegistry
+	symbolValidator domain.SymbolValidator
+}
+
+func NewCreateBot(
+	botRepository domain.BotRepository,
+	strategies *domain.StrategyRegistry,
+	symbolValidator domain.SymbolValidator,
+) *CreateBot {
+	return &CreateBot{
+		botRepository:   botRepository,
+		strategies:      strategies,
+		symbolValidator: symbolValidator,
+	}
+}
+
+func (s *CreateBot) Exec(ctx context.Context, input *CreateBotInput) (*domain.Bot, error) {
+	bot, err := newBotFromInput(input, s.strategies)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.symbolValidator.ValidateSymbol(ctx, bot.Symbol()); err != nil {
+		/** Not wrapped, the client would get the not found of the symbol */
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, errors.New(
+				domain.ErrInvalid,
+				"symbol is not trading",
+				errors.WithMetadata("symbol", bot.Symbol().String()),
+				errors.WithMetadata("cause", err.Error()),
+			)
+		}
+
+		return nil, err
+	}
+
+	if err := s.botRepository.Save(ctx, bot); err != nil {
+		return nil, err
+	}
+
+	return bot, nil
+}
+
+func newBotFromInput(input *CreateBotInput, strategies *domain.StrategyRegistry) (*domain.Bot, error) {
+	monitorInterval, err := time.ParseDuration(input.MonitorInterval)
+	if err != nil {
+		return nil, errors.Wrap(
+			domain.ErrInvalid,
+			err,
+			"invalid monitor interval",
+			errors.WithMetadata("monitor_interval", input.MonitorInterval),
+		)
+	}
+
+	strategy, err := strategies.Get(input.Strategy)
+	if err != nil {
+		return nil, errors.New(
+			domain.ErrInvalid,
+			"invalid strategy",
+			errors.WithMetadata("strategy", input.Strategy),
+		)
+	}
+
+	if validator, ok := strategy.(domain.StrategyParamsValidator); ok {
+		if err := validator.ValidateParams(input.StrategyParams); err != nil {
+			return nil, err
+		}
+	}
+
+	var stopLoss *domain.StopLoss
+	if input.StopLoss != nil {
+		stopLossType := strings.ToUpper(input.StopLoss.Type)
+		if stopLossType == "" {
+			stopLossType = domain.StopLossTypePercentage
+		}
+
+		stopLoss, err = domain.NewStopLoss(stopLossType, input.StopLoss.Value, input.StopLoss.Trailing)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var adaptiveDelta *domain.AdaptiveDelta
+	if input.AdaptiveDelta != nil {
+		adaptiveDelta, err = newAdaptiveDeltaFromInput(input.AdaptiveDelta)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return domain.CreateBot(
+		input.Name,
+		strings.ToUpper(input.Currency),
+		strings.ToUpper(input.TargetCurrency),
+		input.TakeProfitPercentaje,
+		input.InitialCapital,
+		input.Delta,
+		monitorInterval,
+		input.Strategy,
+		input.StrategyParams,
+		strings.ToUpper(input.Mode),
+		stopLoss,
+		adaptiveDelta,
+	)
+}
+
+func newAdaptiveDeltaFromInput(input *AdaptiveDeltaInput) (*domain.AdaptiveDelta, error) {
+	interval := input.Interval
+	if interval == "" {
+		interval = "1h"
+	}
+
+	period := input.Period
+	if period == 0 {
+		period = 14
+	}
+
+	multiplier := input.Multiplier
+	if multiplier.IsZero() {
+		multiplier = decimal.NewFromInt(1)
+	}
+
+	hysteresis := decimal.NewFromFloat(0.1)
+	if input.Hysteresis != nil {
+		hysteresis = *input.Hysteresis
+	}
+
+	return domain.NewAdaptiveDelta(interval, period, multiplier, input.MinDelta, input.MaxDelta, hysteresis)
+}
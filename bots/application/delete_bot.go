@@ -0,0 +1,35 @@
+package application
+
+import (
+	"context"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+)
+
+type DeleteBotInput struct {
+	ID models.ID
+}
+
+type DeleteBot struct {
+	botRepository domain.BotRepository
+}
+
+func NewDeleteBot(
+	botRepository domain.BotRepository,
+) *DeleteBot {
+	return &DeleteBot{
+		botRepository: botRepository,
+	}
+}
+
+func (s *DeleteBot) Exec(ctx context.Context, input *DeleteBotInput) error {
+	bot, err := s.botRepository.FindByID(ctx, input.ID)
+	if err != nil {
+		return err
+	}
+
+	bot.Delete()
+
+	return s.botRepository.SaveStatus(ctx, bot)
+}
@@ -0,0 +1,150 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/logs"
+)
+
+type ExecuteBotInput struct {
+	Bot  *domain.Bot
+	Tick domain.Tick
+}
+
+// ExecuteBot runs one cycle of a bot for a price tick: reconciles its orders
+// with the provider, sells the positions that reached their stop loss, adapts
+// its Delta to the volatility, evaluates its strategy and places the resulting
+// orders the risk manager accepts. The bot is not saved, so the same cycle
+// drives live bots and backtests.
+type ExecuteBot struct {
+	providers         *domain.Providers
+	strategies        *domain.StrategyRegistry
+	reconcileOrders   *ReconcileOrders
+	triggerStopLosses *TriggerStopLosses
+	adaptDelta        *AdaptDelta
+	// Optional, without it orders are not rounded nor validated before they
+	// are placed.
+	symbolFilters domain.SymbolFiltersProvider
+	fees          domain.FeeSchedule
+	risk          *domain.RiskManager
+}
+
+func NewExecuteBot(
+	providers *domain.Providers,
+	strategies *domain.StrategyRegistry,
+	reconcileOrders *ReconcileOrders,
+	triggerStopLosses *TriggerStopLosses,
+	adaptDelta *AdaptDelta,
+	symbolFilters domain.SymbolFiltersProvider,
+	fees domain.FeeSchedule,
+	risk *domain.RiskManager,
+) *ExecuteBot {
+	return &ExecuteBot{
+		providers:         providers,
+		strategies:        strategies,
+		reconcileOrders:   reconcileOrders,
+		triggerStopLosses: triggerStopLosses,
+		adaptDelta:        adaptDelta,
+		symbolFilters:     symbolFilters,
+		fees:              fees,
+		risk:              risk,
+	}
+}
+
+func (s *ExecuteBot) Exec(ctx context.Context, input *ExecuteBotInput) error {
+	bot, tick := input.Bot, input.Tick
+
+	strategy, err := s.strategies.Get(bot.Strategy)
+	if err != nil {
+		return err
+	}
+
+	if err := s.reconcileOrders.Exec(ctx, &ReconcileOrdersInput{Bot: bot, Tick: tick}); err != nil {
+		return err
+	}
+
+	if err := s.triggerStopLosses.Exec(ctx, &TriggerStopLossesInput{Bot: bot, Tick: tick}); err != nil {
+		return err
+	}
+
+	if err := s.adaptDelta.Exec(ctx, &AdaptDeltaInput{Bot: bot, Tick: tick}); err != nil {
+		return err
+	}
+
+	s.risk.Track(bot, tick)
+
+	decisions, err := strategy.Evaluate(ctx, bot, tick)
+	if err != nil {
+		return errors.Wrap(domain.ErrInternal, err, "could not evaluate strategy")
+	}
+
+	for _, decision := range decisions {
+		if err := s.executeDecision(ctx, bot, tick, decision); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *ExecuteBot) executeDecision(ctx context.Context, bot *domain.Bot, tick domain.Tick, decision domain.Decision) error {
+	switch decision.Action {
+	case domain.DecisionActionBuy:
+		filters, err := getSymbolFilters(ctx, s.symbolFilters, bot)
+		if err != nil {
+			return err
+		}
+
+		var newOrder *domain.Order
+		if decision.OrderType == domain.OrderTypeLimit {
+			newOrder, err = bot.GenerateLimitOrder(decision.Price, tick.Price, decision.PriceRange, decision.QuoteAmount, filters, s.fees, s.risk)
+		} else {
+			newOrder, err = bot.GenerateOrder(tick.Price, decision.PriceRange, decision.QuoteAmount, filters, s.fees, s.risk)
+		}
+		if err != nil {
+			/** Not an error of the bot, it just waits until the limits allow
+			it to buy again */
+			if errors.Is(err, domain.ErrRiskRejected) {
+				logs.Warn(ctx, fmt.Sprintf("%s: Compra de %s %s rechazada por el control de riesgo", bot.Name, decision.QuoteAmount.String(), bot.Currency), logs.NewAttr("error", err))
+				return nil
+			}
+
+			/** Rejected by the filters of the pair */
+			if errors.Is(err, domain.ErrInvalid) {
+				return err
+			}
+
+			return errors.Wrap(domain.ErrInternal, err, "could not generate order")
+		}
+
+		if decision.PositionID != nil {
+			newOrder.JoinPosition(*decision.PositionID)
+		}
+
+		externalId, err := s.providers.ForBot(bot).CreateOrderInProvider(ctx, newOrder, bot.Name)
+		if err != nil {
+			return errors.Wrap(domain.ErrInternal, err, "could not create order in provider")
+		}
+
+		newOrder.AddExternalId(externalId)
+	default:
+		return errors.New(
+			domain.ErrInvalid,
+			"unknown decision action",
+			errors.WithMetadata("action", decision.Action),
+		)
+	}
+
+	return nil
+}
+
+func getSymbolFilters(ctx context.Context, provider domain.SymbolFiltersProvider, bot *domain.Bot) (*domain.SymbolFilters, error) {
+	if provider == nil {
+		return nil, nil
+	}
+
+	return provider.GetSymbolFilters(ctx, bot.TargetCurrency, bot.Currency)
+}
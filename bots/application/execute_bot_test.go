@@ -0,0 +1,118 @@
+package application
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeProvider quotes 50000 for every pair and accepts every order, leaving it
+// open unless it is given the state of the order or the error to fail with.
+type fakeProvider struct {
+	mu        sync.Mutex
+	priceErr  error
+	created   int
+	createErr error
+	canceled  int
+	orders    map[models.ID]*domain.ProviderOrder
+	errs      map[models.ID]error
+}
+
+func (p *fakeProvider) GetPrice(ctx context.Context, baseCurrency string, quoteCurrency string) (*domain.Price, error) {
+	if p.priceErr != nil {
+		return nil, p.priceErr
+	}
+
+	return domain.NewPrice(baseCurrency, quoteCurrency, decimal.NewFromInt(50000))
+}
+
+func (p *fakeProvider) CreateOrderInProvider(ctx context.Context, order *domain.Order, botName string) (string, error) {
+	if p.createErr != nil {
+		return "", p.createErr
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.created++
+	return order.ID.String(), nil
+}
+
+func (p *fakeProvider) CancelOrderInProvider(ctx context.Context, order *domain.Order) error {
+	p.canceled++
+	if _, ok := p.orders[order.ID]; !ok {
+		p.setOrder(order, domain.OrderStatusCanceled, "0", "0")
+	}
+
+	return nil
+}
+
+func (p *fakeProvider) GetOrderFromProvider(ctx context.Context, order *domain.Order) (*domain.ProviderOrder, error) {
+	if err, ok := p.errs[order.ID]; ok {
+		return nil, err
+	}
+
+	if providerOrder, ok := p.orders[order.ID]; ok {
+		return providerOrder, nil
+	}
+
+	if order.ExternalId == nil {
+		return nil, errors.New(domain.ErrNotFound, "order not found")
+	}
+
+	return &domain.ProviderOrder{ExternalId: *order.ExternalId, Status: domain.OrderStatusOpen}, nil
+}
+
+// setOrder sets the state the provider reports for an order.
+func (p *fakeProvider) setOrder(order *domain.Order, status string, quantity string, quoteAmount string) {
+	if p.orders == nil {
+		p.orders = make(map[models.ID]*domain.ProviderOrder)
+	}
+
+	p.orders[order.ID] = &domain.ProviderOrder{
+		ExternalId:          order.ID.String(),
+		Status:              status,
+		ExecutedQuantity:    decimal.RequireFromString(quantity),
+		ExecutedQuoteAmount: decimal.RequireFromString(quoteAmount),
+	}
+}
+
+func TestExecuteBotRiskRejected(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{}
+	providers := domain.NewProviders(provider, provider, true)
+	strategies, err := domain.NewStrategyRegistry(domain.NewGridStrategy())
+	assert.NoError(t, err)
+	executeBot := NewExecuteBot(
+		providers,
+		strategies,
+		NewReconcileOrders(providers, nil, domain.FeeSchedule{}),
+		NewTriggerStopLosses(providers, nil, domain.FeeSchedule{}),
+		NewAdaptDelta(nil),
+		nil,
+		domain.FeeSchedule{},
+		domain.NewRiskManager(domain.RiskLimits{MaxOpenOrders: 1}),
+	)
+
+	bot, err := domain.CreateBot("test", "USDT", "BTC", decimal.RequireFromString("0.01"), decimal.NewFromInt(1000), decimal.NewFromInt(100), time.Minute, domain.StrategyGrid, domain.StrategyParams{domain.GridParamOrders: "10"}, domain.BotModePaper, nil, nil)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	assert.NoError(t, executeBot.Exec(ctx, &ExecuteBotInput{Bot: bot, Tick: domain.NewTick(decimal.NewFromInt(50000), now)}))
+	assert.Len(t, bot.OpenOrders, 1)
+	assert.Equal(t, 1, provider.created)
+
+	/** The next range asks for a buy the risk manager rejects, the cycle goes
+	on without it */
+	assert.NoError(t, executeBot.Exec(ctx, &ExecuteBotInput{Bot: bot, Tick: domain.NewTick(decimal.NewFromInt(49000), now.Add(time.Minute))}))
+	assert.Len(t, bot.OpenOrders, 1)
+	assert.Equal(t, 1, provider.created)
+	assert.Equal(t, "900", bot.AvailableCapital.String())
+}
@@ -0,0 +1,28 @@
+package application
+
+import (
+	"context"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+)
+
+type GetBotInput struct {
+	ID models.ID
+}
+
+type GetBot struct {
+	botRepository domain.BotRepository
+}
+
+func NewGetBot(
+	botRepository domain.BotRepository,
+) *GetBot {
+	return &GetBot{
+		botRepository: botRepository,
+	}
+}
+
+func (s *GetBot) Exec(ctx context.Context, input *GetBotInput) (*domain.Bot, error) {
+	return s.botRepository.FindByID(ctx, input.ID)
+}
@@ -0,0 +1,66 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+	"github.com/shopspring/decimal"
+)
+
+type GetBotEquityInput struct {
+	ID         models.ID
+	Resolution string
+	Start      time.Time
+	End        time.Time
+}
+
+type GetBotEquityOutput struct {
+	Snapshots []*domain.EquitySnapshot
+	// Largest drop of the mark to market in the range, as a fraction of the
+	// previous peak.
+	MaxDrawdown decimal.Decimal
+}
+
+// GetBotEquity returns the equity snapshots of a bot with one resolution.
+type GetBotEquity struct {
+	botRepository            domain.BotRepository
+	equitySnapshotRepository domain.EquitySnapshotRepository
+}
+
+func NewGetBotEquity(
+	botRepository domain.BotRepository,
+	equitySnapshotRepository domain.EquitySnapshotRepository,
+) *GetBotEquity {
+	return &GetBotEquity{
+		botRepository:            botRepository,
+		equitySnapshotRepository: equitySnapshotRepository,
+	}
+}
+
+func (s *GetBotEquity) Exec(ctx context.Context, input *GetBotEquityInput) (*GetBotEquityOutput, error) {
+	if _, err := domain.EquityResolutionDuration(input.Resolution); err != nil {
+		return nil, err
+	}
+
+	if !input.Start.Before(input.End) {
+		return nil, errors.New(domain.ErrInvalid, "start must be before end", errors.WithMetadata("start", input.Start), errors.WithMetadata("end", input.End))
+	}
+
+	bot, err := s.botRepository.FindByID(ctx, input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := s.equitySnapshotRepository.FindByBotID(ctx, bot.ID, input.Resolution, input.Start, input.End)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetBotEquityOutput{
+		Snapshots:   snapshots,
+		MaxDrawdown: domain.EquityMaxDrawdown(snapshots),
+	}, nil
+}
@@ -0,0 +1,68 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+)
+
+type GetBotPnLInput struct {
+	ID models.ID
+	// Range of the snapshots to return.
+	Start time.Time
+	End   time.Time
+}
+
+type GetBotPnLOutput struct {
+	Current *domain.PnL
+	History []*domain.PnL
+}
+
+// GetBotPnL returns the profit of a bot at the current price together with
+// its stored snapshots.
+type GetBotPnL struct {
+	botRepository domain.BotRepository
+	pnlRepository domain.PnLRepository
+	providers     *domain.Providers
+}
+
+func NewGetBotPnL(
+	botRepository domain.BotRepository,
+	pnlRepository domain.PnLRepository,
+	providers *domain.Providers,
+) *GetBotPnL {
+	return &GetBotPnL{
+		botRepository: botRepository,
+		pnlRepository: pnlRepository,
+		providers:     providers,
+	}
+}
+
+func (s *GetBotPnL) Exec(ctx context.Context, input *GetBotPnLInput) (*GetBotPnLOutput, error) {
+	if !input.Start.Before(input.End) {
+		return nil, errors.New(domain.ErrInvalid, "start must be before end", errors.WithMetadata("start", input.Start), errors.WithMetadata("end", input.End))
+	}
+
+	bot, err := s.botRepository.FindByID(ctx, input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := botPnL(ctx, s.providers, bot, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.pnlRepository.FindByBotID(ctx, bot.ID, input.Start, input.End)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetBotPnLOutput{
+		Current: current,
+		History: history,
+	}, nil
+}
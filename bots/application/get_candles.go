@@ -0,0 +1,61 @@
+package application
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+)
+
+type GetCandlesInput struct {
+	Symbol   string
+	Interval string
+	Start    time.Time
+	End      time.Time
+}
+
+// GetCandles returns the stored candles of a range, backfilling the missing
+// ones first.
+type GetCandles struct {
+	candleRepository domain.CandleRepository
+	backfillCandles  *BackfillCandles
+}
+
+func NewGetCandles(
+	candleRepository domain.CandleRepository,
+	backfillCandles *BackfillCandles,
+) *GetCandles {
+	return &GetCandles{
+		candleRepository: candleRepository,
+		backfillCandles:  backfillCandles,
+	}
+}
+
+func (s *GetCandles) Exec(ctx context.Context, input *GetCandlesInput) ([]*domain.Candle, error) {
+	/** Candles are stored with the symbol of Binance, BTC-USDT is BTCUSDT */
+	symbol := strings.ToUpper(input.Symbol)
+	if pair, err := domain.ParseSymbol(symbol); err == nil {
+		symbol = pair.Binance()
+	}
+	if symbol == "" {
+		return nil, errors.New(domain.ErrInvalid, "symbol is required")
+	}
+
+	if !input.Start.Before(input.End) {
+		return nil, errors.New(domain.ErrInvalid, "start must be before end", errors.WithMetadata("start", input.Start), errors.WithMetadata("end", input.End))
+	}
+
+	_, err := s.backfillCandles.Exec(ctx, &BackfillCandlesInput{
+		Symbol:   symbol,
+		Interval: input.Interval,
+		Start:    input.Start,
+		End:      input.End,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.candleRepository.FindCandles(ctx, symbol, input.Interval, input.Start, input.End)
+}
@@ -0,0 +1,80 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/logs"
+)
+
+type GetPnLInput struct{}
+
+type GetPnLOutput struct {
+	Bots []*domain.PnL
+	// Profit of every bot added up by currency, sorted by currency.
+	Totals []*domain.PnL
+}
+
+// GetPnL returns the profit of every bot at the current prices. Bots whose
+// price can't be fetched are left out.
+type GetPnL struct {
+	botRepository domain.BotRepository
+	providers     *domain.Providers
+}
+
+func NewGetPnL(
+	botRepository domain.BotRepository,
+	providers *domain.Providers,
+) *GetPnL {
+	return &GetPnL{
+		botRepository: botRepository,
+		providers:     providers,
+	}
+}
+
+func (s *GetPnL) Exec(ctx context.Context, input *GetPnLInput) (*GetPnLOutput, error) {
+	bots, err := s.botRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	output := &GetPnLOutput{
+		Bots: make([]*domain.PnL, 0, len(bots)),
+	}
+	byCurrency := make(map[string][]*domain.PnL)
+	for _, bot := range bots {
+		pnl, err := botPnL(ctx, s.providers, bot, now)
+		if err != nil {
+			logs.Error(ctx, fmt.Sprintf("could not get %s pnl", bot.Name), logs.NewAttr("error", err))
+			continue
+		}
+
+		output.Bots = append(output.Bots, pnl)
+		byCurrency[bot.Currency] = append(byCurrency[bot.Currency], pnl)
+	}
+
+	output.Totals = make([]*domain.PnL, 0, len(byCurrency))
+	for currency, pnls := range byCurrency {
+		output.Totals = append(output.Totals, domain.SumPnL(currency, now, pnls))
+	}
+	sort.Slice(output.Totals, func(i, j int) bool {
+		return output.Totals[i].Currency < output.Totals[j].Currency
+	})
+
+	return output, nil
+}
+
+// botPnL returns the profit of a bot at the current price of its pair.
+func botPnL(ctx context.Context, providers *domain.Providers, bot *domain.Bot, at time.Time) (*domain.PnL, error) {
+	price, err := providers.ForBot(bot).GetPrice(ctx, bot.TargetCurrency, bot.Currency)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "could not get price", errors.WithMetadata("bot_id", bot.ID))
+	}
+
+	return bot.PnL(price.Price, at), nil
+}
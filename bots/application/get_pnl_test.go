@@ -0,0 +1,72 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPnL(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestBotRepo(t)
+	paper := &fakeProvider{}
+	live := &fakeProvider{priceErr: errors.New(domain.ErrInternal, "provider down")}
+	getPnL := NewGetPnL(repo, domain.NewProviders(live, paper, false))
+
+	/** Bought 0.002 BTC for 90 USDT, worth 100 at 50000 */
+	holding := newTestBot(t)
+	entry, err := holding.GenerateOrder(decimal.NewFromInt(50000), 500, decimal.NewFromInt(100), nil, domain.FeeSchedule{}, domain.NewRiskManager(domain.RiskLimits{}))
+	assert.NoError(t, err)
+	entry.AddExternalId("1")
+	holding.ReconcileOrder(ctx, entry, &domain.ProviderOrder{
+		ExternalId:          "1",
+		Status:              domain.OrderStatusCompleted,
+		ExecutedQuantity:    decimal.RequireFromString("0.002"),
+		ExecutedQuoteAmount: decimal.NewFromInt(90),
+	}, domain.FeeSchedule{}, time.Now())
+	assert.NoError(t, repo.Save(ctx, holding))
+
+	idle := newTestBot(t)
+	assert.NoError(t, repo.Save(ctx, idle))
+
+	inBTC, err := domain.CreateBot("btc", "BTC", "ETH", decimal.RequireFromString("0.01"), decimal.NewFromInt(1), decimal.RequireFromString("0.001"), time.Minute, domain.StrategyGrid, nil, domain.BotModePaper, nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Save(ctx, inBTC))
+
+	/** Its price can't be fetched, it is left out */
+	unpriced, err := domain.CreateBot("live", "USDT", "BTC", decimal.RequireFromString("0.01"), decimal.NewFromInt(1000), decimal.NewFromInt(100), time.Minute, domain.StrategyGrid, nil, domain.BotModeLive, nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Save(ctx, unpriced))
+
+	output, err := getPnL.Exec(ctx, &GetPnLInput{})
+	assert.NoError(t, err)
+
+	assert.Len(t, output.Bots, 3)
+	for _, pnl := range output.Bots {
+		assert.NotEqual(t, unpriced.ID, pnl.BotID)
+		if pnl.BotID == holding.ID {
+			assert.Equal(t, "10", pnl.UnrealizedPnL.String())
+			assert.Equal(t, "1010", pnl.Equity.String())
+		}
+	}
+
+	assert.Len(t, output.Totals, 2)
+	btc, usdt := output.Totals[0], output.Totals[1]
+	assert.Equal(t, "BTC", btc.Currency)
+	assert.Equal(t, "1", btc.InitialCapital.String())
+	assert.Equal(t, "1", btc.Equity.String())
+	assert.Equal(t, "0", btc.ROI.String())
+
+	assert.Equal(t, "USDT", usdt.Currency)
+	assert.Equal(t, "2000", usdt.InitialCapital.String())
+	assert.Equal(t, "0", usdt.RealizedPnL.String())
+	assert.Equal(t, "10", usdt.UnrealizedPnL.String())
+	assert.Equal(t, "2010", usdt.Equity.String())
+	assert.Equal(t, "0.005", usdt.ROI.String())
+	assert.Equal(t, output.Totals[0].Time, output.Bots[0].Time)
+}
@@ -0,0 +1,74 @@
+package application
+
+import (
+	"context"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+)
+
+const (
+	defaultBotsPageLimit = 20
+	maxBotsPageLimit     = 100
+)
+
+type ListBotsInput struct {
+	Cursor string
+	Limit  int
+}
+
+type ListBots struct {
+	botRepository domain.BotRepository
+}
+
+func NewListBots(
+	botRepository domain.BotRepository,
+) *ListBots {
+	return &ListBots{
+		botRepository: botRepository,
+	}
+}
+
+func (s *ListBots) Exec(ctx context.Context, input *ListBotsInput) (models.Page[*domain.Bot], error) {
+	cursor := models.OffsetLimitCursor{
+		Offset: 0,
+		Limit:  defaultBotsPageLimit,
+	}
+
+	if input.Cursor != "" {
+		decoded, err := models.DecodeOffsetLimitCursor(input.Cursor)
+		if err != nil {
+			return models.Page[*domain.Bot]{}, errors.Wrap(domain.ErrInvalid, err, "invalid cursor")
+		}
+		cursor = decoded
+	} else if input.Limit != 0 {
+		cursor.Limit = input.Limit
+	}
+
+	if cursor.Offset < 0 || cursor.Limit < 1 || cursor.Limit > maxBotsPageLimit {
+		return models.Page[*domain.Bot]{}, errors.New(
+			domain.ErrInvalid,
+			"invalid page",
+			errors.WithMetadata("offset", cursor.Offset),
+			errors.WithMetadata("limit", cursor.Limit),
+		)
+	}
+
+	bots, total, err := s.botRepository.FindPage(ctx, cursor.Offset, cursor.Limit)
+	if err != nil {
+		return models.Page[*domain.Bot]{}, err
+	}
+
+	page := models.NewPage(bots, total)
+
+	nextOffset := cursor.Offset + len(bots)
+	if nextOffset < total {
+		page = page.WithCursor(models.OffsetLimitCursor{
+			Offset: nextOffset,
+			Limit:  cursor.Limit,
+		})
+	}
+
+	return page, nil
+}
@@ -0,0 +1,128 @@
+package application
+
+import (
+	"context"
+	"sync"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/logs"
+	"github.com/shopspring/decimal"
+)
+
+// marketWatcher follows the trades of the pair of a bot between executions. It
+// feeds them to the simulated provider of paper bots, so their orders are
+// filled by spikes shorter than the monitor interval, and wakes the bot up as
+// soon as a trade reaches one of its take profits, stop losses or LIMIT buys.
+type marketWatcher struct {
+	subscription domain.MarketSubscription
+	feeder       domain.PriceFeeder
+	wake         chan<- struct{}
+
+	mu              sync.Mutex
+	takeProfitPrice *decimal.Decimal
+	stopLossPrice   *decimal.Decimal
+	buyPrice        *decimal.Decimal
+	crossed         bool
+}
+
+func newMarketWatcher(
+	ctx context.Context,
+	stream domain.MarketDataStream,
+	bot *domain.Bot,
+	provider domain.ProviderRepository,
+	wake chan<- struct{},
+) (*marketWatcher, error) {
+	subscription, err := stream.Subscribe(ctx, bot.TargetCurrency, bot.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher := &marketWatcher{
+		subscription: subscription,
+		wake:         wake,
+	}
+
+	/** Only simulated providers take prices, the exchange fills its own orders */
+	if feeder, ok := provider.(domain.PriceFeeder); ok {
+		watcher.feeder = feeder
+	}
+
+	go watcher.watch(ctx)
+
+	return watcher, nil
+}
+
+func (w *marketWatcher) watch(ctx context.Context) {
+	for event := range w.subscription.Events() {
+		if event.Trade == nil {
+			continue
+		}
+
+		if w.feeder != nil {
+			if err := w.feeder.FeedPrice(ctx, event.BaseCurrency, event.QuoteCurrency, event.Trade.Price); err != nil {
+				logs.Error(ctx, "could not feed price", logs.NewAttr("error", err))
+			}
+		}
+
+		w.mu.Lock()
+		crossed := (w.takeProfitPrice != nil && event.Trade.Price.GreaterThanOrEqual(*w.takeProfitPrice)) ||
+			(w.stopLossPrice != nil && event.Trade.Price.LessThanOrEqual(*w.stopLossPrice)) ||
+			(w.buyPrice != nil && event.Trade.Price.LessThanOrEqual(*w.buyPrice))
+		if crossed {
+			w.crossed = true
+			w.takeProfitPrice = nil
+			w.stopLossPrice = nil
+			w.buyPrice = nil
+		}
+		w.mu.Unlock()
+
+		if crossed {
+			select {
+			case w.wake <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// watchExits sets the take profit, stop loss and LIMIT buy prices to wake the
+// bot at, after every execution.
+func (w *marketWatcher) watchExits(bot *domain.Bot) {
+	takeProfitPrice, takeProfitOk := bot.LowestTakeProfitPrice()
+	stopLossPrice, stopLossOk := bot.HighestStopLossPrice()
+	buyPrice, buyOk := bot.HighestPendingBuyPrice()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.takeProfitPrice = nil
+	if takeProfitOk {
+		w.takeProfitPrice = &takeProfitPrice
+	}
+
+	w.stopLossPrice = nil
+	if stopLossOk {
+		w.stopLossPrice = &stopLossPrice
+	}
+
+	w.buyPrice = nil
+	if buyOk {
+		w.buyPrice = &buyPrice
+	}
+}
+
+// takeCrossed reports whether a take profit, stop loss or LIMIT buy was reached
+// since the last call.
+func (w *marketWatcher) takeCrossed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	crossed := w.crossed
+	w.crossed = false
+
+	return crossed
+}
+
+func (w *marketWatcher) close() {
+	w.subscription.Close()
+}
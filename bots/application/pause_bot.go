@@ -0,0 +1,41 @@
+package application
+
+import (
+	"context"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+)
+
+type PauseBotInput struct {
+	ID models.ID
+}
+
+type PauseBot struct {
+	botRepository domain.BotRepository
+}
+
+func NewPauseBot(
+	botRepository domain.BotRepository,
+) *PauseBot {
+	return &PauseBot{
+		botRepository: botRepository,
+	}
+}
+
+func (s *PauseBot) Exec(ctx context.Context, input *PauseBotInput) (*domain.Bot, error) {
+	bot, err := s.botRepository.FindByID(ctx, input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bot.Pause(); err != nil {
+		return nil, err
+	}
+
+	if err := s.botRepository.SaveStatus(ctx, bot); err != nil {
+		return nil, err
+	}
+
+	return bot, nil
+}
@@ -0,0 +1,133 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/logs"
+)
+
+type ReconcileOrdersInput struct {
+	Bot  *domain.Bot
+	Tick domain.Tick
+}
+
+// ReconcileOrders fetches the state of the open orders of a bot from the
+// provider, settles the capital of the ones that were filled or canceled and
+// places the take profit sell of every filled buy, replacing the ones of the
+// positions that grew since they were placed. The bot is not saved, the
+// caller does it after running the strategy.
+type ReconcileOrders struct {
+	providers *domain.Providers
+	// Optional, without it take profits are not rounded nor validated before
+	// they are placed.
+	symbolFilters domain.SymbolFiltersProvider
+	fees          domain.FeeSchedule
+}
+
+func NewReconcileOrders(
+	providers *domain.Providers,
+	symbolFilters domain.SymbolFiltersProvider,
+	fees domain.FeeSchedule,
+) *ReconcileOrders {
+	return &ReconcileOrders{
+		providers:     providers,
+		symbolFilters: symbolFilters,
+		fees:          fees,
+	}
+}
+
+func (s *ReconcileOrders) Exec(ctx context.Context, input *ReconcileOrdersInput) error {
+	bot, tick := input.Bot, input.Tick
+	providerRepository := s.providers.ForBot(bot)
+
+	for _, order := range bot.OrdersToReconcile() {
+		providerOrder, err := providerRepository.GetOrderFromProvider(ctx, order)
+		if err != nil {
+			if !errors.Is(err, domain.ErrNotFound) || order.ExternalId != nil {
+				logs.Error(ctx, fmt.Sprintf("could not reconcile %s order", bot.Name), logs.NewAttr("id", order.ID), logs.NewAttr("error", err))
+				continue
+			}
+
+			/** The order never reached the provider */
+			providerOrder = &domain.ProviderOrder{
+				Status: domain.OrderStatusCanceled,
+			}
+		}
+
+		bot.ReconcileOrder(ctx, order, providerOrder, s.fees, tick.Time)
+	}
+
+	if !bot.HasOpenOrder() {
+		return nil
+	}
+
+	filters, err := getSymbolFilters(ctx, s.symbolFilters, bot)
+	if err != nil {
+		return err
+	}
+
+	/** Placed again below, unless it was filled before being canceled */
+	for _, order := range bot.OrdersWithOutdatedTakeProfit(filters) {
+		logs.Info(ctx, fmt.Sprintf("%s: Reemplazando el take profit de la posición %s", bot.Name, order.ID))
+		cancelTakeProfit(ctx, providerRepository, s.fees, bot, order, tick.Time)
+	}
+
+	for _, order := range bot.OrdersWithoutTakeProfit() {
+		takeProfitOrder, err := bot.GenerateTakeProfitOrder(order, filters)
+		if err != nil {
+			/** The position is too small for the exchange to sell it */
+			if errors.Is(err, domain.ErrInvalid) {
+				logs.Error(ctx, fmt.Sprintf("could not generate %s take profit order", bot.Name), logs.NewAttr("id", order.ID), logs.NewAttr("error", err))
+				continue
+			}
+
+			return errors.Wrap(domain.ErrInternal, err, "could not generate take profit order")
+		}
+
+		/** If it fails the order is reconciled as canceled and placed again */
+		externalId, err := providerRepository.CreateOrderInProvider(ctx, takeProfitOrder, bot.Name)
+		if err != nil {
+			logs.Error(ctx, fmt.Sprintf("could not create %s take profit order in provider", bot.Name), logs.NewAttr("id", takeProfitOrder.ID), logs.NewAttr("error", err))
+			continue
+		}
+
+		takeProfitOrder.AddExternalId(externalId)
+	}
+
+	return nil
+}
+
+// cancelTakeProfit cancels the take profit of a position and settles what it
+// sold, returning whether the position is free to be sold again.
+func cancelTakeProfit(ctx context.Context, providerRepository domain.ProviderRepository, fees domain.FeeSchedule, bot *domain.Bot, order *domain.Order, at time.Time) bool {
+	takeProfitOrder := order.TakeProfitOrder
+	if takeProfitOrder == nil {
+		return true
+	}
+
+	/** The take profit never reached the provider */
+	providerOrder := &domain.ProviderOrder{
+		Status: domain.OrderStatusCanceled,
+	}
+	if takeProfitOrder.ExternalId != nil {
+		/** It fails if the take profit was filled meanwhile, its state tells */
+		if err := providerRepository.CancelOrderInProvider(ctx, takeProfitOrder); err != nil {
+			logs.Warn(ctx, fmt.Sprintf("could not cancel %s take profit order", bot.Name), logs.NewAttr("id", takeProfitOrder.ID), logs.NewAttr("error", err))
+		}
+
+		var err error
+		providerOrder, err = providerRepository.GetOrderFromProvider(ctx, takeProfitOrder)
+		if err != nil {
+			logs.Error(ctx, fmt.Sprintf("could not reconcile %s take profit order", bot.Name), logs.NewAttr("id", takeProfitOrder.ID), logs.NewAttr("error", err))
+			return false
+		}
+	}
+
+	bot.ReconcileOrder(ctx, takeProfitOrder, providerOrder, fees, at)
+
+	return order.TakeProfitOrder == nil
+}
@@ -0,0 +1,217 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBot(t *testing.T) *domain.Bot {
+	bot, err := domain.CreateBot("test", "USDT", "BTC", decimal.RequireFromString("0.01"), decimal.NewFromInt(1000), decimal.NewFromInt(100), time.Minute, domain.StrategyGrid, domain.StrategyParams{domain.GridParamOrders: "10"}, domain.BotModePaper, nil, nil)
+	assert.NoError(t, err)
+	return bot
+}
+
+func TestReconcileOrders(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name string
+		// The order was placed in the provider.
+		placed        bool
+		providerOrder *domain.ProviderOrder
+		err           error
+		// Expected after the reconciliation.
+		open        bool
+		status      string
+		available   string
+		takeProfits int
+	}{
+		{
+			name:          "filled",
+			placed:        true,
+			providerOrder: &domain.ProviderOrder{Status: domain.OrderStatusCompleted, ExecutedQuantity: decimal.RequireFromString("0.002"), ExecutedQuoteAmount: decimal.NewFromInt(100)},
+			open:          true,
+			status:        domain.OrderStatusCompleted,
+			available:     "900",
+			takeProfits:   1,
+		},
+		{
+			name:          "partly filled then canceled",
+			placed:        true,
+			providerOrder: &domain.ProviderOrder{Status: domain.OrderStatusCanceled, ExecutedQuantity: decimal.RequireFromString("0.001"), ExecutedQuoteAmount: decimal.NewFromInt(50)},
+			open:          true,
+			status:        domain.OrderStatusCanceled,
+			available:     "950",
+			takeProfits:   1,
+		},
+		{
+			name:      "never reached the provider",
+			err:       errors.New(domain.ErrNotFound, "order not found"),
+			status:    domain.OrderStatusCanceled,
+			available: "1000",
+		},
+		{
+			name:      "not found once placed",
+			placed:    true,
+			err:       errors.New(domain.ErrNotFound, "order not found"),
+			open:      true,
+			status:    domain.OrderStatusOpen,
+			available: "900",
+		},
+		{
+			name:      "provider error",
+			placed:    true,
+			err:       errors.New(domain.ErrInternal, "provider down"),
+			open:      true,
+			status:    domain.OrderStatusOpen,
+			available: "900",
+		},
+	} {
+		bot := newTestBot(t)
+		order, err := bot.GenerateOrder(decimal.NewFromInt(50000), 500, decimal.NewFromInt(100), nil, domain.FeeSchedule{}, domain.NewRiskManager(domain.RiskLimits{}))
+		assert.NoError(t, err)
+		if tt.placed {
+			order.AddExternalId("1")
+		}
+
+		provider := &fakeProvider{
+			orders: map[models.ID]*domain.ProviderOrder{},
+			errs:   map[models.ID]error{},
+		}
+		if tt.providerOrder != nil {
+			tt.providerOrder.ExternalId = "1"
+			provider.orders[order.ID] = tt.providerOrder
+		}
+		if tt.err != nil {
+			provider.errs[order.ID] = tt.err
+		}
+		providers := domain.NewProviders(provider, provider, true)
+
+		assert.NoError(t, NewReconcileOrders(providers, nil, domain.FeeSchedule{}).Exec(ctx, &ReconcileOrdersInput{Bot: bot}), tt.name)
+
+		assert.Equal(t, tt.status, order.Status, tt.name)
+		assert.Equal(t, tt.available, bot.AvailableCapital.String(), tt.name)
+		assert.Equal(t, "1000", bot.TotalCapital.String(), tt.name)
+		assert.Equal(t, tt.takeProfits, provider.created, tt.name)
+		if tt.open {
+			assert.Equal(t, []*domain.Order{order}, bot.OpenOrders, tt.name)
+		} else {
+			assert.Empty(t, bot.OpenOrders, tt.name)
+			assert.NotNil(t, order.ClosedAt, tt.name)
+		}
+	}
+}
+
+func TestReconcileOrdersTakeProfit(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{}
+	reconcileOrders := NewReconcileOrders(domain.NewProviders(provider, provider, true), nil, domain.FeeSchedule{})
+	bot := newTestBot(t)
+
+	entry, err := bot.GenerateOrder(decimal.NewFromInt(50000), 500, decimal.NewFromInt(100), nil, domain.FeeSchedule{}, domain.NewRiskManager(domain.RiskLimits{}))
+	assert.NoError(t, err)
+	entry.AddExternalId(entry.ID.String())
+
+	/** The fill of the buy places its take profit */
+	provider.setOrder(entry, domain.OrderStatusCompleted, "0.002", "100")
+	assert.NoError(t, reconcileOrders.Exec(ctx, &ReconcileOrdersInput{Bot: bot}))
+	takeProfit := entry.TakeProfitOrder
+	assert.NotNil(t, takeProfit)
+	assert.NotNil(t, takeProfit.ExternalId)
+	assert.Equal(t, domain.OrderSideSell, takeProfit.Side)
+	assert.Equal(t, "0.002", takeProfit.Quantity.String())
+	assert.Equal(t, "50500", takeProfit.EntryPrice.String())
+	assert.Equal(t, 1, provider.created)
+
+	/** Nothing is released while it waits in the book */
+	provider.setOrder(takeProfit, domain.OrderStatusOpen, "0.001", "50.5")
+	assert.NoError(t, reconcileOrders.Exec(ctx, &ReconcileOrdersInput{Bot: bot}))
+	assert.Equal(t, takeProfit, entry.TakeProfitOrder)
+	assert.Equal(t, "900", bot.AvailableCapital.String())
+	assert.Equal(t, 1, provider.created)
+
+	/** Canceled after a partial fill, the rest is sold by a new one */
+	provider.setOrder(takeProfit, domain.OrderStatusCanceled, "0.001", "50.5")
+	assert.NoError(t, reconcileOrders.Exec(ctx, &ReconcileOrdersInput{Bot: bot}))
+	assert.Equal(t, "0.001", entry.Quantity.String())
+	assert.Equal(t, "50", entry.InitialQuoteAmount.String())
+	assert.Equal(t, "950.5", bot.AvailableCapital.String())
+	assert.NotEqual(t, takeProfit, entry.TakeProfitOrder)
+	assert.Equal(t, "0.001", entry.TakeProfitOrder.Quantity.String())
+	assert.Equal(t, 2, provider.created)
+
+	/** Sold, the position is closed */
+	provider.setOrder(entry.TakeProfitOrder, domain.OrderStatusCompleted, "0.001", "50.5")
+	assert.NoError(t, reconcileOrders.Exec(ctx, &ReconcileOrdersInput{Bot: bot}))
+	assert.Empty(t, bot.OpenOrders)
+	assert.Equal(t, "1001", bot.AvailableCapital.String())
+	assert.Equal(t, "0", bot.InvestedCapital.String())
+	assert.Equal(t, "1", bot.RealizedPnL.String())
+	assert.Equal(t, 2, provider.created)
+}
+
+func TestReconcileOrdersTakeProfitNotPlaced(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{createErr: errors.New(domain.ErrInternal, "provider down")}
+	reconcileOrders := NewReconcileOrders(domain.NewProviders(provider, provider, true), nil, domain.FeeSchedule{})
+	bot := newTestBot(t)
+
+	entry, err := bot.GenerateOrder(decimal.NewFromInt(50000), 500, decimal.NewFromInt(100), nil, domain.FeeSchedule{}, domain.NewRiskManager(domain.RiskLimits{}))
+	assert.NoError(t, err)
+	entry.AddExternalId(entry.ID.String())
+	provider.setOrder(entry, domain.OrderStatusCompleted, "0.002", "100")
+
+	assert.NoError(t, reconcileOrders.Exec(ctx, &ReconcileOrdersInput{Bot: bot}))
+	failed := entry.TakeProfitOrder
+	assert.NotNil(t, failed)
+	assert.Nil(t, failed.ExternalId)
+	assert.Equal(t, 0, provider.created)
+
+	/** Reconciled as canceled on the next tick and placed again */
+	provider.createErr = nil
+	assert.NoError(t, reconcileOrders.Exec(ctx, &ReconcileOrdersInput{Bot: bot}))
+	assert.Equal(t, domain.OrderStatusCanceled, failed.Status)
+	assert.NotEqual(t, failed, entry.TakeProfitOrder)
+	assert.NotNil(t, entry.TakeProfitOrder.ExternalId)
+	assert.Equal(t, "0.002", entry.TakeProfitOrder.Quantity.String())
+	assert.Equal(t, "900", bot.AvailableCapital.String())
+	assert.Equal(t, 1, provider.created)
+}
+
+func TestReconcileOrdersOutdatedTakeProfit(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{}
+	reconcileOrders := NewReconcileOrders(domain.NewProviders(provider, provider, true), nil, domain.FeeSchedule{})
+	bot := newTestBot(t)
+	risk := domain.NewRiskManager(domain.RiskLimits{})
+
+	entry, err := bot.GenerateOrder(decimal.NewFromInt(50000), 500, decimal.NewFromInt(100), nil, domain.FeeSchedule{}, risk)
+	assert.NoError(t, err)
+	entry.AddExternalId(entry.ID.String())
+	provider.setOrder(entry, domain.OrderStatusCompleted, "0.002", "100")
+	assert.NoError(t, reconcileOrders.Exec(ctx, &ReconcileOrdersInput{Bot: bot}))
+	takeProfit := entry.TakeProfitOrder
+
+	/** A safety buy joins the position, its take profit no longer sells all */
+	safety, err := bot.GenerateOrder(decimal.NewFromInt(40000), 400, decimal.NewFromInt(80), nil, domain.FeeSchedule{}, risk)
+	assert.NoError(t, err)
+	safety.JoinPosition(entry.ID)
+	safety.AddExternalId(safety.ID.String())
+	provider.setOrder(safety, domain.OrderStatusCompleted, "0.002", "80")
+
+	assert.NoError(t, reconcileOrders.Exec(ctx, &ReconcileOrdersInput{Bot: bot}))
+	assert.Equal(t, 1, provider.canceled)
+	assert.Equal(t, domain.OrderStatusCanceled, takeProfit.Status)
+	assert.Equal(t, []*domain.Order{entry}, bot.OpenOrders)
+	assert.Equal(t, "0.004", entry.Quantity.String())
+	assert.Equal(t, "0.004", entry.TakeProfitOrder.Quantity.String())
+	assert.Equal(t, "45450", entry.TakeProfitOrder.EntryPrice.String())
+	assert.Equal(t, 2, provider.created)
+}
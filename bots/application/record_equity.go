@@ -0,0 +1,93 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/logs"
+)
+
+type RecordEquityConfig struct {
+	// Age after which the minute snapshots are merged into hour ones.
+	MinuteRetention time.Duration
+	// Age after which the hour snapshots are merged into day ones, kept
+	// forever.
+	HourRetention time.Duration
+}
+
+// RecordEquity stores a snapshot of the capital of every bot at the current
+// prices and downsamples the old ones, keeping the history small.
+type RecordEquity struct {
+	botRepository            domain.BotRepository
+	equitySnapshotRepository domain.EquitySnapshotRepository
+	providers                *domain.Providers
+	config                   RecordEquityConfig
+}
+
+func NewRecordEquity(
+	botRepository domain.BotRepository,
+	equitySnapshotRepository domain.EquitySnapshotRepository,
+	providers *domain.Providers,
+	config RecordEquityConfig,
+) *RecordEquity {
+	return &RecordEquity{
+		botRepository:            botRepository,
+		equitySnapshotRepository: equitySnapshotRepository,
+		providers:                providers,
+		config:                   config,
+	}
+}
+
+// Run records the snapshots every interval until the context is canceled.
+// Snapshots within the same minute replace each other, so intervals below a
+// minute only keep the last one.
+func (s *RecordEquity) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Exec(ctx); err != nil {
+				logs.Error(ctx, "could not record equity snapshots", logs.NewAttr("error", err))
+			}
+		}
+	}
+}
+
+// Exec records a snapshot of every bot, skipping the ones whose price can't be
+// fetched, and then downsamples the snapshots past their retention.
+func (s *RecordEquity) Exec(ctx context.Context) error {
+	bots, err := s.botRepository.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, bot := range bots {
+		price, err := s.providers.ForBot(bot).GetPrice(ctx, bot.TargetCurrency, bot.Currency)
+		if err != nil {
+			logs.Error(ctx, fmt.Sprintf("could not get %s price", bot.Name), logs.NewAttr("error", err))
+			continue
+		}
+
+		if err := s.equitySnapshotRepository.Save(ctx, bot.EquitySnapshot(price.Price, now)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.equitySnapshotRepository.Downsample(ctx, domain.EquityResolutionMinute, domain.EquityResolutionHour, now.Add(-s.config.MinuteRetention)); err != nil {
+		return errors.Wrap(domain.ErrInternal, err, "could not downsample minute snapshots")
+	}
+
+	if _, err := s.equitySnapshotRepository.Downsample(ctx, domain.EquityResolutionHour, domain.EquityResolutionDay, now.Add(-s.config.HourRetention)); err != nil {
+		return errors.Wrap(domain.ErrInternal, err, "could not downsample hour snapshots")
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/logs"
+)
+
+// RecordPnL stores a snapshot of the profit of every bot at the current
+// prices, building the history returned by GetBotPnL.
+type RecordPnL struct {
+	botRepository domain.BotRepository
+	pnlRepository domain.PnLRepository
+	providers     *domain.Providers
+}
+
+func NewRecordPnL(
+	botRepository domain.BotRepository,
+	pnlRepository domain.PnLRepository,
+	providers *domain.Providers,
+) *RecordPnL {
+	return &RecordPnL{
+		botRepository: botRepository,
+		pnlRepository: pnlRepository,
+		providers:     providers,
+	}
+}
+
+// Run records the snapshots every interval until the context is canceled.
+func (s *RecordPnL) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Exec(ctx); err != nil {
+				logs.Error(ctx, "could not record pnl snapshots", logs.NewAttr("error", err))
+			}
+		}
+	}
+}
+
+// Exec records a snapshot of every bot, skipping the ones whose price can't be
+// fetched.
+func (s *RecordPnL) Exec(ctx context.Context) error {
+	bots, err := s.botRepository.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	/** Every snapshot of a round has the same time, to compare them */
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, bot := range bots {
+		pnl, err := botPnL(ctx, s.providers, bot, now)
+		if err != nil {
+			logs.Error(ctx, fmt.Sprintf("could not get %s pnl", bot.Name), logs.NewAttr("error", err))
+			continue
+		}
+
+		if err := s.pnlRepository.Save(ctx, pnl); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
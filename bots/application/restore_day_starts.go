@@ -0,0 +1,62 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+)
+
+type RestoreDayStartsInput struct {
+	Time time.Time
+}
+
+// RestoreDayStarts gives the risk manager the mark to market of every bot at
+// the start of the day from the equity snapshots recorded on it, so a restart
+// doesn't reset the daily loss. Once downsampled to hours, the first snapshot
+// of the day is the state at the end of its first hour.
+type RestoreDayStarts struct {
+	botRepository            domain.BotRepository
+	equitySnapshotRepository domain.EquitySnapshotRepository
+	risk                     *domain.RiskManager
+}
+
+func NewRestoreDayStarts(
+	botRepository domain.BotRepository,
+	equitySnapshotRepository domain.EquitySnapshotRepository,
+	risk *domain.RiskManager,
+) *RestoreDayStarts {
+	return &RestoreDayStarts{
+		botRepository:            botRepository,
+		equitySnapshotRepository: equitySnapshotRepository,
+		risk:                     risk,
+	}
+}
+
+func (s *RestoreDayStarts) Exec(ctx context.Context, input *RestoreDayStartsInput) error {
+	bots, err := s.botRepository.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	day := input.Time.UTC().Truncate(24 * time.Hour)
+	for _, bot := range bots {
+		var first *domain.EquitySnapshot
+		for _, resolution := range []string{domain.EquityResolutionHour, domain.EquityResolutionMinute} {
+			snapshots, err := s.equitySnapshotRepository.FindByBotID(ctx, bot.ID, resolution, day, input.Time)
+			if err != nil {
+				return err
+			}
+
+			if len(snapshots) > 0 && (first == nil || snapshots[0].Time.Before(first.Time)) {
+				first = snapshots[0]
+			}
+		}
+
+		if first != nil {
+			s.risk.RestoreDayStart(bot.ID, first.Time, first.MarkToMarket)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/bots/infrastructure"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoreDayStarts(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	botRepo, err := infrastructure.NewSQLiteBotRepo(db)
+	assert.NoError(t, err)
+	equityRepo, err := infrastructure.NewSQLiteEquitySnapshotRepo(db)
+	assert.NoError(t, err)
+
+	now := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	snapshot := func(bot *domain.Bot, at time.Time, resolution string, markToMarket int64) {
+		snapshot, err := domain.NewEquitySnapshot(bot.ID, at, resolution, decimal.Zero, decimal.Zero, decimal.Zero, decimal.NewFromInt(markToMarket), decimal.NewFromInt(markToMarket), decimal.NewFromInt(markToMarket), 0)
+		assert.NoError(t, err)
+		assert.NoError(t, equityRepo.Save(ctx, snapshot))
+	}
+
+	/** Worth 1100 at the start of the day, its first hour already downsampled */
+	restored := newTestBot(t)
+	assert.NoError(t, botRepo.Save(ctx, restored))
+	snapshot(restored, now.Add(-24*time.Hour), domain.EquityResolutionHour, 2000)
+	snapshot(restored, now.Add(-15*time.Hour), domain.EquityResolutionHour, 1100)
+	snapshot(restored, now.Add(-time.Minute), domain.EquityResolutionMinute, 1000)
+
+	/** Only snapshots of the day before */
+	yesterday := newTestBot(t)
+	assert.NoError(t, botRepo.Save(ctx, yesterday))
+	snapshot(yesterday, now.Add(-16*time.Hour), domain.EquityResolutionMinute, 2000)
+
+	risk := domain.NewRiskManager(domain.RiskLimits{MaxDailyLoss: decimal.RequireFromString("0.05")})
+	assert.NoError(t, NewRestoreDayStarts(botRepo, equityRepo, risk).Exec(ctx, &RestoreDayStartsInput{Time: now}))
+
+	/** Worth 1000 now, 9% less than at the start of the day */
+	risk.Track(restored, domain.NewTick(decimal.NewFromInt(50000), now))
+	_, err = restored.GenerateOrder(decimal.NewFromInt(50000), 500, decimal.NewFromInt(100), nil, domain.FeeSchedule{}, risk)
+	assert.ErrorIs(t, err, domain.ErrDailyLossLimitReached)
+
+	risk.Track(yesterday, domain.NewTick(decimal.NewFromInt(50000), now))
+	_, err = yesterday.GenerateOrder(decimal.NewFromInt(50000), 500, decimal.NewFromInt(100), nil, domain.FeeSchedule{}, risk)
+	assert.NoError(t, err)
+}
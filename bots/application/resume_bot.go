@@ -0,0 +1,41 @@
+package application
+
+import (
+	"context"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+)
+
+type ResumeBotInput struct {
+	ID models.ID
+}
+
+type ResumeBot struct {
+	botRepository domain.BotRepository
+}
+
+func NewResumeBot(
+	botRepository domain.BotRepository,
+) *ResumeBot {
+	return &ResumeBot{
+		botRepository: botRepository,
+	}
+}
+
+func (s *ResumeBot) Exec(ctx context.Context, input *ResumeBotInput) (*domain.Bot, error) {
+	bot, err := s.botRepository.FindByID(ctx, input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bot.Resume(); err != nil {
+		return nil, err
+	}
+
+	if err := s.botRepository.SaveStatus(ctx, bot); err != nil {
+		return nil, err
+	}
+
+	return bot, nil
+}
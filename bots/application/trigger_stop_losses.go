@@ -0,0 +1,89 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/logs"
+)
+
+type TriggerStopLossesInput struct {
+	Bot  *domain.Bot
+	Tick domain.Tick
+}
+
+// TriggerStopLosses sells with a MARKET order the positions of a bot whose
+// stop loss the price reached, after canceling their take profit. The loss is
+// booked when the sell is reconciled. The bot is not saved.
+type TriggerStopLosses struct {
+	providers *domain.Providers
+	// Optional, without it the sells are not rounded nor validated before they
+	// are placed.
+	symbolFilters domain.SymbolFiltersProvider
+	fees          domain.FeeSchedule
+}
+
+func NewTriggerStopLosses(
+	providers *domain.Providers,
+	symbolFilters domain.SymbolFiltersProvider,
+	fees domain.FeeSchedule,
+) *TriggerStopLosses {
+	return &TriggerStopLosses{
+		providers:     providers,
+		symbolFilters: symbolFilters,
+		fees:          fees,
+	}
+}
+
+func (s *TriggerStopLosses) Exec(ctx context.Context, input *TriggerStopLossesInput) error {
+	bot, tick := input.Bot, input.Tick
+
+	triggered := bot.TriggeredStopLosses(tick.Price)
+	if len(triggered) == 0 {
+		return nil
+	}
+
+	filters, err := getSymbolFilters(ctx, s.symbolFilters, bot)
+	if err != nil {
+		return err
+	}
+
+	providerRepository := s.providers.ForBot(bot)
+	for _, order := range triggered {
+		logs.Info(ctx, fmt.Sprintf("%s: Stop loss de la orden %s alcanzado a %s %s (stop: %s %s)", bot.Name, order.ID, tick.Price.String(), bot.Currency, order.StopLossPrice.String(), bot.Currency))
+
+		if !cancelTakeProfit(ctx, providerRepository, s.fees, bot, order, tick.Time) {
+			continue
+		}
+
+		/** Sold completely by the take profit before it was canceled */
+		if order.IsClosed() {
+			continue
+		}
+
+		stopLossOrder, err := bot.GenerateStopLossOrder(order, filters)
+		if err != nil {
+			/** The position is too small for the exchange to sell it */
+			if errors.Is(err, domain.ErrInvalid) {
+				logs.Error(ctx, fmt.Sprintf("could not generate %s stop loss order", bot.Name), logs.NewAttr("id", order.ID), logs.NewAttr("error", err))
+				continue
+			}
+
+			return errors.Wrap(domain.ErrInternal, err, "could not generate stop loss order")
+		}
+
+		/** If it fails the order is reconciled as canceled and the stop is
+		triggered again on the next tick */
+		externalId, err := providerRepository.CreateOrderInProvider(ctx, stopLossOrder, bot.Name)
+		if err != nil {
+			logs.Error(ctx, fmt.Sprintf("could not create %s stop loss order in provider", bot.Name), logs.NewAttr("id", stopLossOrder.ID), logs.NewAttr("error", err))
+			continue
+		}
+
+		stopLossOrder.AddExternalId(externalId)
+	}
+
+	return nil
+}
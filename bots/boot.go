@@ -1,20 +1,34 @@
 package bots
 
 import (
+	"net/http"
+
 	"github.com/juankohler/crypto-bot/common"
 )
 
 func Boot(cfg *common.Config, commonDeps *common.Dependencies) error {
-	_, err := BuildDependencies(cfg, commonDeps)
+	deps, err := BuildDependencies(cfg, commonDeps)
 	if err != nil {
 		return err
 	}
 
-	// handlers := NewHandlers(cfg, deps)
-
-	// mux := commonDeps.Mux
-
-	// mux.HandleFunc("POST /v1/init", handlers.Init)
+	registerRoutes(commonDeps.Mux, NewHandlers(cfg, deps))
 
 	return nil
 }
+
+func registerRoutes(mux *http.ServeMux, handlers *Handlers) {
+	mux.HandleFunc("POST /v1/bots", handlers.CreateBot)
+	mux.HandleFunc("GET /v1/bots", handlers.ListBots)
+	mux.HandleFunc("GET /v1/bots/{id}", handlers.GetBot)
+	mux.HandleFunc("POST /v1/bots/{id}/pause", handlers.PauseBot)
+	mux.HandleFunc("POST /v1/bots/{id}/resume", handlers.ResumeBot)
+	mux.HandleFunc("DELETE /v1/bots/{id}", handlers.DeleteBot)
+	mux.HandleFunc("POST /v1/bots/{id}/start", handlers.StartBotWorker)
+	mux.HandleFunc("POST /v1/bots/{id}/stop", handlers.StopBotWorker)
+	mux.HandleFunc("GET /v1/workers", handlers.ListBotWorkers)
+	mux.HandleFunc("GET /v1/pnl", handlers.GetPnL)
+	mux.HandleFunc("GET /v1/bots/{id}/pnl", handlers.GetBotPnL)
+	mux.HandleFunc("GET /v1/bots/{id}/equity", handlers.GetBotEquity)
+	mux.HandleFunc("GET /v1/candles", handlers.GetCandles)
+}
@@ -2,14 +2,29 @@ package bots
 
 import (
 	"context"
+	"time"
 
 	"github.com/juankohler/crypto-bot/bots/application"
+	"github.com/juankohler/crypto-bot/bots/domain"
 	"github.com/juankohler/crypto-bot/bots/infrastructure"
 	"github.com/juankohler/crypto-bot/common"
 	"github.com/juankohler/crypto-bot/libs/go/logs"
+	"github.com/juankohler/crypto-bot/libs/go/restclient"
+	"github.com/shopspring/decimal"
 )
 
 type Dependencies struct {
+	CreateBot    *application.CreateBot
+	ListBots     *application.ListBots
+	GetBot       *application.GetBot
+	PauseBot     *application.PauseBot
+	ResumeBot    *application.ResumeBot
+	DeleteBot    *application.DeleteBot
+	GetCandles   *application.GetCandles
+	BotRunner    *application.BotRunner
+	GetPnL       *application.GetPnL
+	GetBotPnL    *application.GetBotPnL
+	GetBotEquity *application.GetBotEquity
 }
 
 func BuildDependencies(cfg *common.Config, commonDeps *common.Dependencies) (*Dependencies, error) {
@@ -18,13 +33,233 @@ func BuildDependencies(cfg *common.Config, commonDeps *common.Dependencies) (*De
 	})
 
 	/** Infraestruture dependencies */
-	binanceRepo, err := infrastructure.NewBinanceRepo(&cfg.BinanceRepo)
+	fees, err := newFeeSchedule(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	binanceRepo, err := newBinanceRepo(cfg, fees)
 	if err != nil {
 		panic(err)
 	}
 
-	initService := application.NewInit(binanceRepo)
-	initService.Exec(logs.ContextWithLogger(context.Background()), &application.InitInput{})
+	riskLimits, err := newRiskLimits(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	priceHub := infrastructure.NewPriceHub(binanceRepo, infrastructure.PriceHubConfig{
+		MaxAge:       cfg.Prices.MaxAge,
+		MaxStaleness: cfg.Prices.MaxStaleness,
+		BatchWindow:  cfg.Prices.BatchWindow,
+	})
+
+	paperRepo, err := infrastructure.NewPaperRepo(priceHub, paperConfig(cfg, fees, cfg.Paper.Balances))
+	if err != nil {
+		return nil, err
+	}
+
+	providers := domain.NewProviders(infrastructure.WithPriceHub(binanceRepo, priceHub), paperRepo, cfg.Paper.Enabled)
+
+	botRepo, err := infrastructure.NewSQLiteBotRepo(commonDeps.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	orderRepo, err := infrastructure.NewSQLiteOrderRepo(commonDeps.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	candleRepo, err := infrastructure.NewSQLiteCandleRepo(commonDeps.DB)
+	if err != nil {
+		return nil, err
+	}
 
-	return &Dependencies{}, nil
+	pnlRepo, err := infrastructure.NewSQLitePnLRepo(commonDeps.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	equitySnapshotRepo, err := infrastructure.NewSQLiteEquitySnapshotRepo(commonDeps.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	strategies, err := newStrategies()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(logs.ContextWithLogger(context.Background()))
+	commonDeps.OnShutdown(func(ctx context.Context) error {
+		cancel()
+		return nil
+	})
+
+	var stream domain.MarketDataStream
+	if cfg.BinanceStreamEnabled {
+		binanceStream, err := infrastructure.NewBinanceStream(cfg.BinanceStream)
+		if err != nil {
+			return nil, err
+		}
+		go binanceStream.Run(ctx)
+		stream = binanceStream
+	}
+
+	/** The paper exchange starts where it was before the restart */
+	paperOrders, err := orderRepo.FindPaperOrders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := paperRepo.Restore(ctx, paperOrders); err != nil {
+		return nil, err
+	}
+
+	/** Application services */
+	reconcileOrders := application.NewReconcileOrders(providers, binanceRepo, fees)
+	risk := domain.NewRiskManager(riskLimits)
+	/** The loss of the day counts from before the restart */
+	if err := application.NewRestoreDayStarts(botRepo, equitySnapshotRepo, risk).Exec(ctx, &application.RestoreDayStartsInput{Time: time.Now()}); err != nil {
+		return nil, err
+	}
+	triggerStopLosses := application.NewTriggerStopLosses(providers, binanceRepo, fees)
+	adaptDelta := application.NewAdaptDelta(binanceRepo)
+	executeBot := application.NewExecuteBot(providers, strategies, reconcileOrders, triggerStopLosses, adaptDelta, binanceRepo, fees, risk)
+	botRunner := application.NewBotRunner(botRepo, providers, executeBot, risk, stream)
+	if err := botRunner.Start(ctx); err != nil {
+		return nil, err
+	}
+	commonDeps.OnShutdown(botRunner.Shutdown)
+
+	go application.NewRecordPnL(botRepo, pnlRepo, providers).Run(ctx, cfg.PnLSnapshotInterval)
+	go application.NewRecordEquity(botRepo, equitySnapshotRepo, providers, application.RecordEquityConfig{
+		MinuteRetention: cfg.EquitySnapshots.MinuteRetention,
+		HourRetention:   cfg.EquitySnapshots.HourRetention,
+	}).Run(ctx, cfg.EquitySnapshots.Interval)
+
+	return &Dependencies{
+		CreateBot:    application.NewCreateBot(botRepo, strategies, binanceRepo),
+		ListBots:     application.NewListBots(botRepo),
+		GetBot:       application.NewGetBot(botRepo),
+		PauseBot:     application.NewPauseBot(botRepo),
+		ResumeBot:    application.NewResumeBot(botRepo),
+		DeleteBot:    application.NewDeleteBot(botRepo),
+		GetCandles:   application.NewGetCandles(candleRepo, application.NewBackfillCandles(candleRepo, binanceRepo)),
+		BotRunner:    botRunner,
+		GetPnL:       application.NewGetPnL(botRepo, providers),
+		GetBotPnL:    application.NewGetBotPnL(botRepo, pnlRepo, providers),
+		GetBotEquity: application.NewGetBotEquity(botRepo, equitySnapshotRepo),
+	}, nil
+}
+
+type BacktestDependencies struct {
+	Backtest   *application.Backtest
+	GetCandles *application.GetCandles
+}
+
+// BuildBacktestDependencies builds the services to run backtests, without
+// starting the bots.
+func BuildBacktestDependencies(cfg *common.Config, commonDeps *common.Dependencies) (*BacktestDependencies, error) {
+	strategies, err := newStrategies()
+	if err != nil {
+		return nil, err
+	}
+
+	fees, err := newFeeSchedule(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	binanceRepo, err := newBinanceRepo(cfg, fees)
+	if err != nil {
+		return nil, err
+	}
+
+	riskLimits, err := newRiskLimits(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	candleRepo, err := infrastructure.NewSQLiteCandleRepo(commonDeps.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	newReplayProvider := func(balances map[string]decimal.Decimal) (domain.ReplayProvider, error) {
+		return infrastructure.NewReplayRepo(paperConfig(cfg, fees, balances))
+	}
+
+	return &BacktestDependencies{
+		Backtest:   application.NewBacktest(strategies, newReplayProvider, binanceRepo, fees, riskLimits),
+		GetCandles: application.NewGetCandles(candleRepo, application.NewBackfillCandles(candleRepo, binanceRepo)),
+	}, nil
+}
+
+type binanceProvider interface {
+	domain.ProviderRepository
+	domain.PricesProvider
+	domain.CandleProvider
+	domain.SymbolFiltersProvider
+	domain.SymbolValidator
+}
+
+func newBinanceRepo(cfg *common.Config, fees domain.FeeSchedule) (binanceProvider, error) {
+	rateLimiter := restclient.NewRateLimiter(restclient.RateLimiterConfig{
+		Limits: []restclient.RateLimit{
+			{
+				Name:        infrastructure.BinanceRateLimitWeight,
+				Limit:       cfg.BinanceRateLimits.MaxWeightPerMinute,
+				Interval:    time.Minute,
+				UsageHeader: infrastructure.BinanceWeightHeader,
+			},
+			{
+				Name:        infrastructure.BinanceRateLimitOrders,
+				Limit:       cfg.BinanceRateLimits.MaxOrdersPer10Seconds,
+				Interval:    10 * time.Second,
+				UsageHeader: infrastructure.BinanceOrderCountHeader,
+			},
+		},
+		MaxWait: cfg.BinanceRateLimits.MaxWait,
+	})
+
+	return infrastructure.NewBinanceRepo(&cfg.BinanceRepo, infrastructure.BinanceCredentials{
+		ApiKey:       cfg.BinanceApiKey,
+		SecretKey:    cfg.BinanceSecretKey,
+		RecvWindowMs: cfg.BinanceRecvWindowMs,
+	}, fees, rateLimiter)
+}
+
+func newStrategies() (*domain.StrategyRegistry, error) {
+	return domain.NewStrategyRegistry(
+		domain.NewGridStrategy(),
+		domain.NewBuyTheDipStrategy(),
+		domain.NewGeometricGridStrategy(),
+		domain.NewDCAStrategy(),
+	)
+}
+
+func newFeeSchedule(cfg *common.Config) (domain.FeeSchedule, error) {
+	return domain.NewFeeSchedule(cfg.Fees.MakerFee, cfg.Fees.TakerFee, cfg.Fees.BNBDiscount, cfg.Fees.PayWithBNB)
+}
+
+func newRiskLimits(cfg *common.Config) (domain.RiskLimits, error) {
+	return domain.NewRiskLimits(
+		cfg.Risk.MaxPositionSize,
+		cfg.Risk.MaxOpenOrders,
+		cfg.Risk.MaxInvestedFraction,
+		cfg.Risk.MaxSymbolExposure,
+		cfg.Risk.MaxDailyLoss,
+	)
+}
+
+// paperConfig simulates the fee schedule of the account, charged in the
+// traded assets even when it is paid in BNB.
+func paperConfig(cfg *common.Config, fees domain.FeeSchedule, balances map[string]decimal.Decimal) infrastructure.PaperConfig {
+	return infrastructure.PaperConfig{
+		MakerFee: fees.Rate(domain.OrderTypeLimit),
+		TakerFee: fees.Rate(domain.OrderTypeMarket),
+		Slippage: cfg.Paper.Slippage,
+		Balances: balances,
+	}
 }
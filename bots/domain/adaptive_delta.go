@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+)
+
+// AdaptiveDelta derives the Delta of a bot from the recent volatility of the
+// pair: its ATR over the candles of an interval, bounded. The Delta only moves
+// when the change is larger than the hysteresis, so it does not follow every
+// small change of the volatility.
+type AdaptiveDelta struct {
+	// Candle interval the ATR is computed on, e.g. 1h.
+	Interval string
+	// Number of candles of the ATR.
+	Period     int
+	Multiplier decimal.Decimal
+	MinDelta   decimal.Decimal
+	MaxDelta   decimal.Decimal
+	// Minimum change of the Delta to move it, as a fraction of the current
+	// one.
+	Hysteresis decimal.Decimal
+}
+
+func NewAdaptiveDelta(
+	interval string,
+	period int,
+	multiplier decimal.Decimal,
+	minDelta decimal.Decimal,
+	maxDelta decimal.Decimal,
+	hysteresis decimal.Decimal,
+) (*AdaptiveDelta, error) {
+	if _, err := CandleIntervalDuration(interval); err != nil {
+		return nil, err
+	}
+
+	if period < 1 {
+		return nil, errors.New(ErrInvalid, "ATR period must be positive", errors.WithMetadata("period", period))
+	}
+
+	if !multiplier.IsPositive() {
+		return nil, errors.New(ErrInvalid, "ATR multiplier must be positive", errors.WithMetadata("multiplier", multiplier.String()))
+	}
+
+	if !minDelta.IsPositive() || maxDelta.LessThan(minDelta) {
+		return nil, errors.New(
+			ErrInvalid,
+			"invalid delta bounds",
+			errors.WithMetadata("min_delta", minDelta.String()),
+			errors.WithMetadata("max_delta", maxDelta.String()),
+		)
+	}
+
+	if hysteresis.IsNegative() || hysteresis.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return nil, errors.New(ErrInvalid, "hysteresis must be between 0 and 1", errors.WithMetadata("hysteresis", hysteresis.String()))
+	}
+
+	return &AdaptiveDelta{
+		Interval:   interval,
+		Period:     period,
+		Multiplier: multiplier,
+		MinDelta:   minDelta,
+		MaxDelta:   maxDelta,
+		Hysteresis: hysteresis,
+	}, nil
+}
+
+// Target returns the Delta for the given ATR, within the bounds and rounded to
+// the precision of the prices.
+func (a *AdaptiveDelta) Target(atr decimal.Decimal) decimal.Decimal {
+	return decimal.Min(decimal.Max(atr.Mul(a.Multiplier).Round(8), a.MinDelta), a.MaxDelta)
+}
+
+// ShouldMove reports whether the Delta has to move from current to target.
+func (a *AdaptiveDelta) ShouldMove(current decimal.Decimal, target decimal.Decimal) bool {
+	if !current.IsPositive() {
+		return true
+	}
+
+	return target.Sub(current).Abs().Div(current).GreaterThan(a.Hysteresis)
+}
+
+func (a *AdaptiveDelta) Equal(other *AdaptiveDelta) bool {
+	return other != nil &&
+		a.Interval == other.Interval &&
+		a.Period == other.Period &&
+		a.Multiplier.Equal(other.Multiplier) &&
+		a.MinDelta.Equal(other.MinDelta) &&
+		a.MaxDelta.Equal(other.MaxDelta) &&
+		a.Hysteresis.Equal(other.Hysteresis)
+}
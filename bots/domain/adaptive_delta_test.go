@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAdaptiveDelta(t *testing.T) *AdaptiveDelta {
+	adaptiveDelta, err := NewAdaptiveDelta(
+		"1h",
+		14,
+		decimal.RequireFromString("1.5"),
+		decimal.NewFromInt(50),
+		decimal.NewFromInt(500),
+		decimal.RequireFromString("0.1"),
+	)
+	assert.NoError(t, err)
+	return adaptiveDelta
+}
+
+func TestNewAdaptiveDelta(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		interval   string
+		period     int
+		multiplier string
+		minDelta   string
+		maxDelta   string
+		hysteresis string
+	}{
+		{"interval", "7m", 14, "1", "1", "10", "0"},
+		{"period", "1h", 0, "1", "1", "10", "0"},
+		{"multiplier", "1h", 14, "0", "1", "10", "0"},
+		{"min delta", "1h", 14, "1", "0", "10", "0"},
+		{"max delta", "1h", 14, "1", "10", "1", "0"},
+		{"hysteresis", "1h", 14, "1", "1", "10", "1"},
+	} {
+		_, err := NewAdaptiveDelta(
+			tt.interval,
+			tt.period,
+			decimal.RequireFromString(tt.multiplier),
+			decimal.RequireFromString(tt.minDelta),
+			decimal.RequireFromString(tt.maxDelta),
+			decimal.RequireFromString(tt.hysteresis),
+		)
+		assert.True(t, errors.Is(err, ErrInvalid), tt.name)
+	}
+}
+
+func TestAdaptiveDeltaTarget(t *testing.T) {
+	adaptiveDelta := newTestAdaptiveDelta(t)
+
+	assert.Equal(t, "150", adaptiveDelta.Target(decimal.NewFromInt(100)).String())
+	/** Clamped to the bounds */
+	assert.Equal(t, "50", adaptiveDelta.Target(decimal.NewFromInt(10)).String())
+	assert.Equal(t, "500", adaptiveDelta.Target(decimal.NewFromInt(1000)).String())
+	/** Rounded to the precision of the prices */
+	assert.Equal(t, "66.66666667", adaptiveDelta.Target(decimal.RequireFromString("44.444444444")).String())
+}
+
+func TestAdaptiveDeltaShouldMove(t *testing.T) {
+	adaptiveDelta := newTestAdaptiveDelta(t)
+	current := decimal.NewFromInt(100)
+
+	/** Within the 10% hysteresis, either way */
+	assert.False(t, adaptiveDelta.ShouldMove(current, decimal.NewFromInt(110)))
+	assert.False(t, adaptiveDelta.ShouldMove(current, decimal.NewFromInt(90)))
+	assert.True(t, adaptiveDelta.ShouldMove(current, decimal.NewFromInt(111)))
+	assert.True(t, adaptiveDelta.ShouldMove(current, decimal.NewFromInt(89)))
+	assert.True(t, adaptiveDelta.ShouldMove(decimal.Zero, decimal.NewFromInt(50)))
+}
+
+func TestBotAdaptDelta(t *testing.T) {
+	bot := newTestBot(t, StrategyGrid, nil, nil)
+
+	/** Without an adaptive delta the Delta is fixed */
+	assert.False(t, bot.AdaptDelta(decimal.NewFromInt(300)))
+	assert.Equal(t, "100", bot.Delta.String())
+
+	bot.AdaptiveDelta = newTestAdaptiveDelta(t)
+
+	/** 105 is within the hysteresis of 100 */
+	assert.False(t, bot.AdaptDelta(decimal.NewFromInt(70)))
+	assert.Equal(t, "100", bot.Delta.String())
+
+	assert.True(t, bot.AdaptDelta(decimal.NewFromInt(200)))
+	assert.Equal(t, "300", bot.Delta.String())
+	assert.True(t, bot.Version.IsUpdated())
+
+	/** Clamped to the bounds */
+	assert.True(t, bot.AdaptDelta(decimal.NewFromInt(1000)))
+	assert.Equal(t, "500", bot.Delta.String())
+	assert.False(t, bot.AdaptDelta(decimal.NewFromInt(2000)))
+
+	assert.True(t, bot.AdaptDelta(decimal.NewFromInt(1)))
+	assert.Equal(t, "50", bot.Delta.String())
+}
@@ -3,6 +3,7 @@ package domain
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,9 +15,29 @@ import (
 
 type BotRepository interface {
 	FindByID(ctx context.Context, id models.ID) (*Bot, error)
-	Save(ctx context.Context, user *Bot) error
+	FindAll(ctx context.Context) ([]*Bot, error)
+	FindPage(ctx context.Context, offset int, limit int) ([]*Bot, int, error)
+	// Save stores everything but the status and the deletion of the bot,
+	// which are only stored on insert.
+	Save(ctx context.Context, bot *Bot) error
+	// SaveStatus stores only the status and the deletion of the bot, without
+	// checking nor bumping its version, so pausing, resuming or deleting a bot
+	// doesn't conflict with the cycle its worker is saving.
+	SaveStatus(ctx context.Context, bot *Bot) error
 }
 
+const (
+	BotStatusActive = "ACTIVE"
+	BotStatusPaused = "PAUSED"
+)
+
+const (
+	// Orders are sent to the exchange.
+	BotModeLive = "LIVE"
+	// Orders are simulated against live prices, without risking capital.
+	BotModePaper = "PAPER"
+)
+
 type Bot struct {
 	ID                   models.ID
 	Name                 string
@@ -29,10 +50,27 @@ type Bot struct {
 	TargetCurrency       string
 	Delta                decimal.Decimal
 	MonitorInterval      time.Duration
+	Strategy             string
+	StrategyParams       StrategyParams
+	Status               string
+	Mode                 string
 	Timestamps           models.Timestamps
 	Version              models.Version
 	OpenOrders           []*Order
 	LastSalePrice        *decimal.Decimal
+	// Net profit of the positions sold, after every fee, including the ones
+	// paid in BNB that are not taken from the capital.
+	RealizedPnL decimal.Decimal
+	// Fees of every order, valued in the currency of the bot.
+	FeesPaid decimal.Decimal
+	// Optional, positions are only sold by their take profit without it.
+	StopLoss *StopLoss
+	// Optional, the Delta is fixed without it.
+	AdaptiveDelta *AdaptiveDelta
+
+	// Orders closed since the bot was last saved. The repository persists
+	// them together with the bot and then clears the list.
+	ClosedOrders []*Order
 
 	mu sync.Mutex
 }
@@ -49,11 +87,56 @@ func NewBot(
 	totalCapital decimal.Decimal,
 	delta decimal.Decimal,
 	monitorInterval time.Duration,
+	strategy string,
+	strategyParams StrategyParams,
+	status string,
+	mode string,
 	openOrders []*Order,
 	lastSalePrice *decimal.Decimal,
+	realizedPnL decimal.Decimal,
+	feesPaid decimal.Decimal,
+	stopLoss *StopLoss,
+	adaptiveDelta *AdaptiveDelta,
 	timestamps models.Timestamps,
 	version models.Version,
 ) (*Bot, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, errors.New(ErrInvalid, "name is required")
+	}
+
+	symbol, err := NewSymbol(targetCurrency, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if !takeProfitPercentaje.IsPositive() {
+		return nil, errors.New(ErrInvalid, "take profit percentage must be positive", errors.WithMetadata("take_profit_percentaje", takeProfitPercentaje))
+	}
+
+	if !initialCapital.IsPositive() {
+		return nil, errors.New(ErrInvalid, "initial capital must be positive", errors.WithMetadata("initial_capital", initialCapital))
+	}
+
+	if !delta.IsPositive() {
+		return nil, errors.New(ErrInvalid, "delta must be positive", errors.WithMetadata("delta", delta))
+	}
+
+	if monitorInterval < time.Second {
+		return nil, errors.New(ErrInvalid, "monitor interval must be at least one second", errors.WithMetadata("monitor_interval", monitorInterval.String()))
+	}
+
+	if strategy == "" {
+		return nil, errors.New(ErrInvalid, "strategy is required")
+	}
+
+	if status != BotStatusActive && status != BotStatusPaused {
+		return nil, errors.New(ErrInvalid, "invalid status", errors.WithMetadata("status", status))
+	}
+
+	if mode != BotModeLive && mode != BotModePaper {
+		return nil, errors.New(ErrInvalid, "invalid mode", errors.WithMetadata("mode", mode))
+	}
+
 	entity := &Bot{
 		ID:                   id,
 		Name:                 name,
@@ -62,12 +145,20 @@ func NewBot(
 		AvailableCapital:     availableCapital,
 		InvestedCapital:      investedCapital,
 		TotalCapital:         totalCapital,
-		Currency:             currency,
-		TargetCurrency:       targetCurrency,
+		Currency:             symbol.Quote,
+		TargetCurrency:       symbol.Base,
 		Delta:                delta,
 		MonitorInterval:      monitorInterval,
+		Strategy:             strategy,
+		StrategyParams:       strategyParams,
+		Status:               status,
+		Mode:                 mode,
 		OpenOrders:           openOrders,
 		LastSalePrice:        lastSalePrice,
+		RealizedPnL:          realizedPnL,
+		FeesPaid:             feesPaid,
+		StopLoss:             stopLoss,
+		AdaptiveDelta:        adaptiveDelta,
 		Timestamps:           timestamps,
 		Version:              version,
 	}
@@ -75,10 +166,16 @@ func NewBot(
 	return entity, nil
 }
 
-// func (t *Bot) updated() {
-// 	t.Timestamps = t.Timestamps.Update()
-// 	t.Version = t.Version.Update()
-// }
+func (t *Bot) updated() {
+	t.Timestamps = t.Timestamps.Update()
+	t.Version = t.Version.Update()
+}
+
+// statusUpdated is updated for the changes stored through SaveStatus, which
+// leave the version as it is.
+func (t *Bot) statusUpdated() {
+	t.Timestamps = t.Timestamps.Update()
+}
 
 func CreateBot(
 	name string,
@@ -88,10 +185,23 @@ func CreateBot(
 	initialCapital decimal.Decimal,
 	delta decimal.Decimal,
 	monitorInterval time.Duration,
+	strategy string,
+	strategyParams StrategyParams,
+	mode string,
+	stopLoss *StopLoss,
+	adaptiveDelta *AdaptiveDelta,
 ) (*Bot, error) {
 	id, err := models.GenerateNanoID(10)
 	if err != nil {
-		errors.Wrap(ErrInternal, err, "could not generate nano id")
+		return nil, errors.Wrap(ErrInternal, err, "could not generate nano id")
+	}
+
+	if strategyParams == nil {
+		strategyParams = StrategyParams{}
+	}
+
+	if mode == "" {
+		mode = BotModeLive
 	}
 
 	availableCapital := initialCapital
@@ -112,8 +222,16 @@ func CreateBot(
 		totalCapital,
 		delta,
 		monitorInterval,
+		strategy,
+		strategyParams,
+		BotStatusActive,
+		mode,
 		openOrders,
 		lastSalePrice,
+		decimal.Zero,
+		decimal.Zero,
+		stopLoss,
+		adaptiveDelta,
 		models.CreateTimestamps(),
 		models.CreateVersion(),
 	)
@@ -124,10 +242,97 @@ func CreateBot(
 	return entity, nil
 }
 
+func (s *Bot) IsPaper() bool {
+	return s.Mode == BotModePaper
+}
+
+func (s *Bot) IsActive() bool {
+	return s.Status == BotStatusActive && s.Timestamps.DeletedAt == nil
+}
+
+func (s *Bot) Pause() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Status == BotStatusPaused {
+		return errors.New(ErrInvalid, "bot is already paused", errors.WithMetadata("id", s.ID))
+	}
+
+	s.Status = BotStatusPaused
+	s.statusUpdated()
+
+	return nil
+}
+
+func (s *Bot) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Status == BotStatusActive {
+		return errors.New(ErrInvalid, "bot is already active", errors.WithMetadata("id", s.ID))
+	}
+
+	s.Status = BotStatusActive
+	s.statusUpdated()
+
+	return nil
+}
+
+func (s *Bot) Delete() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Timestamps = s.Timestamps.Delete()
+	s.statusUpdated()
+}
+
 func (s *Bot) CalculatePriceRange(currentPrice decimal.Decimal) int {
 	return int(currentPrice.Div(s.Delta).Floor().IntPart())
 }
 
+// HasOpenOrderAtPrice reports whether the price is in the price range of an
+// open order. Each order keeps the range of the Delta it was placed with, even
+// if the Delta moved since.
+func (s *Bot) HasOpenOrderAtPrice(price decimal.Decimal) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, order := range s.OpenOrders {
+		delta := order.Delta
+		if !delta.IsPositive() {
+			delta = s.Delta
+		}
+
+		if int(price.Div(delta).Floor().IntPart()) == order.PriceRange {
+			return true
+		}
+	}
+	return false
+}
+
+// AdaptDelta moves the Delta to the target of the adaptive delta for the given
+// ATR, unless the change is within the hysteresis. It returns whether it moved.
+func (s *Bot) AdaptDelta(atr decimal.Decimal) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.AdaptiveDelta == nil {
+		return false
+	}
+
+	target := s.AdaptiveDelta.Target(atr)
+	if target.Equal(s.Delta) || !s.AdaptiveDelta.ShouldMove(s.Delta, target) {
+		return false
+	}
+
+	s.Delta = target
+	s.updated()
+
+	return true
+}
+
+// HasOpenOrderWithPriceRange reports whether an open order was placed for the
+// price range, for strategies with their own ranges instead of the Delta.
 func (s *Bot) HasOpenOrderWithPriceRange(priceRange int) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -147,12 +352,59 @@ func (s *Bot) HasOpenOrder() bool {
 	return len(s.OpenOrders) > 0
 }
 
-func (s *Bot) GenerateOrder(currentPrice decimal.Decimal, priceRange int, initialQuoteAmount decimal.Decimal) (*Order, error) {
-	quantity := initialQuoteAmount.Div(currentPrice)
-	takeProfit := currentPrice.Add(currentPrice.Mul(s.TakeProfitPercentaje))
+// GenerateOrder creates the MARKET buy of a decision and reserves its capital.
+// The buy spends exactly that capital, so its quantity is an estimate at the
+// current price until it is filled.
+// The take profit covers the fees of the buy and its sell. With the filters of
+// the pair, the quantity and take profit are rounded as the exchange requires
+// and orders it would reject are not created. Buys breaching the limits of the
+// risk manager are rejected with ErrRiskRejected. The stop loss, if any, is
+// estimated from the current price until the buy is filled.
+func (s *Bot) GenerateOrder(currentPrice decimal.Decimal, priceRange int, initialQuoteAmount decimal.Decimal, filters *SymbolFilters, fees FeeSchedule, risk *RiskManager) (*Order, error) {
+	return s.generateEntryOrder(OrderTypeMarket, currentPrice, currentPrice, priceRange, initialQuoteAmount, filters, fees, risk)
+}
+
+// GenerateLimitOrder creates a LIMIT buy at the given price, rounded down to
+// the tick size of the pair, as GenerateOrder does for MARKET buys. Its capital
+// is reserved while it waits in the book.
+func (s *Bot) GenerateLimitOrder(price decimal.Decimal, currentPrice decimal.Decimal, priceRange int, initialQuoteAmount decimal.Decimal, filters *SymbolFilters, fees FeeSchedule, risk *RiskManager) (*Order, error) {
+	if filters != nil {
+		price = filters.RoundPriceDown(price)
+	}
+
+	if !price.IsPositive() {
+		return nil, errors.New(ErrInvalid, "limit price must be positive", errors.WithMetadata("price", price.String()))
+	}
+
+	return s.generateEntryOrder(OrderTypeLimit, price, currentPrice, priceRange, initialQuoteAmount, filters, fees, risk)
+}
+
+func (s *Bot) generateEntryOrder(
+	orderType string,
+	entryPrice decimal.Decimal,
+	currentPrice decimal.Decimal,
+	priceRange int,
+	initialQuoteAmount decimal.Decimal,
+	filters *SymbolFilters,
+	fees FeeSchedule,
+	risk *RiskManager,
+) (*Order, error) {
+	quantity := initialQuoteAmount.Div(entryPrice)
+	takeProfit := fees.TakeProfitPrice(entryPrice, s.TakeProfitPercentaje)
+	if filters != nil {
+		quantity = filters.RoundQuantity(quantity)
+		takeProfit = filters.RoundPriceUp(takeProfit)
+
+		if err := filters.Validate(quantity, entryPrice); err != nil {
+			return nil, err
+		}
+	}
+	stopLossPrice := decimal.Zero
+	if s.StopLoss != nil {
+		stopLossPrice = s.StopLoss.Price(entryPrice)
+	}
 	finalQuoteAmount := quantity.Mul(takeProfit)
 	status := OrderStatusPending
-	symbol := s.TargetCurrency + "/" + s.Currency
 	orderId, err := models.GenerateNanoID(14)
 	if err != nil {
 		return nil, errors.Wrap(ErrInternal, err, "could not generate order id")
@@ -161,15 +413,29 @@ func (s *Bot) GenerateOrder(currentPrice decimal.Decimal, priceRange int, initia
 	newOrder, err := NewOrder(
 		orderId,
 		s.ID,
-		symbol,
+		nil,
+		nil,
+		s.Symbol().String(),
+		OrderSideBuy,
+		orderType,
 		quantity,
 		initialQuoteAmount,
 		finalQuoteAmount,
-		currentPrice,
+		entryPrice,
 		takeProfit,
+		decimal.Zero,
+		decimal.Zero,
+		decimal.Zero,
+		"",
+		decimal.Zero,
+		decimal.Zero,
+		stopLossPrice,
+		"",
 		nil,
 		status,
 		priceRange,
+		s.Delta,
+		nil,
 		models.CreateTimestamps(),
 		models.CreateVersion(),
 	)
@@ -178,36 +444,552 @@ func (s *Bot) GenerateOrder(currentPrice decimal.Decimal, priceRange int, initia
 	}
 
 	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := risk.reserve(s, newOrder.InitialQuoteAmount, currentPrice); err != nil {
+		return nil, err
+	}
+
 	s.InvestedCapital = s.InvestedCapital.Add(newOrder.InitialQuoteAmount)
 	s.AvailableCapital = s.AvailableCapital.Sub(newOrder.InitialQuoteAmount)
 	s.TotalCapital = s.InvestedCapital.Add(s.AvailableCapital)
 	s.OpenOrders = append(s.OpenOrders, newOrder)
-	s.mu.Unlock()
+	s.updated()
 
 	return newOrder, nil
 }
 
-func (s *Bot) RemoveOrdersBelowPrice(ctx context.Context, price decimal.Decimal) {
+// MarkToMarket returns the value of the bot at the given price: the available
+// capital plus the filled positions at that price and the capital reserved for
+// the orders not filled yet.
+func (s *Bot) MarkToMarket(price decimal.Decimal) decimal.Decimal {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	var filteredOrders []*Order
+	value, _ := s.markToMarket(price)
+
+	return value
+}
+
+// PnL returns the profit of the bot with its open positions marked to the
+// given price.
+func (s *Bot) PnL(price decimal.Decimal, at time.Time) *PnL {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	equity, unrealizedPnL := s.markToMarket(price)
+
+	return NewPnL(s.ID, s.Currency, at, price, s.InitialCapital, s.RealizedPnL, unrealizedPnL, equity, s.FeesPaid)
+}
+
+// EquitySnapshot returns the state of the capital of the bot with its open
+// positions marked to the given price, with minute resolution.
+func (s *Bot) EquitySnapshot(price decimal.Decimal, at time.Time) *EquitySnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	markToMarket, _ := s.markToMarket(price)
+
+	return &EquitySnapshot{
+		BotID:            s.ID,
+		Time:             at.Truncate(time.Minute),
+		Resolution:       EquityResolutionMinute,
+		AvailableCapital: s.AvailableCapital,
+		InvestedCapital:  s.InvestedCapital,
+		TotalCapital:     s.TotalCapital,
+		MarkToMarket:     markToMarket,
+		LowMarkToMarket:  markToMarket,
+		HighMarkToMarket: markToMarket,
+		OpenOrders:       len(s.OpenOrders),
+	}
+}
+
+// markToMarket returns the value of the bot at the price and the profit of the
+// filled positions over their cost.
+func (s *Bot) markToMarket(price decimal.Decimal) (decimal.Decimal, decimal.Decimal) {
+	value := s.AvailableCapital
+	unrealizedPnL := decimal.Zero
 	for _, order := range s.OpenOrders {
-		if order.TakeProfitPrice.GreaterThan(price) {
-			filteredOrders = append(filteredOrders, order)
+		if order.IsFilled() {
+			positionValue := order.Quantity.Mul(price)
+			value = value.Add(positionValue)
+			unrealizedPnL = unrealizedPnL.Add(positionValue.Sub(order.InitialQuoteAmount))
+		} else {
+			value = value.Add(order.InitialQuoteAmount)
+		}
+	}
+
+	return value, unrealizedPnL
+}
+
+// OrdersToReconcile returns the open orders, buys and take profit sells, the
+// provider has not finished executing yet.
+func (s *Bot) OrdersToReconcile() []*Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var orders []*Order
+	for _, order := range s.OpenOrders {
+		if !order.IsFinal() {
+			orders = append(orders, order)
+		}
+		if order.TakeProfitOrder != nil && !order.TakeProfitOrder.IsFinal() {
+			orders = append(orders, order.TakeProfitOrder)
+		}
+	}
+
+	return orders
+}
+
+// ReconcileOrder applies the state reported by the provider to one of the open
+// orders and settles the capital, fees and profit once the order is final,
+// closing the orders at the given time.
+func (s *Bot) ReconcileOrder(ctx context.Context, order *Order, providerOrder *ProviderOrder, fees FeeSchedule, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wasFinal := order.IsFinal()
+	if !order.Update(providerOrder) || wasFinal || !order.IsFinal() {
+		return
+	}
+
+	if order.IsTakeProfit() {
+		s.settleTakeProfitOrder(ctx, order, fees, at)
+	} else {
+		s.settleEntryOrder(ctx, order, fees, at)
+	}
+}
+
+// settleEntryOrder settles the capital reserved for a buy with the executed
+// amounts and fee: the unspent part goes back to the available capital and, if
+// nothing was executed, the order is closed. A fee paid in another asset, like
+// BNB, is not taken from the capital but booked as a loss right away.
+func (s *Bot) settleEntryOrder(ctx context.Context, order *Order, fees FeeSchedule, at time.Time) {
+	feeQuoteAmount := fees.QuoteValue(order, s.TargetCurrency, s.Currency)
+	spent := order.ExecutedQuoteAmount
+	received := order.ExecutedQuantity
+	otherFee := decimal.Zero
+	switch order.FeeCurrency {
+	case s.Currency:
+		spent = spent.Add(order.Fee)
+	case s.TargetCurrency:
+		received = received.Sub(order.Fee)
+	default:
+		otherFee = feeQuoteAmount
+	}
+
+	s.AvailableCapital = s.AvailableCapital.Add(order.InitialQuoteAmount).Sub(spent)
+	s.InvestedCapital = s.InvestedCapital.Sub(order.InitialQuoteAmount).Add(spent)
+	s.TotalCapital = s.InvestedCapital.Add(s.AvailableCapital)
+	s.updated()
+
+	if !received.IsPositive() {
+		logs.Info(ctx, fmt.Sprintf("%s: Orden %s cancelada sin ejecutar", s.Name, order.ID))
+		order.Settle(decimal.Zero, decimal.Zero, decimal.Zero, order.TakeProfitPrice)
+		order.Close(at)
+		s.removeOpenOrder(order)
+		return
+	}
+
+	/** The fees of the buy are already in its cost per unit */
+	takeProfit := fees.takeProfitPriceFromCost(spent.Add(otherFee).Div(received), s.TakeProfitPercentaje)
+	order.Settle(spent, received, feeQuoteAmount, takeProfit)
+	if s.StopLoss != nil {
+		order.SetStopLossPrice(s.StopLoss.Price(order.EntryPrice))
+	}
+	s.FeesPaid = s.FeesPaid.Add(feeQuoteAmount)
+	if otherFee.IsPositive() {
+		order.AddRealizedPnL(otherFee.Neg())
+		s.RealizedPnL = s.RealizedPnL.Sub(otherFee)
+	}
+
+	logs.Info(ctx, fmt.Sprintf("%s: Compra ejecutada %s %s a %s %s (%s %s, fee: %s %s)", s.Name, order.Quantity.String(), s.TargetCurrency, order.EntryPrice.String(), s.Currency, spent.String(), s.Currency, order.Fee.String(), order.FeeCurrency))
+
+	if order.PositionID != nil {
+		s.mergeIntoPosition(ctx, order, at)
+	}
+}
+
+// mergeIntoPosition merges a filled buy into the position it joins, whose take
+// profit is replaced afterwards. If that position was closed or is being sold
+// at a loss meanwhile, the buy keeps its own position.
+func (s *Bot) mergeIntoPosition(ctx context.Context, order *Order, at time.Time) {
+	var position *Order
+	for _, openOrder := range s.OpenOrders {
+		if openOrder.ID == *order.PositionID {
+			position = openOrder
+			break
+		}
+	}
+	if position == nil || !position.IsFilled() || (position.TakeProfitOrder != nil && position.TakeProfitOrder.IsStopLoss()) {
+		logs.Warn(ctx, fmt.Sprintf("%s: La orden %s queda como posición propia, la posición %s ya no está abierta", s.Name, order.ID, *order.PositionID))
+		return
+	}
+
+	position.Merge(order)
+	if s.StopLoss != nil {
+		position.SetStopLossPrice(s.StopLoss.Price(position.EntryPrice))
+	}
+	order.Exit(ExitReasonMerged, at)
+	s.removeOpenOrder(order)
+
+	logs.Info(ctx, fmt.Sprintf("%s: Orden %s sumada a la posición %s, %s %s a un precio medio de %s %s (take profit: %s %s)", s.Name, order.ID, position.ID, position.Quantity.String(), s.TargetCurrency, position.EntryPrice.String(), s.Currency, position.TakeProfitPrice.String(), s.Currency))
+}
+
+// settleTakeProfitOrder releases the capital of the position sold by a take
+// profit, or a stop loss, and books its net profit or loss. The position is
+// closed once it was sold completely, otherwise, if the sell was canceled, the
+// position keeps the rest and a new take profit is placed for it.
+func (s *Bot) settleTakeProfitOrder(ctx context.Context, order *Order, fees FeeSchedule, at time.Time) {
+	var entry *Order
+	for _, openOrder := range s.OpenOrders {
+		if openOrder.TakeProfitOrder == order {
+			entry = openOrder
+			break
+		}
+	}
+	if entry == nil {
+		return
+	}
+
+	order.Close(at)
+	entry.TakeProfitOrder = nil
+	s.ClosedOrders = append(s.ClosedOrders, order)
+
+	sold := order.ExecutedQuantity
+	if sold.IsPositive() {
+		feeQuoteAmount := fees.QuoteValue(order, s.TargetCurrency, s.Currency)
+		received := order.ExecutedQuoteAmount
+		otherFee := decimal.Zero
+		if order.FeeCurrency == s.Currency {
+			received = received.Sub(order.Fee)
 		} else {
-			logs.Info(ctx, fmt.Sprintf("%s: Venta %s BTC a %s USDT (%s USDT)", s.Name, order.Quantity.String(), order.TakeProfitPrice.String(), order.FinalQuoteAmount.String()))
-			s.InvestedCapital = s.InvestedCapital.Sub(order.InitialQuoteAmount)
-			s.AvailableCapital = s.AvailableCapital.Add(order.FinalQuoteAmount)
-			s.TotalCapital = s.InvestedCapital.Add(s.AvailableCapital)
-			lastSalePrice := price
-			s.LastSalePrice = &lastSalePrice
+			otherFee = feeQuoteAmount
+		}
+
+		/** A take profit sold completely closes the position, with the dust
+		left out of it by the step size */
+		cost, reduced := entry.InitialQuoteAmount, entry.Quantity
+		if order.Status != OrderStatusCompleted && sold.LessThan(entry.Quantity) {
+			cost, reduced = cost.Mul(sold).Div(entry.Quantity), sold
+		}
+
+		s.InvestedCapital = s.InvestedCapital.Sub(cost)
+		s.AvailableCapital = s.AvailableCapital.Add(received)
+		s.TotalCapital = s.InvestedCapital.Add(s.AvailableCapital)
+		lastSalePrice := order.ExecutedQuoteAmount.Div(sold)
+		s.LastSalePrice = &lastSalePrice
+		entry.Reduce(reduced, cost)
+
+		pnl := received.Sub(cost).Sub(otherFee)
+		order.SettleSale(feeQuoteAmount, pnl)
+		entry.AddRealizedPnL(pnl)
+		s.RealizedPnL = s.RealizedPnL.Add(pnl)
+		s.FeesPaid = s.FeesPaid.Add(feeQuoteAmount)
+
+		sale := "Venta"
+		if order.IsStopLoss() {
+			sale = "Venta por stop loss"
+		}
+
+		logs.Info(ctx, fmt.Sprintf("%s: %s %s %s a %s %s (%s %s, ganancia neta: %s %s)", s.Name, sale, sold.String(), s.TargetCurrency, lastSalePrice.String(), s.Currency, received.String(), s.Currency, pnl.String(), s.Currency))
+	}
+
+	if !entry.Quantity.IsPositive() {
+		entry.Exit(order.ExitReason, at)
+		s.removeOpenOrder(entry)
+
+		println()
+		logs.Info(ctx, fmt.Sprintf("%s: Saldo total %s", s.Name, s.TotalCapital.String()))
+		println()
+	} else {
+		logs.Info(ctx, fmt.Sprintf("%s: Venta de la orden %s cancelada, quedan %s %s", s.Name, entry.ID, entry.Quantity.String(), s.TargetCurrency))
+	}
+
+	s.updated()
+}
+
+// Positions returns the open buys that do not join the position of another
+// one.
+func (s *Bot) Positions() []*Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var positions []*Order
+	for _, order := range s.OpenOrders {
+		if order.PositionID == nil {
+			positions = append(positions, order)
+		}
+	}
+
+	return positions
+}
+
+// HasPendingBuyForPosition reports whether a buy joining the position is not
+// merged into it yet.
+func (s *Bot) HasPendingBuyForPosition(positionID models.ID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, order := range s.OpenOrders {
+		if order.PositionID != nil && *order.PositionID == positionID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OrdersWithOutdatedTakeProfit returns the positions whose take profit, still
+// waiting in the book, no longer sells them as they are, since other buys were
+// merged into them. They must be canceled and placed again.
+func (s *Bot) OrdersWithOutdatedTakeProfit(filters *SymbolFilters) []*Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var orders []*Order
+	for _, order := range s.OpenOrders {
+		takeProfitOrder := order.TakeProfitOrder
+		if takeProfitOrder == nil || takeProfitOrder.IsFinal() || takeProfitOrder.IsStopLoss() {
+			continue
+		}
+
+		/** Merging always changes the quantity, as sold by the take profit */
+		quantity := order.Quantity
+		if filters != nil {
+			quantity = filters.RoundQuantity(quantity)
+		}
+
+		if !takeProfitOrder.Quantity.Equal(quantity) {
+			orders = append(orders, order)
+		}
+	}
+
+	return orders
+}
+
+// OrdersWithoutTakeProfit returns the filled buys that still need their take
+// profit sell.
+func (s *Bot) OrdersWithoutTakeProfit() []*Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var orders []*Order
+	for _, order := range s.OpenOrders {
+		if order.IsFilled() && order.TakeProfitOrder == nil {
+			orders = append(orders, order)
+		}
+	}
+
+	return orders
+}
+
+// HighestPendingBuyPrice returns the highest price of the LIMIT buys waiting
+// in the book, the first price at which one of them is filled.
+func (s *Bot) HighestPendingBuyPrice() (decimal.Decimal, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var highest decimal.Decimal
+	found := false
+	for _, order := range s.OpenOrders {
+		if order.Type != OrderTypeLimit || order.IsFinal() {
+			continue
+		}
+
+		if !found || order.EntryPrice.GreaterThan(highest) {
+			highest = order.EntryPrice
+			found = true
+		}
+	}
+
+	return highest, found
+}
+
+// LowestTakeProfitPrice returns the lowest take profit price of the filled
+// positions, the first price at which one of them is sold.
+func (s *Bot) LowestTakeProfitPrice() (decimal.Decimal, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var lowest decimal.Decimal
+	found := false
+	for _, order := range s.OpenOrders {
+		if !order.IsFilled() {
+			continue
+		}
+
+		if !found || order.TakeProfitPrice.LessThan(lowest) {
+			lowest = order.TakeProfitPrice
+			found = true
+		}
+	}
+
+	return lowest, found
+}
+
+// GenerateTakeProfitOrder creates the LIMIT sell of the whole position of a
+// filled buy at its take profit price. The capital stays invested until the
+// sell is executed. With the filters of the pair, the quantity is rounded down,
+// leaving the dust out of the sell, and the price up.
+func (s *Bot) GenerateTakeProfitOrder(entry *Order, filters *SymbolFilters) (*Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	price := entry.TakeProfitPrice
+	if filters != nil {
+		price = filters.RoundPriceUp(price)
+	}
+
+	return s.generateExitOrder(entry, OrderTypeLimit, price, ExitReasonTakeProfit, filters)
+}
+
+// TriggeredStopLosses raises the trailing stops with the given price and
+// returns the filled positions whose stop it reached, unless they are already
+// being sold by one.
+func (s *Bot) TriggeredStopLosses(price decimal.Decimal) []*Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.StopLoss == nil {
+		return nil
+	}
+
+	var orders []*Order
+	for _, order := range s.OpenOrders {
+		if !order.IsFilled() || !order.StopLossPrice.IsPositive() {
+			continue
+		}
+
+		if order.TakeProfitOrder != nil && order.TakeProfitOrder.IsStopLoss() {
+			continue
+		}
+
+		if stopLossPrice := s.StopLoss.Price(price); s.StopLoss.Trailing && stopLossPrice.GreaterThan(order.StopLossPrice) {
+			order.SetStopLossPrice(stopLossPrice)
+		}
+
+		if price.LessThanOrEqual(order.StopLossPrice) {
+			orders = append(orders, order)
+		}
+	}
+
+	return orders
+}
+
+// HighestStopLossPrice returns the highest stop of the filled positions, the
+// first price at which one of them is sold at a loss.
+func (s *Bot) HighestStopLossPrice() (decimal.Decimal, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var highest decimal.Decimal
+	found := false
+	for _, order := range s.OpenOrders {
+		if !order.IsFilled() || !order.StopLossPrice.IsPositive() {
+			continue
+		}
+
+		if !found || order.StopLossPrice.GreaterThan(highest) {
+			highest = order.StopLossPrice
+			found = true
+		}
+	}
+
+	return highest, found
+}
+
+// GenerateStopLossOrder creates the MARKET sell of the whole position of a buy
+// that reached its stop. Its take profit must have been canceled and settled
+// before. The loss is booked once the sell is executed, as for take profits.
+func (s *Bot) GenerateStopLossOrder(entry *Order, filters *SymbolFilters) (*Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.StopLoss == nil {
+		return nil, errors.New(ErrInvalid, "bot has no stop loss", errors.WithMetadata("id", s.ID))
+	}
+
+	return s.generateExitOrder(entry, OrderTypeMarket, entry.StopLossPrice, s.StopLoss.ExitReason(), filters)
+}
+
+// generateExitOrder creates the sell of the position of a filled buy, linked
+// to it as its take profit. It must be called holding the lock.
+func (s *Bot) generateExitOrder(entry *Order, orderType string, price decimal.Decimal, reason string, filters *SymbolFilters) (*Order, error) {
+	if !entry.IsFilled() || entry.IsClosed() {
+		return nil, errors.New(ErrInvalid, "order is not filled", errors.WithMetadata("id", entry.ID))
+	}
+
+	if entry.TakeProfitOrder != nil {
+		return nil, errors.New(ErrConflict, "order already has a take profit", errors.WithMetadata("id", entry.ID))
+	}
+
+	quantity := entry.Quantity
+	if filters != nil {
+		quantity = filters.RoundQuantity(quantity)
+
+		if err := filters.Validate(quantity, price); err != nil {
+			return nil, err
+		}
+	}
+
+	orderId, err := models.GenerateNanoID(14)
+	if err != nil {
+		return nil, errors.Wrap(ErrInternal, err, "could not generate order id")
+	}
+
+	parentID := entry.ID
+	exitOrder, err := NewOrder(
+		orderId,
+		s.ID,
+		&parentID,
+		nil,
+		entry.Symbol,
+		OrderSideSell,
+		orderType,
+		quantity,
+		quantity.Mul(price),
+		quantity.Mul(price),
+		price,
+		price,
+		decimal.Zero,
+		decimal.Zero,
+		decimal.Zero,
+		"",
+		decimal.Zero,
+		decimal.Zero,
+		decimal.Zero,
+		reason,
+		nil,
+		OrderStatusPending,
+		entry.PriceRange,
+		entry.Delta,
+		nil,
+		models.CreateTimestamps(),
+		models.CreateVersion(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.TakeProfitOrder = exitOrder
+
+	return exitOrder, nil
+}
+
+// Symbol is the pair traded by the bot, validated when the bot is created.
+func (s *Bot) Symbol() Symbol {
+	return Symbol{Base: s.TargetCurrency, Quote: s.Currency}
+}
 
-			println()
-			logs.Info(ctx, fmt.Sprintf("%s: Saldo total %s", s.Name, s.TotalCapital.String()))
-			println()
+func (s *Bot) removeOpenOrder(order *Order) {
+	var openOrders []*Order
+	for _, openOrder := range s.OpenOrders {
+		if openOrder != order {
+			openOrders = append(openOrders, openOrder)
 		}
 	}
 
-	s.OpenOrders = filteredOrders
+	s.OpenOrders = openOrders
+	s.ClosedOrders = append(s.ClosedOrders, order)
 }
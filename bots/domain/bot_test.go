@@ -0,0 +1,189 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBot(t *testing.T, strategy string, params StrategyParams, stopLoss *StopLoss) *Bot {
+	bot, err := CreateBot(
+		"test",
+		"USDT",
+		"BTC",
+		decimal.RequireFromString("0.01"),
+		decimal.NewFromInt(1000),
+		decimal.NewFromInt(100),
+		time.Minute,
+		strategy,
+		params,
+		BotModePaper,
+		stopLoss,
+		nil,
+	)
+	assert.NoError(t, err)
+	return bot
+}
+
+// buy generates a MARKET buy in the price range of its price.
+func buy(bot *Bot, price int64, quoteAmount int64, risk *RiskManager) (*Order, error) {
+	return bot.GenerateOrder(decimal.NewFromInt(price), int(price/100), decimal.NewFromInt(quoteAmount), nil, FeeSchedule{}, risk)
+}
+
+// fillOrder executes the whole buy for the given amounts, without fees.
+func fillOrder(t *testing.T, bot *Bot, order *Order, quantity string, quoteAmount string) {
+	order.AddExternalId(order.ID.String())
+	bot.ReconcileOrder(context.Background(), order, &ProviderOrder{
+		ExternalId:          order.ID.String(),
+		Status:              OrderStatusCompleted,
+		ExecutedQuantity:    decimal.RequireFromString(quantity),
+		ExecutedQuoteAmount: decimal.RequireFromString(quoteAmount),
+		FeeCurrency:         bot.TargetCurrency,
+	}, FeeSchedule{}, time.Now())
+	assert.True(t, order.IsFilled())
+}
+
+func TestReconcileEntryOrder(t *testing.T) {
+	fees, err := NewFeeSchedule(decimal.RequireFromString("0.001"), decimal.RequireFromString("0.001"), decimal.RequireFromString("0.25"), true)
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		name        string
+		status      string
+		quantity    string
+		quoteAmount string
+		fee         string
+		feeCurrency string
+		// Expected after the settlement, no position when the order is closed.
+		available   string
+		invested    string
+		position    string
+		feesPaid    string
+		realizedPnL string
+	}{
+		{"fee in quote", OrderStatusCompleted, "0.002", "99.8", "0.1", "USDT", "900.1", "99.9", "0.002", "0.1", "0"},
+		{"fee in base", OrderStatusCompleted, "0.002", "100", "0.000002", "BTC", "900", "100", "0.001998", "0.1", "0"},
+		{"fee in BNB", OrderStatusCompleted, "0.002", "100", "0.0002", "BNB", "900", "100", "0.002", "0.075", "-0.075"},
+		{"partly filled then canceled", OrderStatusCanceled, "0.001", "50", "0.05", "USDT", "949.95", "50.05", "0.001", "0.05", "0"},
+		{"canceled without a fill", OrderStatusCanceled, "0", "0", "0", "", "1000", "0", "", "0", "0"},
+		{"still open", OrderStatusOpen, "0.001", "50", "0.05", "USDT", "900", "100", "0.002", "0", "0"},
+	} {
+		bot := newTestBot(t, StrategyGrid, nil, nil)
+		order, err := buy(bot, 50000, 100, NewRiskManager(RiskLimits{}))
+		assert.NoError(t, err)
+		order.AddExternalId("1")
+
+		bot.ReconcileOrder(context.Background(), order, &ProviderOrder{
+			ExternalId:          "1",
+			Status:              tt.status,
+			ExecutedQuantity:    decimal.RequireFromString(tt.quantity),
+			ExecutedQuoteAmount: decimal.RequireFromString(tt.quoteAmount),
+			Fee:                 decimal.RequireFromString(tt.fee),
+			FeeCurrency:         tt.feeCurrency,
+		}, fees, time.Now())
+
+		assert.Equal(t, tt.available, bot.AvailableCapital.String(), tt.name)
+		assert.Equal(t, tt.invested, bot.InvestedCapital.String(), tt.name)
+		assert.Equal(t, "1000", bot.TotalCapital.String(), tt.name)
+		assert.Equal(t, tt.feesPaid, bot.FeesPaid.String(), tt.name)
+		assert.Equal(t, tt.realizedPnL, bot.RealizedPnL.String(), tt.name)
+
+		if tt.position == "" {
+			assert.Empty(t, bot.OpenOrders, tt.name)
+			assert.Equal(t, []*Order{order}, bot.ClosedOrders, tt.name)
+			assert.NotNil(t, order.ClosedAt, tt.name)
+			continue
+		}
+
+		assert.Equal(t, []*Order{order}, bot.OpenOrders, tt.name)
+		assert.Equal(t, tt.position, order.Quantity.String(), tt.name)
+		assert.Nil(t, order.ClosedAt, tt.name)
+	}
+}
+
+func TestReconcileEntryOrderOnce(t *testing.T) {
+	bot := newTestBot(t, StrategyGrid, nil, nil)
+	order, err := buy(bot, 50000, 100, NewRiskManager(RiskLimits{}))
+	assert.NoError(t, err)
+
+	providerOrder := &ProviderOrder{
+		ExternalId:          "1",
+		Status:              OrderStatusCanceled,
+		ExecutedQuantity:    decimal.RequireFromString("0.001"),
+		ExecutedQuoteAmount: decimal.NewFromInt(50),
+	}
+	order.AddExternalId("1")
+	bot.ReconcileOrder(context.Background(), order, providerOrder, FeeSchedule{}, time.Now())
+	assert.Equal(t, "950", bot.AvailableCapital.String())
+
+	/** A final order is not settled again */
+	bot.ReconcileOrder(context.Background(), order, providerOrder, FeeSchedule{}, time.Now())
+	assert.Equal(t, "950", bot.AvailableCapital.String())
+	assert.Equal(t, "50", bot.InvestedCapital.String())
+}
+
+func TestSettleTakeProfitOrder(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		status      string
+		quantity    string
+		quoteAmount string
+		// Expected after the settlement, no position when it was sold.
+		available   string
+		invested    string
+		position    string
+		realizedPnL string
+		// The take profit is still waiting in the book.
+		selling bool
+	}{
+		{"sold", OrderStatusCompleted, "0.002", "101", "1001", "0", "", "1", false},
+		{"partly sold then canceled", OrderStatusCanceled, "0.001", "50.5", "950.5", "50", "0.001", "0.5", false},
+		{"canceled unsold", OrderStatusCanceled, "0", "0", "900", "100", "0.002", "0", false},
+		{"partly sold", OrderStatusOpen, "0.001", "50.5", "900", "100", "0.002", "0", true},
+	} {
+		bot := newTestBot(t, StrategyGrid, nil, nil)
+		entry, err := buy(bot, 50000, 100, NewRiskManager(RiskLimits{}))
+		assert.NoError(t, err)
+		fillOrder(t, bot, entry, "0.002", "100")
+
+		takeProfit, err := bot.GenerateTakeProfitOrder(entry, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, takeProfit, entry.TakeProfitOrder, tt.name)
+		assert.Equal(t, OrderSideSell, takeProfit.Side, tt.name)
+		assert.Equal(t, "0.002", takeProfit.Quantity.String(), tt.name)
+		assert.Equal(t, "50500", takeProfit.EntryPrice.String(), tt.name)
+		takeProfit.AddExternalId("2")
+
+		bot.ReconcileOrder(context.Background(), takeProfit, &ProviderOrder{
+			ExternalId:          "2",
+			Status:              tt.status,
+			ExecutedQuantity:    decimal.RequireFromString(tt.quantity),
+			ExecutedQuoteAmount: decimal.RequireFromString(tt.quoteAmount),
+			FeeCurrency:         bot.Currency,
+		}, FeeSchedule{}, time.Now())
+
+		assert.Equal(t, tt.available, bot.AvailableCapital.String(), tt.name)
+		assert.Equal(t, tt.invested, bot.InvestedCapital.String(), tt.name)
+		assert.Equal(t, tt.realizedPnL, bot.RealizedPnL.String(), tt.name)
+
+		if tt.position == "" {
+			assert.Empty(t, bot.OpenOrders, tt.name)
+			assert.Equal(t, ExitReasonTakeProfit, entry.ExitReason, tt.name)
+			assert.Equal(t, []*Order{takeProfit, entry}, bot.ClosedOrders, tt.name)
+			continue
+		}
+
+		assert.Equal(t, []*Order{entry}, bot.OpenOrders, tt.name)
+		assert.Equal(t, tt.position, entry.Quantity.String(), tt.name)
+		if tt.selling {
+			assert.Equal(t, takeProfit, entry.TakeProfitOrder, tt.name)
+		} else {
+			/** Free to be placed again for what is left */
+			assert.Nil(t, entry.TakeProfitOrder, tt.name)
+			assert.Equal(t, []*Order{entry}, bot.OrdersWithoutTakeProfit(), tt.name)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"context"
+)
+
+const (
+	StrategyBuyTheDip = "BUY_THE_DIP"
+)
+
+// BuyTheDipStrategy invests all the available capital at once, the first time
+// and then every time the price drops more than Delta since the last sale.
+type BuyTheDipStrategy struct{}
+
+func NewBuyTheDipStrategy() *BuyTheDipStrategy {
+	return &BuyTheDipStrategy{}
+}
+
+func (s *BuyTheDipStrategy) Name() string {
+	return StrategyBuyTheDip
+}
+
+func (s *BuyTheDipStrategy) Evaluate(ctx context.Context, bot *Bot, tick Tick) ([]Decision, error) {
+	first := true
+	if bot.LastSalePrice != nil {
+		first = false
+	}
+
+	priceHasDroppedBelowDelta := false
+	if !first && bot.LastSalePrice.GreaterThan(tick.Price) {
+		priceDifference := bot.LastSalePrice.Sub(tick.Price)
+		priceHasDroppedBelowDelta = priceDifference.GreaterThan(bot.Delta)
+	}
+
+	if bot.HasOpenOrder() || (!first && !priceHasDroppedBelowDelta) {
+		return nil, nil
+	}
+
+	priceRange := bot.CalculatePriceRange(tick.Price)
+
+	return []Decision{NewBuyDecision(priceRange, bot.AvailableCapital)}, nil
+}
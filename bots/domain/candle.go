@@ -0,0 +1,185 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+)
+
+type CandleRepository interface {
+	// FindCandles returns the candles with open time in [start, end), sorted
+	// by open time.
+	FindCandles(ctx context.Context, symbol string, interval string, start time.Time, end time.Time) ([]*Candle, error)
+	// FindGaps returns the ranges of [start, end) without stored candles.
+	FindGaps(ctx context.Context, symbol string, interval string, start time.Time, end time.Time) ([]CandleGap, error)
+	// SaveCandles inserts the candles or replaces the stored ones with the
+	// same symbol, interval and open time.
+	SaveCandles(ctx context.Context, candles []*Candle) error
+}
+
+// CandleProvider fetches historical candles from the exchange.
+type CandleProvider interface {
+	// GetCandles returns the candles with open time in [start, end), sorted by
+	// open time.
+	GetCandles(ctx context.Context, symbol string, interval string, start time.Time, end time.Time) ([]*Candle, error)
+}
+
+// Candle intervals in the Binance notation. Monthly candles are not supported
+// since they do not have a fixed duration.
+var candleIntervals = map[string]time.Duration{
+	"1s":  time.Second,
+	"1m":  time.Minute,
+	"3m":  3 * time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"1h":  time.Hour,
+	"2h":  2 * time.Hour,
+	"4h":  4 * time.Hour,
+	"6h":  6 * time.Hour,
+	"8h":  8 * time.Hour,
+	"12h": 12 * time.Hour,
+	"1d":  24 * time.Hour,
+	"3d":  3 * 24 * time.Hour,
+	"1w":  7 * 24 * time.Hour,
+}
+
+// CandleIntervalDuration returns the duration of a candle interval.
+func CandleIntervalDuration(interval string) (time.Duration, error) {
+	duration, ok := candleIntervals[interval]
+	if !ok {
+		return 0, errors.New(ErrInvalid, "invalid candle interval", errors.WithMetadata("interval", interval))
+	}
+
+	return duration, nil
+}
+
+// CandleGap is a range of open times, [Start, End), without candles.
+type CandleGap struct {
+	Start time.Time
+	End   time.Time
+}
+
+// FindCandleGaps returns the ranges of [start, end) not covered by the given
+// open times, which must be sorted. Both bounds are aligned to the interval.
+func FindCandleGaps(openTimes []time.Time, interval time.Duration, start time.Time, end time.Time) []CandleGap {
+	start = start.Truncate(interval)
+	end = end.Truncate(interval)
+
+	var gaps []CandleGap
+	expected := start
+	for _, openTime := range openTimes {
+		if openTime.Before(expected) {
+			continue
+		}
+		if !openTime.Before(end) {
+			break
+		}
+
+		if openTime.After(expected) {
+			gaps = append(gaps, CandleGap{Start: expected, End: openTime})
+		}
+		expected = openTime.Add(interval)
+	}
+
+	if expected.Before(end) {
+		gaps = append(gaps, CandleGap{Start: expected, End: end})
+	}
+
+	return gaps
+}
+
+// Candle is an OHLCV candlestick of a symbol, e.g. BTCUSDT, for an interval
+// using the Binance notation: 1m, 5m, 1h, 1d...
+type Candle struct {
+	Symbol    string
+	Interval  string
+	OpenTime  time.Time
+	CloseTime time.Time
+	Open      decimal.Decimal
+	High      decimal.Decimal
+	Low       decimal.Decimal
+	Close     decimal.Decimal
+	Volume    decimal.Decimal
+}
+
+func NewCandle(
+	symbol string,
+	interval string,
+	openTime time.Time,
+	closeTime time.Time,
+	open decimal.Decimal,
+	high decimal.Decimal,
+	low decimal.Decimal,
+	close decimal.Decimal,
+	volume decimal.Decimal,
+) (*Candle, error) {
+	if !closeTime.After(openTime) {
+		return nil, errors.New(ErrInvalid, "close time must be after open time", errors.WithMetadata("open_time", openTime))
+	}
+
+	if high.LessThan(low) || high.LessThan(open) || high.LessThan(close) || low.GreaterThan(open) || low.GreaterThan(close) {
+		return nil, errors.New(ErrInvalid, "invalid candle prices", errors.WithMetadata("open_time", openTime))
+	}
+
+	entity := &Candle{
+		Symbol:    symbol,
+		Interval:  interval,
+		OpenTime:  openTime,
+		CloseTime: closeTime,
+		Open:      open,
+		High:      high,
+		Low:       low,
+		Close:     close,
+		Volume:    volume,
+	}
+
+	return entity, nil
+}
+
+// ResampleCandles merges candles, sorted by open time, into candles of a
+// longer interval. The last one may be partial if the candles end before it
+// closes.
+func ResampleCandles(candles []*Candle, interval string) ([]*Candle, error) {
+	duration, err := CandleIntervalDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	var resampled []*Candle
+	var current *Candle
+	for _, candle := range candles {
+		openTime := candle.OpenTime.Truncate(duration)
+		if current == nil || !current.OpenTime.Equal(openTime) {
+			current = &Candle{
+				Symbol:    candle.Symbol,
+				Interval:  interval,
+				OpenTime:  openTime,
+				CloseTime: openTime.Add(duration - time.Millisecond),
+				Open:      candle.Open,
+				High:      candle.High,
+				Low:       candle.Low,
+			}
+			resampled = append(resampled, current)
+		}
+
+		current.High = decimal.Max(current.High, candle.High)
+		current.Low = decimal.Min(current.Low, candle.Low)
+		current.Close = candle.Close
+		current.Volume = current.Volume.Add(candle.Volume)
+	}
+
+	return resampled, nil
+}
+
+// Path returns the prices the candle most likely went through: to the low
+// first if it closed up and to the high first if it closed down.
+func (c *Candle) Path() []decimal.Decimal {
+	if c.Close.GreaterThanOrEqual(c.Open) {
+		return []decimal.Decimal{c.Open, c.Low, c.High, c.Close}
+	}
+
+	return []decimal.Decimal{c.Open, c.High, c.Low, c.Close}
+}
@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	StrategyDCA = "DCA"
+
+	// Number of safety orders after the base order.
+	DCAParamSafetyOrders = "safety_orders"
+	// Drop of the price from the base order, as a fraction of it, that places
+	// the first safety order.
+	DCAParamPriceDeviation = "price_deviation"
+	// Multiplier of the deviation between every safety order and the next.
+	DCAParamStepScale = "step_scale"
+	// Multiplier of the amount of every safety order over the previous one.
+	DCAParamVolumeScale = "volume_scale"
+)
+
+// DCAStrategy opens a position with a base order and, as the price drops,
+// averages it down with safety orders at increasing deviations from the base
+// order and with increasing amounts. Every safety order is merged into the
+// position, sold at once by a single take profit from its average entry. The
+// initial capital is split among the base order and every safety order, so
+// they are all covered. A new position is opened once the last one is sold.
+type DCAStrategy struct{}
+
+func NewDCAStrategy() *DCAStrategy {
+	return &DCAStrategy{}
+}
+
+func (s *DCAStrategy) Name() string {
+	return StrategyDCA
+}
+
+func (s *DCAStrategy) ValidateParams(params StrategyParams) error {
+	_, err := newDCAParams(params)
+
+	return err
+}
+
+func (s *DCAStrategy) Evaluate(ctx context.Context, bot *Bot, tick Tick) ([]Decision, error) {
+	params, err := newDCAParams(bot.StrategyParams)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := bot.Positions()
+	if len(positions) == 0 {
+		return []Decision{NewBuyDecision(0, params.quoteAmount(bot.InitialCapital, 0))}, nil
+	}
+
+	/** Waiting for the base order to be filled, for the last safety order to
+	be merged or for the position to be sold at a loss */
+	position := positions[0]
+	if !position.IsFilled() || bot.HasPendingBuyForPosition(position.ID) ||
+		(position.TakeProfitOrder != nil && position.TakeProfitOrder.IsStopLoss()) {
+		return nil, nil
+	}
+
+	/** The price range of the position is its last safety order */
+	next := position.PriceRange + 1
+	if next > params.safetyOrders {
+		return nil, nil
+	}
+
+	triggerPrice := position.FillPrice().Mul(decimal.NewFromInt(1).Sub(params.deviation(next)))
+	if tick.Price.GreaterThan(triggerPrice) {
+		return nil, nil
+	}
+
+	return []Decision{NewPositionBuyDecision(position.ID, next, params.quoteAmount(bot.InitialCapital, next))}, nil
+}
+
+type dcaParams struct {
+	safetyOrders   int
+	priceDeviation decimal.Decimal
+	stepScale      decimal.Decimal
+	volumeScale    decimal.Decimal
+}
+
+func newDCAParams(params StrategyParams) (*dcaParams, error) {
+	safetyOrders, err := params.Int(DCAParamSafetyOrders, 5)
+	if err != nil {
+		return nil, err
+	}
+
+	priceDeviation, err := params.Decimal(DCAParamPriceDeviation, decimal.NewFromFloat(0.01))
+	if err != nil {
+		return nil, err
+	}
+
+	stepScale, err := params.Decimal(DCAParamStepScale, decimal.NewFromInt(1))
+	if err != nil {
+		return nil, err
+	}
+
+	volumeScale, err := params.Decimal(DCAParamVolumeScale, decimal.NewFromInt(2))
+	if err != nil {
+		return nil, err
+	}
+
+	if safetyOrders < 0 {
+		return nil, errors.New(ErrInvalid, "safety orders can't be negative", errors.WithMetadata(DCAParamSafetyOrders, safetyOrders))
+	}
+
+	if !priceDeviation.IsPositive() || !stepScale.IsPositive() || !volumeScale.IsPositive() {
+		return nil, errors.New(
+			ErrInvalid,
+			"price deviation and scales must be positive",
+			errors.WithMetadata(DCAParamPriceDeviation, priceDeviation.String()),
+			errors.WithMetadata(DCAParamStepScale, stepScale.String()),
+			errors.WithMetadata(DCAParamVolumeScale, volumeScale.String()),
+		)
+	}
+
+	p := &dcaParams{
+		safetyOrders:   safetyOrders,
+		priceDeviation: priceDeviation,
+		stepScale:      stepScale,
+		volumeScale:    volumeScale,
+	}
+
+	if deviation := p.deviation(safetyOrders); deviation.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return nil, errors.New(ErrInvalid, "the last safety order is below zero", errors.WithMetadata("deviation", deviation.String()))
+	}
+
+	return p, nil
+}
+
+// deviation returns the drop from the base order, as a fraction of it, of a
+// safety order, numbered from 1.
+func (p *dcaParams) deviation(safetyOrder int) decimal.Decimal {
+	deviation := decimal.Zero
+	step := p.priceDeviation
+	for i := 0; i < safetyOrder; i++ {
+		deviation = deviation.Add(step)
+		step = step.Mul(p.stepScale)
+	}
+
+	return deviation
+}
+
+// quoteAmount returns the slice of the capital of an order, 0 for the base
+// order. The base order and the first safety order take one share each and
+// every next safety order volume scale times the previous one.
+func (p *dcaParams) quoteAmount(capital decimal.Decimal, order int) decimal.Decimal {
+	shares := decimal.NewFromInt(1)
+	share := decimal.NewFromInt(1)
+	orderShare := share
+	for i := 1; i <= p.safetyOrders; i++ {
+		shares = shares.Add(share)
+		if i == order {
+			orderShare = share
+		}
+		share = share.Mul(p.volumeScale)
+	}
+
+	return capital.Mul(orderShare).Div(shares)
+}
@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDCAStrategyValidateParams(t *testing.T) {
+	strategy := NewDCAStrategy()
+
+	assert.NoError(t, strategy.ValidateParams(StrategyParams{}))
+
+	for name, params := range map[string]StrategyParams{
+		"negative safety orders": {DCAParamSafetyOrders: "-1"},
+		"no deviation":           {DCAParamPriceDeviation: "0"},
+		"no step scale":          {DCAParamStepScale: "0"},
+		"no volume scale":        {DCAParamVolumeScale: "-2"},
+		"below zero":             {DCAParamSafetyOrders: "3", DCAParamPriceDeviation: "0.2", DCAParamStepScale: "2"},
+	} {
+		err := strategy.ValidateParams(params)
+		assert.True(t, errors.Is(err, ErrInvalid), name)
+	}
+}
+
+func TestDCAParams(t *testing.T) {
+	params, err := newDCAParams(StrategyParams{
+		DCAParamSafetyOrders:   "3",
+		DCAParamPriceDeviation: "0.1",
+		DCAParamStepScale:      "2",
+		DCAParamVolumeScale:    "2",
+	})
+	assert.NoError(t, err)
+
+	/** Steps of 10%, 20% and 40% */
+	for safetyOrder, deviation := range []string{"0", "0.1", "0.3", "0.7"} {
+		assert.Equal(t, deviation, params.deviation(safetyOrder).String())
+	}
+
+	/** Shares of 1, 1, 2 and 4 */
+	capital := decimal.NewFromInt(800)
+	for order, quoteAmount := range []string{"100", "100", "200", "400"} {
+		assert.Equal(t, quoteAmount, params.quoteAmount(capital, order).String())
+	}
+}
+
+func TestDCAStrategyEvaluate(t *testing.T) {
+	ctx := context.Background()
+	strategy := NewDCAStrategy()
+	risk := NewRiskManager(RiskLimits{})
+	bot := newTestBot(t, StrategyDCA, StrategyParams{
+		DCAParamSafetyOrders:   "2",
+		DCAParamPriceDeviation: "0.1",
+		DCAParamStepScale:      "2",
+		DCAParamVolumeScale:    "2",
+	}, nil)
+	evaluate := func(price int64) []Decision {
+		decisions, err := strategy.Evaluate(ctx, bot, NewTick(decimal.NewFromInt(price), time.Now()))
+		assert.NoError(t, err)
+		return decisions
+	}
+	safetyOrder := func(decision Decision, price int64) *Order {
+		order, err := bot.GenerateOrder(decimal.NewFromInt(price), decision.PriceRange, decision.QuoteAmount, nil, FeeSchedule{}, risk)
+		assert.NoError(t, err)
+		order.JoinPosition(*decision.PositionID)
+		return order
+	}
+
+	/** Base order */
+	decisions := evaluate(50000)
+	assert.Len(t, decisions, 1)
+	assert.Equal(t, 0, decisions[0].PriceRange)
+	assert.Equal(t, "250", decisions[0].QuoteAmount.String())
+	assert.Nil(t, decisions[0].PositionID)
+
+	position, err := bot.GenerateOrder(decimal.NewFromInt(50000), 0, decisions[0].QuoteAmount, nil, FeeSchedule{}, risk)
+	assert.NoError(t, err)
+	/** Nothing until it is filled */
+	assert.Empty(t, evaluate(40000))
+	fillOrder(t, bot, position, "0.005", "250")
+	assert.Equal(t, "50500", position.TakeProfitPrice.String())
+
+	/** First safety order at a 10% drop */
+	assert.Empty(t, evaluate(45001))
+	decisions = evaluate(45000)
+	assert.Len(t, decisions, 1)
+	assert.Equal(t, 1, decisions[0].PriceRange)
+	assert.Equal(t, "250", decisions[0].QuoteAmount.String())
+	assert.Equal(t, position.ID, *decisions[0].PositionID)
+
+	first := safetyOrder(decisions[0], 45000)
+	/** Nothing until it is merged */
+	assert.Empty(t, evaluate(30000))
+	fillOrder(t, bot, first, "0.005", "225")
+
+	/** Merged into one position, sold by one take profit from its average */
+	assert.Len(t, bot.OpenOrders, 1)
+	assert.Equal(t, ExitReasonMerged, first.ExitReason)
+	assert.Equal(t, "0.01", position.Quantity.String())
+	assert.Equal(t, "475", position.InitialQuoteAmount.String())
+	assert.Equal(t, "47500", position.EntryPrice.String())
+	assert.Equal(t, "47975", position.TakeProfitPrice.String())
+	assert.Equal(t, 1, position.PriceRange)
+	assert.Equal(t, "525", bot.AvailableCapital.String())
+
+	/** Second safety order at a 30% drop from the base order, twice the amount */
+	assert.Empty(t, evaluate(35001))
+	decisions = evaluate(35000)
+	assert.Len(t, decisions, 1)
+	assert.Equal(t, 2, decisions[0].PriceRange)
+	assert.Equal(t, "500", decisions[0].QuoteAmount.String())
+
+	second := safetyOrder(decisions[0], 35000)
+	fillOrder(t, bot, second, "0.01", "350")
+	assert.Len(t, bot.OpenOrders, 1)
+	assert.Equal(t, "0.02", position.Quantity.String())
+	assert.Equal(t, "825", position.InitialQuoteAmount.String())
+	assert.Equal(t, "41250", position.EntryPrice.String())
+	assert.Equal(t, "41662.5", position.TakeProfitPrice.String())
+
+	/** No more safety orders */
+	assert.Empty(t, evaluate(10000))
+}
+
+func TestDCAMergeIntoClosedPosition(t *testing.T) {
+	bot := newTestBot(t, StrategyDCA, nil, nil)
+
+	order, err := buy(bot, 45000, 100, NewRiskManager(RiskLimits{}))
+	assert.NoError(t, err)
+	order.JoinPosition(models.ID("gone"))
+	fillOrder(t, bot, order, "0.002", "90")
+
+	/** The position was sold meanwhile, the buy keeps its own */
+	assert.Len(t, bot.OpenOrders, 1)
+	assert.Equal(t, order.ID, bot.OpenOrders[0].ID)
+	assert.Empty(t, order.ExitReason)
+}
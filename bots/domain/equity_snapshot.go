@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+	"github.com/shopspring/decimal"
+)
+
+type EquitySnapshotRepository interface {
+	// FindByBotID returns the snapshots of a bot with the given resolution
+	// taken in [start, end), sorted by time.
+	FindByBotID(ctx context.Context, botID models.ID, resolution string, start time.Time, end time.Time) ([]*EquitySnapshot, error)
+	Save(ctx context.Context, snapshot *EquitySnapshot) error
+	// Downsample replaces the snapshots with resolution from taken before the
+	// given time with one per bucket of resolution to, as merged by
+	// DownsampleEquitySnapshots, and returns how many were replaced.
+	Downsample(ctx context.Context, from string, to string, before time.Time) (int, error)
+}
+
+// Resolutions of the snapshots. The recorded ones have minute resolution and
+// they are downsampled to hour and then day resolution as they get older.
+const (
+	EquityResolutionMinute = "1m"
+	EquityResolutionHour   = "1h"
+	EquityResolutionDay    = "1d"
+)
+
+var equityResolutions = map[string]time.Duration{
+	EquityResolutionMinute: time.Minute,
+	EquityResolutionHour:   time.Hour,
+	EquityResolutionDay:    24 * time.Hour,
+}
+
+func EquityResolutionDuration(resolution string) (time.Duration, error) {
+	duration, ok := equityResolutions[resolution]
+	if !ok {
+		return 0, errors.New(ErrInvalid, "invalid resolution", errors.WithMetadata("resolution", resolution))
+	}
+
+	return duration, nil
+}
+
+// EquitySnapshot is the state of the capital of a bot at a time. Downsampled
+// snapshots keep the last state of their bucket, at the start of it, with the
+// lowest and highest mark to market seen in it.
+type EquitySnapshot struct {
+	BotID            models.ID
+	Time             time.Time
+	Resolution       string
+	AvailableCapital decimal.Decimal
+	InvestedCapital  decimal.Decimal
+	TotalCapital     decimal.Decimal
+	MarkToMarket     decimal.Decimal
+	LowMarkToMarket  decimal.Decimal
+	HighMarkToMarket decimal.Decimal
+	OpenOrders       int
+}
+
+func NewEquitySnapshot(
+	botID models.ID,
+	at time.Time,
+	resolution string,
+	availableCapital decimal.Decimal,
+	investedCapital decimal.Decimal,
+	totalCapital decimal.Decimal,
+	markToMarket decimal.Decimal,
+	lowMarkToMarket decimal.Decimal,
+	highMarkToMarket decimal.Decimal,
+	openOrders int,
+) (*EquitySnapshot, error) {
+	if _, err := EquityResolutionDuration(resolution); err != nil {
+		return nil, err
+	}
+
+	return &EquitySnapshot{
+		BotID:            botID,
+		Time:             at,
+		Resolution:       resolution,
+		AvailableCapital: availableCapital,
+		InvestedCapital:  investedCapital,
+		TotalCapital:     totalCapital,
+		MarkToMarket:     markToMarket,
+		LowMarkToMarket:  lowMarkToMarket,
+		HighMarkToMarket: highMarkToMarket,
+		OpenOrders:       openOrders,
+	}, nil
+}
+
+// DownsampleEquitySnapshots merges the snapshots, sorted by time, into one per
+// bot and bucket of the given resolution.
+func DownsampleEquitySnapshots(snapshots []*EquitySnapshot, resolution string) ([]*EquitySnapshot, error) {
+	duration, err := EquityResolutionDuration(resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	type bucket struct {
+		botID models.ID
+		start time.Time
+	}
+
+	var downsampled []*EquitySnapshot
+	merged := make(map[bucket]*EquitySnapshot)
+	for _, snapshot := range snapshots {
+		key := bucket{botID: snapshot.BotID, start: snapshot.Time.Truncate(duration)}
+
+		current, ok := merged[key]
+		if !ok {
+			current = &EquitySnapshot{
+				BotID:            snapshot.BotID,
+				Time:             key.start,
+				Resolution:       resolution,
+				LowMarkToMarket:  snapshot.LowMarkToMarket,
+				HighMarkToMarket: snapshot.HighMarkToMarket,
+			}
+			merged[key] = current
+			downsampled = append(downsampled, current)
+		}
+
+		current.AvailableCapital = snapshot.AvailableCapital
+		current.InvestedCapital = snapshot.InvestedCapital
+		current.TotalCapital = snapshot.TotalCapital
+		current.MarkToMarket = snapshot.MarkToMarket
+		current.OpenOrders = snapshot.OpenOrders
+		current.LowMarkToMarket = decimal.Min(current.LowMarkToMarket, snapshot.LowMarkToMarket)
+		current.HighMarkToMarket = decimal.Max(current.HighMarkToMarket, snapshot.HighMarkToMarket)
+	}
+
+	return downsampled, nil
+}
+
+// EquityMaxDrawdown returns the largest drop of the mark to market from a
+// previous peak, as a fraction of it, of snapshots sorted by time.
+func EquityMaxDrawdown(snapshots []*EquitySnapshot) decimal.Decimal {
+	maxDrawdown := decimal.Zero
+	peak := decimal.Zero
+	for _, snapshot := range snapshots {
+		/** The low of a bucket may come before its high, so it is compared
+		with the previous peak */
+		if peak.IsPositive() {
+			if drawdown := peak.Sub(snapshot.LowMarkToMarket).Div(peak); drawdown.GreaterThan(maxDrawdown) {
+				maxDrawdown = drawdown
+			}
+		}
+
+		if snapshot.HighMarkToMarket.GreaterThan(peak) {
+			peak = snapshot.HighMarkToMarket
+		}
+	}
+
+	return maxDrawdown
+}
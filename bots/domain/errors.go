@@ -8,4 +8,5 @@ var (
 	ErrInvalid  = errors.Define("INVALID")
 	ErrNotFound = errors.Define("NOT_FOUND")
 	ErrInternal = errors.Define("INTERNAL")
+	ErrConflict = errors.Define("CONFLICT")
 )
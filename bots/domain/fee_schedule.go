@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+)
+
+// FeeSchedule is the fee rate the exchange charges on every fill. The zero
+// value charges no fees.
+type FeeSchedule struct {
+	// Rate for LIMIT orders resting in the book, like the take profits.
+	MakerFee decimal.Decimal
+	// Rate for MARKET orders, like the buys.
+	TakerFee decimal.Decimal
+	// Discount on the rates when the fees are paid in BNB, 0.25 on Binance.
+	BNBDiscount decimal.Decimal
+	PayWithBNB  bool
+}
+
+func NewFeeSchedule(
+	makerFee decimal.Decimal,
+	takerFee decimal.Decimal,
+	bnbDiscount decimal.Decimal,
+	payWithBNB bool,
+) (FeeSchedule, error) {
+	one := decimal.NewFromInt(1)
+
+	for _, fee := range []decimal.Decimal{makerFee, takerFee} {
+		if fee.IsNegative() || fee.GreaterThanOrEqual(one) {
+			return FeeSchedule{}, errors.New(ErrInvalid, "fee rates must be between 0 and 1", errors.WithMetadata("fee", fee.String()))
+		}
+	}
+
+	if bnbDiscount.IsNegative() || bnbDiscount.GreaterThanOrEqual(one) {
+		return FeeSchedule{}, errors.New(ErrInvalid, "BNB discount must be between 0 and 1", errors.WithMetadata("bnb_discount", bnbDiscount.String()))
+	}
+
+	return FeeSchedule{
+		MakerFee:    makerFee,
+		TakerFee:    takerFee,
+		BNBDiscount: bnbDiscount,
+		PayWithBNB:  payWithBNB,
+	}, nil
+}
+
+// Rate returns the rate charged on an order of the given type, with the BNB
+// discount when the fees are paid in BNB.
+func (f FeeSchedule) Rate(orderType string) decimal.Decimal {
+	rate := f.baseRate(orderType)
+	if f.PayWithBNB {
+		rate = rate.Mul(decimal.NewFromInt(1).Sub(f.BNBDiscount))
+	}
+
+	return rate
+}
+
+func (f FeeSchedule) baseRate(orderType string) decimal.Decimal {
+	if orderType == OrderTypeLimit {
+		return f.MakerFee
+	}
+
+	return f.TakerFee
+}
+
+// TakeProfitPrice returns the price at which the position bought with a
+// MARKET order at entryPrice is sold with a LIMIT order making the given
+// profit after paying the fees of both orders:
+//
+//	entry * (1 + profit) * (1 + buy fee) / (1 - sell fee)
+func (f FeeSchedule) TakeProfitPrice(entryPrice decimal.Decimal, takeProfitPercentaje decimal.Decimal) decimal.Decimal {
+	one := decimal.NewFromInt(1)
+
+	return entryPrice.
+		Mul(one.Add(takeProfitPercentaje)).
+		Mul(one.Add(f.Rate(OrderTypeMarket))).
+		Div(one.Sub(f.Rate(OrderTypeLimit)))
+}
+
+// takeProfitPriceFromCost is TakeProfitPrice for a position whose cost per
+// unit already includes the fees of the buy.
+func (f FeeSchedule) takeProfitPriceFromCost(unitCost decimal.Decimal, takeProfitPercentaje decimal.Decimal) decimal.Decimal {
+	one := decimal.NewFromInt(1)
+
+	return unitCost.
+		Mul(one.Add(takeProfitPercentaje)).
+		Div(one.Sub(f.Rate(OrderTypeLimit)))
+}
+
+// QuoteValue returns the fee of an executed order in the quote currency of the
+// pair. Fees taken in BNB are valued at the rate charged for them, since the
+// fill does not report the BNB price.
+func (f FeeSchedule) QuoteValue(order *Order, baseCurrency string, quoteCurrency string) decimal.Decimal {
+	if !order.Fee.IsPositive() {
+		return decimal.Zero
+	}
+
+	switch order.FeeCurrency {
+	case quoteCurrency:
+		return order.Fee
+	case baseCurrency:
+		if !order.ExecutedQuantity.IsPositive() {
+			return decimal.Zero
+		}
+
+		return order.Fee.Mul(order.ExecutedQuoteAmount).Div(order.ExecutedQuantity)
+	default:
+		return order.ExecutedQuoteAmount.
+			Mul(f.baseRate(order.Type)).
+			Mul(decimal.NewFromInt(1).Sub(f.BNBDiscount))
+	}
+}
@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFeeSchedule(t *testing.T, payWithBNB bool) FeeSchedule {
+	fees, err := NewFeeSchedule(
+		decimal.RequireFromString("0.001"),
+		decimal.RequireFromString("0.002"),
+		decimal.RequireFromString("0.25"),
+		payWithBNB,
+	)
+	assert.NoError(t, err)
+	return fees
+}
+
+func TestNewFeeSchedule(t *testing.T) {
+	for _, rates := range [][3]string{{"-0.001", "0.001", "0"}, {"0.001", "1", "0"}, {"0.001", "0.001", "1"}} {
+		_, err := NewFeeSchedule(decimal.RequireFromString(rates[0]), decimal.RequireFromString(rates[1]), decimal.RequireFromString(rates[2]), true)
+		assert.True(t, errors.Is(err, ErrInvalid), rates)
+	}
+
+	fees := newTestFeeSchedule(t, true)
+	assert.Equal(t, "0.00075", fees.Rate(OrderTypeLimit).String())
+	assert.Equal(t, "0.0015", fees.Rate(OrderTypeMarket).String())
+}
+
+func TestTakeProfitPriceNetProfit(t *testing.T) {
+	entryPrice := decimal.NewFromInt(50000)
+	profit := decimal.RequireFromString("0.01")
+	one := decimal.NewFromInt(1)
+
+	for name, fees := range map[string]FeeSchedule{
+		"no fees":       {},
+		"in the assets": newTestFeeSchedule(t, false),
+		"in BNB":        newTestFeeSchedule(t, true),
+	} {
+		/** Taker fee on the buy, maker fee on the sell */
+		cost := entryPrice.Mul(one.Add(fees.Rate(OrderTypeMarket)))
+		takeProfit := fees.TakeProfitPrice(entryPrice, profit)
+		proceeds := takeProfit.Mul(one.Sub(fees.Rate(OrderTypeLimit)))
+
+		net := proceeds.Sub(cost).Div(cost)
+		assert.True(t, net.GreaterThanOrEqual(profit), "%s: %s", name, net.String())
+		assert.True(t, net.Sub(profit).LessThan(decimal.New(1, -12)), "%s: %s", name, net.String())
+
+		/** The same from the cost per unit with the buy fee in it */
+		assert.True(t, fees.takeProfitPriceFromCost(cost, profit).Sub(takeProfit).Abs().LessThan(decimal.New(1, -8)), name)
+	}
+}
+
+func TestFeeScheduleQuoteValue(t *testing.T) {
+	fees := newTestFeeSchedule(t, true)
+
+	for _, tt := range []struct {
+		name        string
+		orderType   string
+		fee         string
+		feeCurrency string
+		quantity    string
+		quoteValue  string
+	}{
+		{"quote", OrderTypeMarket, "0.2", "USDT", "0.002", "0.2"},
+		{"base at the fill price", OrderTypeMarket, "0.000002", "BTC", "0.002", "0.1"},
+		{"BNB at the taker rate", OrderTypeMarket, "0.0003", "BNB", "0.002", "0.15"},
+		{"BNB at the maker rate", OrderTypeLimit, "0.0003", "BNB", "0.002", "0.075"},
+		{"no fee", OrderTypeMarket, "0", "", "0.002", "0"},
+		{"base without execution", OrderTypeMarket, "0.000002", "BTC", "0", "0"},
+	} {
+		order := &Order{
+			Type:                tt.orderType,
+			Fee:                 decimal.RequireFromString(tt.fee),
+			FeeCurrency:         tt.feeCurrency,
+			ExecutedQuantity:    decimal.RequireFromString(tt.quantity),
+			ExecutedQuoteAmount: decimal.NewFromInt(100),
+		}
+		assert.Equal(t, tt.quoteValue, fees.QuoteValue(order, "BTC", "USDT").String(), tt.name)
+	}
+}
+
+func TestTakeProfitNetProfitAfterSettlement(t *testing.T) {
+	ctx := context.Background()
+	quantity := decimal.RequireFromString("0.002")
+	makerFee := decimal.RequireFromString("0.001")
+
+	for _, tt := range []struct {
+		name       string
+		payWithBNB bool
+		buyFee     string
+		buyAsset   string
+		// Fee of the sell, a rate of its proceeds when it is not in BNB.
+		sellFee   string
+		sellAsset string
+	}{
+		{"quote", false, "0.2", "USDT", "", "USDT"},
+		{"base", false, "0.000004", "BTC", "", "USDT"},
+		{"BNB", true, "0.0005", "BNB", "0.0003", "BNB"},
+	} {
+		fees := newTestFeeSchedule(t, tt.payWithBNB)
+		bot := newTestBot(t, StrategyGrid, nil, nil)
+
+		entry, err := bot.GenerateOrder(decimal.NewFromInt(50000), 500, decimal.NewFromInt(100), nil, fees, NewRiskManager(RiskLimits{}))
+		assert.NoError(t, err)
+		entry.AddExternalId("1")
+		bot.ReconcileOrder(ctx, entry, &ProviderOrder{
+			ExternalId:          "1",
+			Status:              OrderStatusCompleted,
+			ExecutedQuantity:    quantity,
+			ExecutedQuoteAmount: decimal.NewFromInt(100),
+			Fee:                 decimal.RequireFromString(tt.buyFee),
+			FeeCurrency:         tt.buyAsset,
+		}, fees, time.Now())
+		/** A fee in the base asset lowers the quantity instead, one in BNB is
+		paid apart from the capital */
+		cost := entry.InitialQuoteAmount
+		if tt.buyAsset == "BNB" {
+			cost = cost.Add(entry.FeeQuoteAmount)
+		}
+
+		takeProfit, err := bot.GenerateTakeProfitOrder(entry, nil)
+		assert.NoError(t, err)
+		takeProfit.AddExternalId("2")
+		proceeds := takeProfit.Quantity.Mul(takeProfit.EntryPrice)
+		sellFee := proceeds.Mul(makerFee)
+		if tt.sellFee != "" {
+			sellFee = decimal.RequireFromString(tt.sellFee)
+		}
+		bot.ReconcileOrder(ctx, takeProfit, &ProviderOrder{
+			ExternalId:          "2",
+			Status:              OrderStatusCompleted,
+			ExecutedQuantity:    takeProfit.Quantity,
+			ExecutedQuoteAmount: proceeds,
+			Fee:                 sellFee,
+			FeeCurrency:         tt.sellAsset,
+		}, fees, time.Now())
+
+		/** Both fees paid, the position nets the take profit on its cost */
+		assert.Empty(t, bot.OpenOrders, tt.name)
+		net := bot.RealizedPnL.Div(cost)
+		assert.True(t, net.GreaterThanOrEqual(bot.TakeProfitPercentaje), "%s: %s", tt.name, net.String())
+		assert.True(t, net.Sub(bot.TakeProfitPercentaje).LessThan(decimal.New(1, -8)), "%s: %s", tt.name, net.String())
+	}
+}
@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"context"
+	"sort"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	StrategyGeometricGrid = "GEOMETRIC_GRID"
+
+	// Bounds of the grid. Nothing is bought outside them.
+	GeometricGridParamLowerPrice = "lower_price"
+	GeometricGridParamUpperPrice = "upper_price"
+	// Number of levels between the bounds, each one with an equal slice of the
+	// initial capital.
+	GeometricGridParamLevels = "levels"
+	// Place a LIMIT buy on every level below the price instead of buying at
+	// market when the price enters a level.
+	GeometricGridParamLadder = "ladder"
+)
+
+// GeometricGrid splits a price range into levels a fixed percentage apart, so
+// the spacing is the same relative to the price at both ends of the range.
+type GeometricGrid struct {
+	LowerPrice decimal.Decimal
+	UpperPrice decimal.Decimal
+	Levels     int
+
+	// Price at which every level starts, from the lower bound to the upper one.
+	prices []decimal.Decimal
+}
+
+func NewGeometricGrid(lowerPrice decimal.Decimal, upperPrice decimal.Decimal, levels int) (*GeometricGrid, error) {
+	if !lowerPrice.IsPositive() || !upperPrice.GreaterThan(lowerPrice) {
+		return nil, errors.New(
+			ErrInvalid,
+			"invalid grid bounds",
+			errors.WithMetadata("lower_price", lowerPrice.String()),
+			errors.WithMetadata("upper_price", upperPrice.String()),
+		)
+	}
+
+	if levels < 1 {
+		return nil, errors.New(ErrInvalid, "grid levels must be positive", errors.WithMetadata("levels", levels))
+	}
+
+	ratio, err := upperPrice.Div(lowerPrice).PowWithPrecision(decimal.NewFromInt(1).Div(decimal.NewFromInt(int64(levels))), 16)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalid, err, "could not compute the grid ratio")
+	}
+
+	prices := make([]decimal.Decimal, levels+1)
+	prices[0] = lowerPrice
+	for level := 1; level < levels; level++ {
+		prices[level] = prices[level-1].Mul(ratio).Round(8)
+	}
+	/** Without the rounding errors of the ratio */
+	prices[levels] = upperPrice
+
+	return &GeometricGrid{
+		LowerPrice: lowerPrice,
+		UpperPrice: upperPrice,
+		Levels:     levels,
+		prices:     prices,
+	}, nil
+}
+
+// Price returns the price at which the level starts, its buy price.
+func (g *GeometricGrid) Price(level int) decimal.Decimal {
+	return g.prices[level]
+}
+
+// Level returns the level of the price, false if it is outside the bounds.
+func (g *GeometricGrid) Level(price decimal.Decimal) (int, bool) {
+	/** First price above the given one, the level after it */
+	next := sort.Search(len(g.prices), func(i int) bool {
+		return g.prices[i].GreaterThan(price)
+	})
+
+	if next == 0 || next == len(g.prices) {
+		return 0, false
+	}
+
+	return next - 1, true
+}
+
+// GeometricGridStrategy buys a slice of the initial capital on every level of
+// a geometric grid, either at market when the price enters a level without an
+// open order or, with the ladder, with LIMIT buys placed in advance on every
+// level below the price. The Delta of the bot is not used.
+type GeometricGridStrategy struct{}
+
+func NewGeometricGridStrategy() *GeometricGridStrategy {
+	return &GeometricGridStrategy{}
+}
+
+func (s *GeometricGridStrategy) Name() string {
+	return StrategyGeometricGrid
+}
+
+func (s *GeometricGridStrategy) ValidateParams(params StrategyParams) error {
+	_, _, err := s.params(params)
+
+	return err
+}
+
+func (s *GeometricGridStrategy) Evaluate(ctx context.Context, bot *Bot, tick Tick) ([]Decision, error) {
+	grid, ladder, err := s.params(bot.StrategyParams)
+	if err != nil {
+		return nil, err
+	}
+
+	quoteAmount := bot.InitialCapital.Div(decimal.NewFromInt(int64(grid.Levels)))
+
+	if !ladder {
+		level, ok := grid.Level(tick.Price)
+		if !ok || bot.HasOpenOrderWithPriceRange(level) {
+			return nil, nil
+		}
+
+		return []Decision{NewBuyDecision(level, quoteAmount)}, nil
+	}
+
+	/** From the closest level down, so they are placed first if the capital
+	does not cover them all */
+	var decisions []Decision
+	for level := grid.Levels - 1; level >= 0; level-- {
+		price := grid.Price(level)
+		if price.GreaterThanOrEqual(tick.Price) || bot.HasOpenOrderWithPriceRange(level) {
+			continue
+		}
+
+		decisions = append(decisions, NewLimitBuyDecision(level, price, quoteAmount))
+	}
+
+	return decisions, nil
+}
+
+func (s *GeometricGridStrategy) params(params StrategyParams) (*GeometricGrid, bool, error) {
+	lowerPrice, err := params.Decimal(GeometricGridParamLowerPrice, decimal.Zero)
+	if err != nil {
+		return nil, false, err
+	}
+
+	upperPrice, err := params.Decimal(GeometricGridParamUpperPrice, decimal.Zero)
+	if err != nil {
+		return nil, false, err
+	}
+
+	levels, err := params.Int(GeometricGridParamLevels, 10)
+	if err != nil {
+		return nil, false, err
+	}
+
+	ladder, err := params.Bool(GeometricGridParamLadder, false)
+	if err != nil {
+		return nil, false, err
+	}
+
+	grid, err := NewGeometricGrid(lowerPrice, upperPrice, levels)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return grid, ladder, nil
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeometricGridLevel(t *testing.T) {
+	grid, err := NewGeometricGrid(decimal.NewFromInt(100), decimal.NewFromInt(1600), 4)
+	assert.NoError(t, err)
+
+	for level, price := range []string{"100", "200", "400", "800"} {
+		assert.Equal(t, price, grid.Price(level).String())
+	}
+
+	for price, expected := range map[string]int{
+		"100":    0,
+		"199.99": 0,
+		"200":    1,
+		"500":    2,
+		"1599":   3,
+	} {
+		level, ok := grid.Level(decimal.RequireFromString(price))
+		assert.True(t, ok, price)
+		assert.Equal(t, expected, level, price)
+	}
+
+	/** Outside the bounds, the upper one included */
+	for _, price := range []string{"99.99", "1600", "2000"} {
+		_, ok := grid.Level(decimal.RequireFromString(price))
+		assert.False(t, ok, price)
+	}
+}
+
+func TestGeometricGridStrategyValidateParams(t *testing.T) {
+	strategy := NewGeometricGridStrategy()
+
+	assert.NoError(t, strategy.ValidateParams(StrategyParams{
+		GeometricGridParamLowerPrice: "40000",
+		GeometricGridParamUpperPrice: "60000",
+	}))
+
+	for name, params := range map[string]StrategyParams{
+		"no bounds":      {},
+		"inverted":       {GeometricGridParamLowerPrice: "60000", GeometricGridParamUpperPrice: "40000"},
+		"equal":          {GeometricGridParamLowerPrice: "40000", GeometricGridParamUpperPrice: "40000"},
+		"negative lower": {GeometricGridParamLowerPrice: "-1", GeometricGridParamUpperPrice: "40000"},
+		"no levels":      {GeometricGridParamLowerPrice: "40000", GeometricGridParamUpperPrice: "60000", GeometricGridParamLevels: "0"},
+		"invalid levels": {GeometricGridParamLowerPrice: "40000", GeometricGridParamUpperPrice: "60000", GeometricGridParamLevels: "ten"},
+		"invalid ladder": {GeometricGridParamLowerPrice: "40000", GeometricGridParamUpperPrice: "60000", GeometricGridParamLadder: "maybe"},
+	} {
+		err := strategy.ValidateParams(params)
+		assert.True(t, errors.Is(err, ErrInvalid), name)
+	}
+}
+
+func TestGeometricGridStrategyEvaluate(t *testing.T) {
+	strategy := NewGeometricGridStrategy()
+	bot := newTestBot(t, StrategyGeometricGrid, StrategyParams{
+		GeometricGridParamLowerPrice: "100",
+		GeometricGridParamUpperPrice: "1600",
+		GeometricGridParamLevels:     "4",
+	}, nil)
+
+	decisions, err := strategy.Evaluate(context.Background(), bot, NewTick(decimal.NewFromInt(500), time.Now()))
+	assert.NoError(t, err)
+	assert.Len(t, decisions, 1)
+	assert.Equal(t, OrderTypeMarket, decisions[0].OrderType)
+	assert.Equal(t, 2, decisions[0].PriceRange)
+	assert.Equal(t, "250", decisions[0].QuoteAmount.String())
+
+	_, err = bot.GenerateOrder(decimal.NewFromInt(500), decisions[0].PriceRange, decisions[0].QuoteAmount, nil, FeeSchedule{}, NewRiskManager(RiskLimits{}))
+	assert.NoError(t, err)
+
+	/** Not again on the same level */
+	decisions, err = strategy.Evaluate(context.Background(), bot, NewTick(decimal.NewFromInt(700), time.Now()))
+	assert.NoError(t, err)
+	assert.Empty(t, decisions)
+
+	/** Nor outside the grid */
+	decisions, err = strategy.Evaluate(context.Background(), bot, NewTick(decimal.NewFromInt(2000), time.Now()))
+	assert.NoError(t, err)
+	assert.Empty(t, decisions)
+}
+
+func TestGeometricGridStrategyEvaluateLadder(t *testing.T) {
+	strategy := NewGeometricGridStrategy()
+	bot := newTestBot(t, StrategyGeometricGrid, StrategyParams{
+		GeometricGridParamLowerPrice: "100",
+		GeometricGridParamUpperPrice: "1600",
+		GeometricGridParamLevels:     "4",
+		GeometricGridParamLadder:     "true",
+	}, nil)
+
+	/** A LIMIT buy on every level below the price, the closest first */
+	decisions, err := strategy.Evaluate(context.Background(), bot, NewTick(decimal.NewFromInt(400), time.Now()))
+	assert.NoError(t, err)
+	assert.Len(t, decisions, 2)
+	for i, price := range []string{"200", "100"} {
+		assert.Equal(t, OrderTypeLimit, decisions[i].OrderType)
+		assert.Equal(t, 1-i, decisions[i].PriceRange)
+		assert.Equal(t, price, decisions[i].Price.String())
+		assert.Equal(t, "250", decisions[i].QuoteAmount.String())
+	}
+
+	_, err = bot.GenerateLimitOrder(decisions[0].Price, decimal.NewFromInt(400), decisions[0].PriceRange, decisions[0].QuoteAmount, nil, FeeSchedule{}, NewRiskManager(RiskLimits{}))
+	assert.NoError(t, err)
+
+	/** The levels with an order are skipped */
+	decisions, err = strategy.Evaluate(context.Background(), bot, NewTick(decimal.NewFromInt(900), time.Now()))
+	assert.NoError(t, err)
+	assert.Len(t, decisions, 3)
+	for i, level := range []int{3, 2, 0} {
+		assert.Equal(t, level, decisions[i].PriceRange)
+	}
+}
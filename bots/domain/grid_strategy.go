@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	StrategyGrid = "GRID"
+
+	// Number of equal slices the initial capital is split into, one per order.
+	GridParamOrders = "orders"
+)
+
+// GridStrategy buys a fixed slice of the initial capital every time the price
+// enters a price range without an open order.
+type GridStrategy struct{}
+
+func NewGridStrategy() *GridStrategy {
+	return &GridStrategy{}
+}
+
+func (s *GridStrategy) Name() string {
+	return StrategyGrid
+}
+
+func (s *GridStrategy) ValidateParams(params StrategyParams) error {
+	_, err := s.orders(params)
+
+	return err
+}
+
+func (s *GridStrategy) Evaluate(ctx context.Context, bot *Bot, tick Tick) ([]Decision, error) {
+	if bot.HasOpenOrderAtPrice(tick.Price) {
+		return nil, nil
+	}
+
+	orders, err := s.orders(bot.StrategyParams)
+	if err != nil {
+		return nil, err
+	}
+
+	quoteAmount := bot.InitialCapital.Div(decimal.NewFromInt(int64(orders)))
+
+	return []Decision{NewBuyDecision(bot.CalculatePriceRange(tick.Price), quoteAmount)}, nil
+}
+
+func (s *GridStrategy) orders(params StrategyParams) (int, error) {
+	orders, err := params.Int(GridParamOrders, 50)
+	if err != nil {
+		return 0, err
+	}
+
+	if orders < 1 {
+		return 0, errors.New(ErrInvalid, "grid orders must be positive", errors.WithMetadata(GridParamOrders, orders))
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGridStrategyValidateParams(t *testing.T) {
+	strategy := NewGridStrategy()
+
+	assert.NoError(t, strategy.ValidateParams(StrategyParams{}))
+	assert.NoError(t, strategy.ValidateParams(StrategyParams{GridParamOrders: "10"}))
+
+	for _, orders := range []string{"0", "-5", "2.5", "ten"} {
+		err := strategy.ValidateParams(StrategyParams{GridParamOrders: orders})
+		assert.True(t, errors.Is(err, ErrInvalid), orders)
+	}
+}
+
+func TestGridStrategyEvaluate(t *testing.T) {
+	strategy := NewGridStrategy()
+	bot := newTestBot(t, StrategyGrid, StrategyParams{GridParamOrders: "10"}, nil)
+
+	decisions, err := strategy.Evaluate(context.Background(), bot, NewTick(decimal.NewFromInt(50050), time.Now()))
+	assert.NoError(t, err)
+	assert.Len(t, decisions, 1)
+	assert.Equal(t, 500, decisions[0].PriceRange)
+	assert.Equal(t, "100", decisions[0].QuoteAmount.String())
+
+	/** Stored before params were validated, it fails instead of panicking */
+	bot.StrategyParams = StrategyParams{GridParamOrders: "0"}
+	_, err = strategy.Evaluate(context.Background(), bot, NewTick(decimal.NewFromInt(50050), time.Now()))
+	assert.True(t, errors.Is(err, ErrInvalid))
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// MarketDataStream pushes market data of the pairs subscribed to as it
+// happens, instead of polling prices.
+type MarketDataStream interface {
+	// Subscribe starts receiving the events of the pair until the subscription
+	// is closed. Every subscriber receives every event of its pair.
+	Subscribe(ctx context.Context, baseCurrency string, quoteCurrency string) (MarketSubscription, error)
+}
+
+type MarketSubscription interface {
+	// Events is closed when the subscription is. Events are dropped while the
+	// subscriber doesn't keep up, so only the latest ones matter.
+	Events() <-chan *MarketEvent
+	Close()
+}
+
+// MarketEvent has exactly one of Trade, BookTicker or Candle.
+type MarketEvent struct {
+	BaseCurrency  string
+	QuoteCurrency string
+	Trade         *Trade
+	BookTicker    *BookTicker
+	Candle        *Candle
+	// The candle is closed and won't change anymore.
+	CandleClosed bool
+}
+
+// Trade is a trade executed by the exchange between any two users.
+type Trade struct {
+	Price    decimal.Decimal
+	Quantity decimal.Decimal
+	Time     time.Time
+}
+
+// BookTicker is the best bid and ask of the order book.
+type BookTicker struct {
+	BidPrice    decimal.Decimal
+	BidQuantity decimal.Decimal
+	AskPrice    decimal.Decimal
+	AskQuantity decimal.Decimal
+}
@@ -1,77 +1,316 @@
 package domain
 
 import (
+	"context"
+	"time"
+
 	"github.com/juankohler/crypto-bot/libs/go/models"
 	"github.com/shopspring/decimal"
 )
 
 type OrderRepository interface {
-	// FindByID(ctx context.Context, id models.ID) (*Order, error)
-	// Save(ctx context.Context, order *Order) error
+	FindByID(ctx context.Context, id models.ID) (*Order, error)
+	FindByBotID(ctx context.Context, botID models.ID) ([]*Order, error)
+	Save(ctx context.Context, order *Order) error
 }
 
 const (
-	OrderStatusPending   = "PENDING"
-	OrderStatusOpen      = "OPEN"
-	OrderStatusCompleted = "COMPLETED"
+	OrderStatusPending         = "PENDING"
+	OrderStatusOpen            = "OPEN"
+	OrderStatusPartiallyFilled = "PARTIALLY_FILLED"
+	OrderStatusCompleted       = "COMPLETED"
+	OrderStatusCanceled        = "CANCELED"
+)
+
+const (
+	OrderSideBuy  = "BUY"
+	OrderSideSell = "SELL"
+)
+
+const (
+	OrderTypeMarket = "MARKET"
+	OrderTypeLimit  = "LIMIT"
 )
 
+// Reasons a position is sold for, recorded on the sell and on the buy it
+// closes.
+const (
+	ExitReasonTakeProfit   = "TAKE_PROFIT"
+	ExitReasonStopLoss     = "STOP_LOSS"
+	ExitReasonTrailingStop = "TRAILING_STOP"
+	// Not sold, the position was merged into the one of another buy.
+	ExitReasonMerged = "MERGED"
+)
+
+// Order is a buy that opens a position or the sell that closes it, the take
+// profit or, once the stop loss is reached, a MARKET sell. For LIMIT orders
+// EntryPrice is the limit price, so the take profit sell has EntryPrice and
+// TakeProfitPrice set to the same value.
 type Order struct {
-	ID                 models.ID
-	BotID              models.ID
-	Symbol             string
-	Quantity           decimal.Decimal
-	InitialQuoteAmount decimal.Decimal
-	FinalQuoteAmount   decimal.Decimal
-	EntryPrice         decimal.Decimal
-	TakeProfitPrice    decimal.Decimal
-	ExternalId         *string
-	Status             string
-	PriceRange         int
-	Timestamps         models.Timestamps
-	Version            models.Version
+	ID       models.ID
+	BotID    models.ID
+	ParentID *models.ID
+	// Buy whose position this one adds to, like the safety orders of a DCA
+	// position. It is merged into it once filled.
+	PositionID          *models.ID
+	Symbol              string
+	Side                string
+	Type                string
+	Quantity            decimal.Decimal
+	InitialQuoteAmount  decimal.Decimal
+	FinalQuoteAmount    decimal.Decimal
+	EntryPrice          decimal.Decimal
+	TakeProfitPrice     decimal.Decimal
+	ExecutedQuantity    decimal.Decimal
+	ExecutedQuoteAmount decimal.Decimal
+	Fee                 decimal.Decimal
+	FeeCurrency         string
+	// Fee valued in the quote currency, whatever the asset it was paid in.
+	FeeQuoteAmount decimal.Decimal
+	// Net profit of the position, after every fee. For buys it adds up the
+	// sales of the position, for take profits it is the profit of that sale.
+	RealizedPnL decimal.Decimal
+	// Price at which the position of a buy is sold at a loss, zero without a
+	// stop loss. Trailing stops raise it as the price goes up.
+	StopLossPrice decimal.Decimal
+	// Why the position was sold, on the sells and on the buys they closed.
+	ExitReason string
+	ExternalId *string
+	Status     string
+	PriceRange int
+	// Delta of the bot when the buy was placed, the width of its price range.
+	// Exits keep the one of their buy.
+	Delta      decimal.Decimal
+	ClosedAt   *time.Time
+	Timestamps models.Timestamps
+	Version    models.Version
+
+	// Take profit sell placed once this buy was filled, or the stop loss sell
+	// replacing it, linked to it through its ParentID.
+	TakeProfitOrder *Order
 }
 
 func NewOrder(
 	id models.ID,
 	botID models.ID,
+	parentID *models.ID,
+	positionID *models.ID,
 	symbol string,
+	side string,
+	orderType string,
 	quantity decimal.Decimal,
 	initialQuoteAmount decimal.Decimal,
 	finalQuoteAmount decimal.Decimal,
 	entryPrice decimal.Decimal,
 	takeProfitPrice decimal.Decimal,
+	executedQuantity decimal.Decimal,
+	executedQuoteAmount decimal.Decimal,
+	fee decimal.Decimal,
+	feeCurrency string,
+	feeQuoteAmount decimal.Decimal,
+	realizedPnL decimal.Decimal,
+	stopLossPrice decimal.Decimal,
+	exitReason string,
 	externalId *string,
 	status string,
 	priceRange int,
+	delta decimal.Decimal,
+	closedAt *time.Time,
 	timestamps models.Timestamps,
 	version models.Version,
 ) (*Order, error) {
 	entity := &Order{
-		ID:                 id,
-		BotID:              botID,
-		Symbol:             symbol,
-		Quantity:           quantity,
-		InitialQuoteAmount: initialQuoteAmount,
-		FinalQuoteAmount:   finalQuoteAmount,
-		EntryPrice:         entryPrice,
-		TakeProfitPrice:    takeProfitPrice,
-		ExternalId:         externalId,
-		Status:             status,
-		PriceRange:         priceRange,
-		Timestamps:         timestamps,
-		Version:            version,
+		ID:                  id,
+		BotID:               botID,
+		ParentID:            parentID,
+		PositionID:          positionID,
+		Symbol:              symbol,
+		Side:                side,
+		Type:                orderType,
+		Quantity:            quantity,
+		InitialQuoteAmount:  initialQuoteAmount,
+		FinalQuoteAmount:    finalQuoteAmount,
+		EntryPrice:          entryPrice,
+		TakeProfitPrice:     takeProfitPrice,
+		ExecutedQuantity:    executedQuantity,
+		ExecutedQuoteAmount: executedQuoteAmount,
+		Fee:                 fee,
+		FeeCurrency:         feeCurrency,
+		FeeQuoteAmount:      feeQuoteAmount,
+		RealizedPnL:         realizedPnL,
+		StopLossPrice:       stopLossPrice,
+		ExitReason:          exitReason,
+		ExternalId:          externalId,
+		Status:              status,
+		PriceRange:          priceRange,
+		Delta:               delta,
+		ClosedAt:            closedAt,
+		Timestamps:          timestamps,
+		Version:             version,
 	}
 
 	return entity, nil
 }
 
-// func (s *Order) updated() {
-// 	s.Timestamps = s.Timestamps.Update()
-// 	s.Version = s.Version.Update()
-// }
+func (s *Order) updated() {
+	s.Timestamps = s.Timestamps.Update()
+	s.Version = s.Version.Update()
+}
+
+// IsFinal is true once the provider will not execute the order any further.
+func (s *Order) IsFinal() bool {
+	return s.Status == OrderStatusCompleted || s.Status == OrderStatusCanceled
+}
+
+// IsFilled is true when the order is final and something was executed, that
+// is, there is a position to close.
+func (s *Order) IsFilled() bool {
+	return s.IsFinal() && s.ExecutedQuantity.IsPositive()
+}
+
+// IsTakeProfit is true for the sell that closes the position of another order,
+// also when it is a stop loss.
+func (s *Order) IsTakeProfit() bool {
+	return s.ParentID != nil
+}
+
+// FillPrice is the average price the order was filled at by the provider,
+// which is not the entry price of its position once others were merged into
+// it.
+func (s *Order) FillPrice() decimal.Decimal {
+	if !s.ExecutedQuantity.IsPositive() {
+		return s.EntryPrice
+	}
+
+	return s.ExecutedQuoteAmount.Div(s.ExecutedQuantity)
+}
+
+// IsStopLoss is true for the sell of a position that reached its stop.
+func (s *Order) IsStopLoss() bool {
+	return s.IsTakeProfit() && (s.ExitReason == ExitReasonStopLoss || s.ExitReason == ExitReasonTrailingStop)
+}
+
+// IsClosed is true when the position of the order was closed.
+func (s *Order) IsClosed() bool {
+	return s.ClosedAt != nil
+}
 
 func (s *Order) AddExternalId(externalId string) {
 	s.ExternalId = &externalId
 	s.Status = OrderStatusOpen
+	s.updated()
+}
+
+// Update applies the state reported by the provider and returns whether it
+// changed anything.
+func (s *Order) Update(providerOrder *ProviderOrder) bool {
+	changed := false
+
+	if s.ExternalId == nil && providerOrder.ExternalId != "" {
+		externalId := providerOrder.ExternalId
+		s.ExternalId = &externalId
+		changed = true
+	}
+
+	if s.Status != providerOrder.Status ||
+		!s.ExecutedQuantity.Equal(providerOrder.ExecutedQuantity) ||
+		!s.ExecutedQuoteAmount.Equal(providerOrder.ExecutedQuoteAmount) ||
+		!s.Fee.Equal(providerOrder.Fee) {
+		s.Status = providerOrder.Status
+		s.ExecutedQuantity = providerOrder.ExecutedQuantity
+		s.ExecutedQuoteAmount = providerOrder.ExecutedQuoteAmount
+		s.Fee = providerOrder.Fee
+		s.FeeCurrency = providerOrder.FeeCurrency
+		changed = true
+	}
+
+	if changed {
+		s.updated()
+	}
+
+	return changed
+}
+
+// JoinPosition makes the buy add to the position of another one.
+func (s *Order) JoinPosition(positionID models.ID) {
+	s.PositionID = &positionID
+	s.updated()
+}
+
+// Merge adds to the position of the buy the one of a filled buy joining it:
+// its quantity, cost, fees and profit, with the entry and take profit prices
+// averaged by quantity. Since the take profit grows linearly with the cost, its
+// average is the take profit of the average cost. The position takes the price
+// range of the last buy merged.
+func (s *Order) Merge(other *Order) {
+	quantity := s.Quantity.Add(other.Quantity)
+	s.EntryPrice = s.EntryPrice.Mul(s.Quantity).Add(other.EntryPrice.Mul(other.Quantity)).Div(quantity)
+	s.TakeProfitPrice = s.TakeProfitPrice.Mul(s.Quantity).Add(other.TakeProfitPrice.Mul(other.Quantity)).Div(quantity)
+	s.Quantity = quantity
+	s.InitialQuoteAmount = s.InitialQuoteAmount.Add(other.InitialQuoteAmount)
+	s.FinalQuoteAmount = s.Quantity.Mul(s.TakeProfitPrice)
+	s.FeeQuoteAmount = s.FeeQuoteAmount.Add(other.FeeQuoteAmount)
+	s.RealizedPnL = s.RealizedPnL.Add(other.RealizedPnL)
+	s.PriceRange = other.PriceRange
+	s.updated()
+}
+
+// SetStopLossPrice moves the stop of the position, when it is settled or
+// raised by a trailing stop.
+func (s *Order) SetStopLossPrice(price decimal.Decimal) {
+	s.StopLossPrice = price
+	s.updated()
+}
+
+// Settle replaces the estimated amounts with the executed ones once the order
+// is final: the quote amount actually spent, the quantity actually received,
+// the fee and the average fill price, with the take profit recalculated from
+// them.
+func (s *Order) Settle(spentQuoteAmount decimal.Decimal, receivedQuantity decimal.Decimal, feeQuoteAmount decimal.Decimal, takeProfitPrice decimal.Decimal) {
+	s.InitialQuoteAmount = spentQuoteAmount
+	s.Quantity = receivedQuantity
+	s.FeeQuoteAmount = feeQuoteAmount
+
+	if s.ExecutedQuantity.IsPositive() {
+		s.EntryPrice = s.ExecutedQuoteAmount.Div(s.ExecutedQuantity)
+		s.TakeProfitPrice = takeProfitPrice
+	}
+
+	s.FinalQuoteAmount = s.Quantity.Mul(s.TakeProfitPrice)
+	s.updated()
+}
+
+// Reduce takes out of the position the quantity sold by a take profit that was
+// canceled after a partial fill, together with its share of the cost.
+func (s *Order) Reduce(soldQuantity decimal.Decimal, cost decimal.Decimal) {
+	s.Quantity = s.Quantity.Sub(soldQuantity)
+	s.InitialQuoteAmount = s.InitialQuoteAmount.Sub(cost)
+	s.FinalQuoteAmount = s.Quantity.Mul(s.TakeProfitPrice)
+	s.updated()
+}
+
+// SettleSale records the fee and the net profit of a take profit once it is
+// final.
+func (s *Order) SettleSale(feeQuoteAmount decimal.Decimal, realizedPnL decimal.Decimal) {
+	s.FeeQuoteAmount = feeQuoteAmount
+	s.RealizedPnL = realizedPnL
+	s.updated()
+}
+
+// AddRealizedPnL books on a buy the profit, or the loss, of its position.
+func (s *Order) AddRealizedPnL(pnl decimal.Decimal) {
+	s.RealizedPnL = s.RealizedPnL.Add(pnl)
+	s.updated()
+}
+
+// Close closes the order at the time of the tick that saw it final.
+func (s *Order) Close(at time.Time) {
+	s.ClosedAt = &at
+	s.updated()
+}
+
+// Exit closes the position of a buy sold for the given reason.
+func (s *Order) Exit(reason string, at time.Time) {
+	s.ExitReason = reason
+	s.Close(at)
 }
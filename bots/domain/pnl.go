@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/juankohler/crypto-bot/libs/go/models"
+	"github.com/shopspring/decimal"
+)
+
+type PnLRepository interface {
+	// FindByBotID returns the snapshots of a bot taken in [start, end), sorted
+	// by time.
+	FindByBotID(ctx context.Context, botID models.ID, start time.Time, end time.Time) ([]*PnL, error)
+	Save(ctx context.Context, pnl *PnL) error
+}
+
+// PnL is the profit of a bot at a price, in the currency of the bot. Stored,
+// it is a snapshot of the performance of the bot over time.
+type PnL struct {
+	BotID          models.ID
+	Currency       string
+	Time           time.Time
+	Price          decimal.Decimal
+	InitialCapital decimal.Decimal
+	// Net profit of the positions sold.
+	RealizedPnL decimal.Decimal
+	// Profit of the open positions if they were sold at the price, before the
+	// fees of the sale.
+	UnrealizedPnL decimal.Decimal
+	// Value of the bot at the price, see Bot.MarkToMarket.
+	Equity   decimal.Decimal
+	FeesPaid decimal.Decimal
+	// Realized and unrealized profit over the initial capital.
+	ROI decimal.Decimal
+}
+
+func NewPnL(
+	botID models.ID,
+	currency string,
+	at time.Time,
+	price decimal.Decimal,
+	initialCapital decimal.Decimal,
+	realizedPnL decimal.Decimal,
+	unrealizedPnL decimal.Decimal,
+	equity decimal.Decimal,
+	feesPaid decimal.Decimal,
+) *PnL {
+	return &PnL{
+		BotID:          botID,
+		Currency:       currency,
+		Time:           at,
+		Price:          price,
+		InitialCapital: initialCapital,
+		RealizedPnL:    realizedPnL,
+		UnrealizedPnL:  unrealizedPnL,
+		Equity:         equity,
+		FeesPaid:       feesPaid,
+		ROI:            roi(realizedPnL.Add(unrealizedPnL), initialCapital),
+	}
+}
+
+// TotalPnL is the realized and unrealized profit.
+func (p *PnL) TotalPnL() decimal.Decimal {
+	return p.RealizedPnL.Add(p.UnrealizedPnL)
+}
+
+// SumPnL adds up the profit of many bots with the same currency, with the ROI
+// over their joint initial capital. Price and BotID are left empty.
+func SumPnL(currency string, at time.Time, pnls []*PnL) *PnL {
+	initialCapital, realizedPnL, unrealizedPnL, equity, feesPaid := decimal.Zero, decimal.Zero, decimal.Zero, decimal.Zero, decimal.Zero
+	for _, pnl := range pnls {
+		initialCapital = initialCapital.Add(pnl.InitialCapital)
+		realizedPnL = realizedPnL.Add(pnl.RealizedPnL)
+		unrealizedPnL = unrealizedPnL.Add(pnl.UnrealizedPnL)
+		equity = equity.Add(pnl.Equity)
+		feesPaid = feesPaid.Add(pnl.FeesPaid)
+	}
+
+	return NewPnL("", currency, at, decimal.Zero, initialCapital, realizedPnL, unrealizedPnL, equity, feesPaid)
+}
+
+func roi(pnl decimal.Decimal, initialCapital decimal.Decimal) decimal.Decimal {
+	if !initialCapital.IsPositive() {
+		return decimal.Zero
+	}
+
+	return pnl.Div(initialCapital)
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBotPnL(t *testing.T) {
+	bot := newTestBot(t, StrategyGrid, nil, nil)
+	now := time.Now()
+	assertPnL := func(name string, price int64, realized string, unrealized string, equity string, roi string) {
+		pnl := bot.PnL(decimal.NewFromInt(price), now)
+		assert.Equal(t, realized, pnl.RealizedPnL.String(), name)
+		assert.Equal(t, unrealized, pnl.UnrealizedPnL.String(), name)
+		assert.Equal(t, equity, pnl.Equity.String(), name)
+		assert.Equal(t, equity, bot.MarkToMarket(decimal.NewFromInt(price)).String(), name)
+		assert.Equal(t, roi, pnl.ROI.String(), name)
+		assert.Equal(t, "1000", pnl.InitialCapital.String(), name)
+	}
+
+	assertPnL("no orders", 50000, "0", "0", "1000", "0")
+
+	/** A buy waiting to be filled is worth what it reserved */
+	entry, err := buy(bot, 50000, 100, NewRiskManager(RiskLimits{}))
+	assert.NoError(t, err)
+	assertPnL("pending buy", 55000, "0", "0", "1000", "0")
+
+	fillOrder(t, bot, entry, "0.002", "100")
+	assertPnL("open above its cost", 55000, "0", "10", "1010", "0.01")
+	assertPnL("open below its cost", 45000, "0", "-10", "990", "-0.01")
+
+	/** Half sold before its take profit was canceled */
+	takeProfit, err := bot.GenerateTakeProfitOrder(entry, nil)
+	assert.NoError(t, err)
+	takeProfit.AddExternalId("2")
+	bot.ReconcileOrder(context.Background(), takeProfit, &ProviderOrder{
+		ExternalId:          "2",
+		Status:              OrderStatusCanceled,
+		ExecutedQuantity:    decimal.RequireFromString("0.001"),
+		ExecutedQuoteAmount: decimal.RequireFromString("50.5"),
+		FeeCurrency:         bot.Currency,
+	}, FeeSchedule{}, now)
+	assertPnL("partly sold", 55000, "0.5", "5", "1005.5", "0.0055")
+
+	takeProfit, err = bot.GenerateTakeProfitOrder(entry, nil)
+	assert.NoError(t, err)
+	takeProfit.AddExternalId("3")
+	bot.ReconcileOrder(context.Background(), takeProfit, &ProviderOrder{
+		ExternalId:          "3",
+		Status:              OrderStatusCompleted,
+		ExecutedQuantity:    decimal.RequireFromString("0.001"),
+		ExecutedQuoteAmount: decimal.RequireFromString("50.5"),
+		FeeCurrency:         bot.Currency,
+	}, FeeSchedule{}, now)
+	/** Closed, the price no longer matters */
+	assertPnL("closed", 55000, "1", "0", "1001", "0.001")
+	assertPnL("closed", 45000, "1", "0", "1001", "0.001")
+}
+
+func TestSumPnL(t *testing.T) {
+	now := time.Now()
+	pnl := SumPnL("USDT", now, []*PnL{
+		NewPnL("a", "USDT", now, decimal.NewFromInt(50000), decimal.NewFromInt(1000), decimal.NewFromInt(30), decimal.NewFromInt(-10), decimal.NewFromInt(1020), decimal.NewFromInt(2)),
+		NewPnL("b", "USDT", now, decimal.NewFromInt(50000), decimal.NewFromInt(3000), decimal.NewFromInt(0), decimal.NewFromInt(20), decimal.NewFromInt(3020), decimal.NewFromInt(1)),
+	})
+
+	assert.Equal(t, "4000", pnl.InitialCapital.String())
+	assert.Equal(t, "30", pnl.RealizedPnL.String())
+	assert.Equal(t, "10", pnl.UnrealizedPnL.String())
+	assert.Equal(t, "40", pnl.TotalPnL().String())
+	assert.Equal(t, "4040", pnl.Equity.String())
+	assert.Equal(t, "3", pnl.FeesPaid.String())
+	/** Over the joint capital, not the average of the ROIs */
+	assert.Equal(t, "0.01", pnl.ROI.String())
+	assert.Empty(t, pnl.BotID)
+
+	assert.Equal(t, "0", SumPnL("USDT", now, nil).ROI.String())
+}
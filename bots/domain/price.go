@@ -1,9 +1,23 @@
 package domain
 
 import (
+	"context"
+
 	"github.com/shopspring/decimal"
 )
 
+// PricesProvider fetches the price of many pairs at once.
+type PricesProvider interface {
+	// GetPrices returns the prices of the pairs the provider knows, in any
+	// order.
+	GetPrices(ctx context.Context, pairs []CurrencyPair) ([]*Price, error)
+}
+
+type CurrencyPair struct {
+	BaseCurrency  string
+	QuoteCurrency string
+}
+
 type Price struct {
 	BaseCurrency  string
 	QuoteCurrency string
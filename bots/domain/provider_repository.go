@@ -2,9 +2,62 @@ package domain
 
 import (
 	"context"
+
+	"github.com/shopspring/decimal"
 )
 
 type ProviderRepository interface {
 	GetPrice(ctx context.Context, baseCurrency string, quoteCurrency string) (*Price, error)
 	CreateOrderInProvider(ctx context.Context, order *Order, botName string) (string, error)
+	CancelOrderInProvider(ctx context.Context, order *Order) error
+	GetOrderFromProvider(ctx context.Context, order *Order) (*ProviderOrder, error)
+}
+
+// ProviderOrder is the state of an order as reported by the provider.
+type ProviderOrder struct {
+	ExternalId          string
+	Status              string
+	ExecutedQuantity    decimal.Decimal
+	ExecutedQuoteAmount decimal.Decimal
+	Fee                 decimal.Decimal
+	FeeCurrency         string
+}
+
+// Providers picks the provider a bot sends its orders to: the exchange for
+// bots in live mode and the simulator for bots in paper mode, or for every bot
+// when paper trading is enabled globally.
+type Providers struct {
+	live      ProviderRepository
+	paper     ProviderRepository
+	paperOnly bool
+}
+
+func NewProviders(live ProviderRepository, paper ProviderRepository, paperOnly bool) *Providers {
+	return &Providers{
+		live:      live,
+		paper:     paper,
+		paperOnly: paperOnly,
+	}
+}
+
+func (p *Providers) ForBot(bot *Bot) ProviderRepository {
+	if p.paperOnly || bot.IsPaper() {
+		return p.paper
+	}
+
+	return p.live
+}
+
+// PriceFeeder is a simulated provider whose prices can be fed by the caller.
+type PriceFeeder interface {
+	// FeedPrice sets the current price of the pair and fills the orders it
+	// crosses.
+	FeedPrice(ctx context.Context, baseCurrency string, quoteCurrency string, price decimal.Decimal) error
+}
+
+// ReplayProvider is a simulated provider whose prices are only fed by the
+// caller, used to replay historical data.
+type ReplayProvider interface {
+	ProviderRepository
+	PriceFeeder
 }
@@ -0,0 +1,252 @@
+package domain
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+	"github.com/shopspring/decimal"
+)
+
+// Rejections of the risk manager. Every one of them is also ErrRiskRejected.
+var (
+	ErrRiskRejected          = errors.Define("RISK_REJECTED")
+	ErrInsufficientCapital   = errors.Define("INSUFFICIENT_CAPITAL")
+	ErrMaxPositionSize       = errors.Define("MAX_POSITION_SIZE")
+	ErrMaxOpenOrders         = errors.Define("MAX_OPEN_ORDERS")
+	ErrMaxInvestedFraction   = errors.Define("MAX_INVESTED_FRACTION")
+	ErrMaxSymbolExposure     = errors.Define("MAX_SYMBOL_EXPOSURE")
+	ErrDailyLossLimitReached = errors.Define("DAILY_LOSS_LIMIT_REACHED")
+)
+
+// RiskLimits are the limits every buy is checked against before it is placed.
+// Zero values mean the limit does not apply.
+type RiskLimits struct {
+	// Maximum amount of a single buy, in the currency of the bot.
+	MaxPositionSize decimal.Decimal
+	// Maximum number of open positions of a bot.
+	MaxOpenOrders int
+	// Maximum fraction of the total capital of a bot that can be invested.
+	MaxInvestedFraction decimal.Decimal
+	// Maximum amount invested in a pair by all the bots together, in its
+	// quote currency.
+	MaxSymbolExposure decimal.Decimal
+	// Maximum drop of the mark to market of a bot since the start of the day,
+	// UTC, as a fraction of it. Once reached the bot does not buy again until
+	// the next day.
+	MaxDailyLoss decimal.Decimal
+}
+
+func NewRiskLimits(
+	maxPositionSize decimal.Decimal,
+	maxOpenOrders int,
+	maxInvestedFraction decimal.Decimal,
+	maxSymbolExposure decimal.Decimal,
+	maxDailyLoss decimal.Decimal,
+) (RiskLimits, error) {
+	if maxPositionSize.IsNegative() || maxSymbolExposure.IsNegative() || maxOpenOrders < 0 {
+		return RiskLimits{}, errors.New(
+			ErrInvalid,
+			"risk limits can't be negative",
+			errors.WithMetadata("max_position_size", maxPositionSize.String()),
+			errors.WithMetadata("max_open_orders", maxOpenOrders),
+			errors.WithMetadata("max_symbol_exposure", maxSymbolExposure.String()),
+		)
+	}
+
+	one := decimal.NewFromInt(1)
+	for _, fraction := range []decimal.Decimal{maxInvestedFraction, maxDailyLoss} {
+		if fraction.IsNegative() || fraction.GreaterThan(one) {
+			return RiskLimits{}, errors.New(ErrInvalid, "risk fractions must be between 0 and 1", errors.WithMetadata("fraction", fraction.String()))
+		}
+	}
+
+	return RiskLimits{
+		MaxPositionSize:     maxPositionSize,
+		MaxOpenOrders:       maxOpenOrders,
+		MaxInvestedFraction: maxInvestedFraction,
+		MaxSymbolExposure:   maxSymbolExposure,
+		MaxDailyLoss:        maxDailyLoss,
+	}, nil
+}
+
+// RiskManager checks the buys of the bots against the risk limits, so the ones
+// breaching them are never placed. It is shared by every bot of the process to
+// follow the exposure of each pair across them.
+type RiskManager struct {
+	limits RiskLimits
+
+	mu sync.Mutex
+	// Capital invested by every bot, by pair.
+	exposures map[Symbol]map[models.ID]decimal.Decimal
+	// Mark to market of every bot at the start of the day.
+	dayStarts map[models.ID]dayStart
+}
+
+type dayStart struct {
+	day          time.Time
+	markToMarket decimal.Decimal
+}
+
+func NewRiskManager(limits RiskLimits) *RiskManager {
+	return &RiskManager{
+		limits:    limits,
+		exposures: make(map[Symbol]map[models.ID]decimal.Decimal),
+		dayStarts: make(map[models.ID]dayStart),
+	}
+}
+
+// Track records the exposure of a bot and, on the first tick of each day, its
+// mark to market to measure the loss of the day. Bots are tracked on every
+// execution, so the exposure of a pair is the one of the bots running.
+func (m *RiskManager) Track(bot *Bot, tick Tick) {
+	bot.mu.Lock()
+	defer bot.mu.Unlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.setExposure(bot)
+
+	day := tick.Time.UTC().Truncate(24 * time.Hour)
+	if start, ok := m.dayStarts[bot.ID]; !ok || start.day.Before(day) {
+		markToMarket, _ := bot.markToMarket(tick.Price)
+		m.dayStarts[bot.ID] = dayStart{day: day, markToMarket: markToMarket}
+	}
+}
+
+// RestoreDayStart sets the mark to market of a bot at the start of the day of
+// the given time, as recorded before a restart, so the loss of that day keeps
+// counting from it. It is ignored once the bot was tracked on that day.
+func (m *RiskManager) RestoreDayStart(botID models.ID, at time.Time, markToMarket decimal.Decimal) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	day := at.UTC().Truncate(24 * time.Hour)
+	if start, ok := m.dayStarts[botID]; ok && !start.day.Before(day) {
+		return
+	}
+
+	m.dayStarts[botID] = dayStart{day: day, markToMarket: markToMarket}
+}
+
+// Forget stops following a bot, once it was deleted.
+func (m *RiskManager) Forget(botID models.ID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for symbol, exposures := range m.exposures {
+		delete(exposures, botID)
+		if len(exposures) == 0 {
+			delete(m.exposures, symbol)
+		}
+	}
+	delete(m.dayStarts, botID)
+}
+
+// reserve checks a buy of the bot for the given amount at the price and, if no
+// limit is breached, adds it to the exposure of the pair. It must be called
+// holding the lock of the bot, which reserves the capital right after.
+func (m *RiskManager) reserve(bot *Bot, quoteAmount decimal.Decimal, price decimal.Decimal) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.check(bot, quoteAmount, price); err != nil {
+		return errors.Wrap(
+			ErrRiskRejected,
+			err,
+			"order rejected by the risk manager",
+			errors.WithMetadata("bot_id", bot.ID),
+			errors.WithMetadata("quote_amount", quoteAmount.String()),
+		)
+	}
+
+	m.setExposure(bot)
+	m.exposures[bot.Symbol()][bot.ID] = bot.InvestedCapital.Add(quoteAmount)
+
+	return nil
+}
+
+func (m *RiskManager) check(bot *Bot, quoteAmount decimal.Decimal, price decimal.Decimal) error {
+	if !quoteAmount.IsPositive() || quoteAmount.GreaterThan(bot.AvailableCapital) {
+		return errors.New(
+			ErrInsufficientCapital,
+			"not enough available capital",
+			errors.WithMetadata("available_capital", bot.AvailableCapital.String()),
+		)
+	}
+
+	if m.limits.MaxPositionSize.IsPositive() && quoteAmount.GreaterThan(m.limits.MaxPositionSize) {
+		return errors.New(
+			ErrMaxPositionSize,
+			"position size above the maximum",
+			errors.WithMetadata("max_position_size", m.limits.MaxPositionSize.String()),
+		)
+	}
+
+	if m.limits.MaxOpenOrders > 0 && len(bot.OpenOrders) >= m.limits.MaxOpenOrders {
+		return errors.New(
+			ErrMaxOpenOrders,
+			"too many open orders",
+			errors.WithMetadata("open_orders", len(bot.OpenOrders)),
+			errors.WithMetadata("max_open_orders", m.limits.MaxOpenOrders),
+		)
+	}
+
+	invested := bot.InvestedCapital.Add(quoteAmount)
+	if m.limits.MaxInvestedFraction.IsPositive() && invested.GreaterThan(bot.TotalCapital.Mul(m.limits.MaxInvestedFraction)) {
+		return errors.New(
+			ErrMaxInvestedFraction,
+			"invested capital above the maximum",
+			errors.WithMetadata("invested_capital", invested.String()),
+			errors.WithMetadata("max_invested_fraction", m.limits.MaxInvestedFraction.String()),
+		)
+	}
+
+	if m.limits.MaxSymbolExposure.IsPositive() {
+		/** The bot itself is counted with its current capital, the one tracked
+		may be older */
+		exposure := invested
+		for botID, amount := range m.exposures[bot.Symbol()] {
+			if botID != bot.ID {
+				exposure = exposure.Add(amount)
+			}
+		}
+
+		if exposure.GreaterThan(m.limits.MaxSymbolExposure) {
+			return errors.New(
+				ErrMaxSymbolExposure,
+				"exposure to the pair above the maximum",
+				errors.WithMetadata("symbol", bot.Symbol().String()),
+				errors.WithMetadata("exposure", exposure.String()),
+				errors.WithMetadata("max_symbol_exposure", m.limits.MaxSymbolExposure.String()),
+			)
+		}
+	}
+
+	if start, ok := m.dayStarts[bot.ID]; ok && m.limits.MaxDailyLoss.IsPositive() && start.markToMarket.IsPositive() {
+		markToMarket, _ := bot.markToMarket(price)
+		loss := start.markToMarket.Sub(markToMarket).Div(start.markToMarket)
+		if loss.GreaterThanOrEqual(m.limits.MaxDailyLoss) {
+			return errors.New(
+				ErrDailyLossLimitReached,
+				"daily loss limit reached",
+				errors.WithMetadata("loss", loss.String()),
+				errors.WithMetadata("max_daily_loss", m.limits.MaxDailyLoss.String()),
+			)
+		}
+	}
+
+	return nil
+}
+
+// setExposure must be called holding both locks.
+func (m *RiskManager) setExposure(bot *Bot) {
+	symbol := bot.Symbol()
+	if _, ok := m.exposures[symbol]; !ok {
+		m.exposures[symbol] = make(map[models.ID]decimal.Decimal)
+	}
+
+	m.exposures[symbol][bot.ID] = bot.InvestedCapital
+}
@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func assertRejected(t *testing.T, err error, code *errors.ErrorCode, name string) {
+	assert.True(t, errors.Is(err, ErrRiskRejected), name)
+	assert.True(t, errors.Is(err, code), name)
+}
+
+func TestRiskManagerLimits(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		limits RiskLimits
+		// Buys accepted before the one rejected.
+		accepted int
+		code     *errors.ErrorCode
+	}{
+		{"insufficient capital", RiskLimits{}, 0, ErrInsufficientCapital},
+		{"max position size", RiskLimits{MaxPositionSize: decimal.NewFromInt(50)}, 0, ErrMaxPositionSize},
+		{"max open orders", RiskLimits{MaxOpenOrders: 2}, 2, ErrMaxOpenOrders},
+		{"max invested fraction", RiskLimits{MaxInvestedFraction: decimal.RequireFromString("0.25")}, 2, ErrMaxInvestedFraction},
+	} {
+		bot := newTestBot(t, StrategyGrid, nil, nil)
+		risk := NewRiskManager(tt.limits)
+
+		for i := 0; i < tt.accepted; i++ {
+			_, err := buy(bot, 50000-int64(i)*100, 100, risk)
+			assert.NoError(t, err, tt.name)
+		}
+
+		quoteAmount := int64(100)
+		if tt.code == ErrInsufficientCapital {
+			quoteAmount = 1001
+		}
+
+		_, err := buy(bot, 40000, quoteAmount, risk)
+		assertRejected(t, err, tt.code, tt.name)
+
+		/** Nothing is reserved for the rejected buy */
+		assert.Len(t, bot.OpenOrders, tt.accepted, tt.name)
+		assert.Equal(t, decimal.NewFromInt(1000-int64(tt.accepted)*100).String(), bot.AvailableCapital.String(), tt.name)
+	}
+}
+
+func TestRiskManagerSymbolExposure(t *testing.T) {
+	risk := NewRiskManager(RiskLimits{MaxSymbolExposure: decimal.NewFromInt(250)})
+	first := newTestBot(t, StrategyGrid, nil, nil)
+	second := newTestBot(t, StrategyGrid, nil, nil)
+
+	_, err := buy(first, 50000, 100, risk)
+	assert.NoError(t, err)
+	_, err = buy(second, 49900, 100, risk)
+	assert.NoError(t, err)
+
+	/** The buys of every bot of the pair count together */
+	_, err = buy(first, 49800, 100, risk)
+	assertRejected(t, err, ErrMaxSymbolExposure, "third buy")
+	_, err = buy(second, 49800, 50, risk)
+	assert.NoError(t, err)
+
+	/** Other pairs are not limited by it */
+	other, err := CreateBot("other", "USDT", "ETH", decimal.RequireFromString("0.01"), decimal.NewFromInt(1000), decimal.NewFromInt(10), time.Minute, StrategyGrid, nil, BotModePaper, nil, nil)
+	assert.NoError(t, err)
+	_, err = buy(other, 3000, 200, risk)
+	assert.NoError(t, err)
+
+	/** Once forgotten, a deleted bot frees its exposure */
+	risk.Forget(first.ID)
+	_, err = buy(second, 49700, 100, risk)
+	assert.NoError(t, err)
+}
+
+func TestRiskManagerDailyLoss(t *testing.T) {
+	risk := NewRiskManager(RiskLimits{MaxDailyLoss: decimal.RequireFromString("0.05")})
+	bot := newTestBot(t, StrategyGrid, nil, nil)
+	day := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	entry, err := buy(bot, 50000, 100, risk)
+	assert.NoError(t, err)
+	entry.AddExternalId("1")
+	bot.ReconcileOrder(context.Background(), entry, &ProviderOrder{
+		ExternalId:          "1",
+		Status:              OrderStatusCompleted,
+		ExecutedQuantity:    decimal.RequireFromString("0.002"),
+		ExecutedQuoteAmount: decimal.NewFromInt(100),
+		FeeCurrency:         "BTC",
+	}, FeeSchedule{}, time.Now())
+
+	risk.Track(bot, NewTick(decimal.NewFromInt(50000), day))
+
+	/** Worth 960 at 30000, 4% less than at the start of the day */
+	_, err = buy(bot, 30000, 100, risk)
+	assert.NoError(t, err)
+
+	/** Worth 950 at 25000, the limit is reached */
+	_, err = buy(bot, 25000, 100, risk)
+	assertRejected(t, err, ErrDailyLossLimitReached, "daily loss")
+
+	/** Later the same day the start is kept */
+	risk.Track(bot, NewTick(decimal.NewFromInt(25000), day.Add(time.Hour)))
+	_, err = buy(bot, 25000, 100, risk)
+	assertRejected(t, err, ErrDailyLossLimitReached, "same day")
+
+	/** The next day starts from the current mark to market */
+	risk.Track(bot, NewTick(decimal.NewFromInt(25000), day.Add(24*time.Hour)))
+	_, err = buy(bot, 25000, 100, risk)
+	assert.NoError(t, err)
+}
+
+func TestRiskManagerRestoreDayStart(t *testing.T) {
+	risk := NewRiskManager(RiskLimits{MaxDailyLoss: decimal.RequireFromString("0.05")})
+	bot := newTestBot(t, StrategyGrid, nil, nil)
+	day := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	/** Worth 1100 at the start of the day, before the restart */
+	risk.RestoreDayStart(bot.ID, day.Add(-9*time.Hour), decimal.NewFromInt(1100))
+	risk.Track(bot, NewTick(decimal.NewFromInt(50000), day))
+
+	/** Worth 1000 now, 9% less */
+	_, err := buy(bot, 50000, 100, risk)
+	assertRejected(t, err, ErrDailyLossLimitReached, "restored")
+
+	/** What the bot tracked since is not replaced */
+	risk.RestoreDayStart(bot.ID, day.Add(-10*time.Hour), decimal.NewFromInt(1000))
+	_, err = buy(bot, 50000, 100, risk)
+	assertRejected(t, err, ErrDailyLossLimitReached, "tracked")
+
+	/** Neither by a day before */
+	other := newTestBot(t, StrategyGrid, nil, nil)
+	risk.Track(other, NewTick(decimal.NewFromInt(50000), day))
+	risk.RestoreDayStart(other.ID, day.Add(-24*time.Hour), decimal.NewFromInt(1100))
+	_, err = buy(other, 50000, 100, risk)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// The stop is a fraction of the entry price below it.
+	StopLossTypePercentage = "PERCENTAGE"
+	// The stop is an amount of the quote currency below the entry price.
+	StopLossTypeAbsolute = "ABSOLUTE"
+)
+
+// StopLoss is the distance below the entry price at which the positions of a
+// bot are sold at a loss. A trailing stop keeps that distance below the highest
+// price seen since the entry instead.
+type StopLoss struct {
+	Type     string
+	Value    decimal.Decimal
+	Trailing bool
+}
+
+func NewStopLoss(stopLossType string, value decimal.Decimal, trailing bool) (*StopLoss, error) {
+	if stopLossType != StopLossTypePercentage && stopLossType != StopLossTypeAbsolute {
+		return nil, errors.New(ErrInvalid, "invalid stop loss type", errors.WithMetadata("type", stopLossType))
+	}
+
+	if !value.IsPositive() {
+		return nil, errors.New(ErrInvalid, "stop loss must be positive", errors.WithMetadata("value", value.String()))
+	}
+
+	if stopLossType == StopLossTypePercentage && value.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return nil, errors.New(ErrInvalid, "stop loss percentage must be below 1", errors.WithMetadata("value", value.String()))
+	}
+
+	return &StopLoss{
+		Type:     stopLossType,
+		Value:    value,
+		Trailing: trailing,
+	}, nil
+}
+
+// Price returns the stop price of a position at the given reference, its
+// entry price or, for trailing stops, the highest price since the entry.
+func (s *StopLoss) Price(reference decimal.Decimal) decimal.Decimal {
+	var price decimal.Decimal
+	if s.Type == StopLossTypePercentage {
+		price = reference.Mul(decimal.NewFromInt(1).Sub(s.Value))
+	} else {
+		price = reference.Sub(s.Value)
+	}
+
+	return decimal.Max(price, decimal.Zero)
+}
+
+// ExitReason is the reason recorded on the orders closed by the stop.
+func (s *StopLoss) ExitReason() string {
+	if s.Trailing {
+		return ExitReasonTrailingStop
+	}
+
+	return ExitReasonStopLoss
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStopLoss(t *testing.T) {
+	for _, tt := range []struct {
+		stopLossType string
+		value        string
+	}{
+		{"MOON", "0.1"},
+		{StopLossTypePercentage, "0"},
+		{StopLossTypePercentage, "1"},
+		{StopLossTypeAbsolute, "-100"},
+	} {
+		_, err := NewStopLoss(tt.stopLossType, decimal.RequireFromString(tt.value), false)
+		assert.True(t, errors.Is(err, ErrInvalid), tt.stopLossType+" "+tt.value)
+	}
+
+	stopLoss, err := NewStopLoss(StopLossTypeAbsolute, decimal.NewFromInt(1000), true)
+	assert.NoError(t, err)
+	assert.Equal(t, ExitReasonTrailingStop, stopLoss.ExitReason())
+}
+
+func TestStopLossPrice(t *testing.T) {
+	percentage, err := NewStopLoss(StopLossTypePercentage, decimal.RequireFromString("0.1"), false)
+	assert.NoError(t, err)
+	assert.Equal(t, "45000", percentage.Price(decimal.NewFromInt(50000)).String())
+	assert.Equal(t, ExitReasonStopLoss, percentage.ExitReason())
+
+	absolute, err := NewStopLoss(StopLossTypeAbsolute, decimal.NewFromInt(1000), false)
+	assert.NoError(t, err)
+	assert.Equal(t, "49000", absolute.Price(decimal.NewFromInt(50000)).String())
+	/** Never below zero */
+	assert.Equal(t, "0", absolute.Price(decimal.NewFromInt(500)).String())
+}
+
+func TestTriggeredStopLossesFixed(t *testing.T) {
+	stopLoss, err := NewStopLoss(StopLossTypePercentage, decimal.RequireFromString("0.1"), false)
+	assert.NoError(t, err)
+	bot := newTestBot(t, StrategyGrid, nil, stopLoss)
+	risk := NewRiskManager(RiskLimits{})
+
+	entry, err := buy(bot, 50000, 100, risk)
+	assert.NoError(t, err)
+	assert.Equal(t, "45000", entry.StopLossPrice.String())
+
+	/** Not filled yet, there is no position to sell */
+	assert.Empty(t, bot.TriggeredStopLosses(decimal.NewFromInt(40000)))
+
+	fillOrder(t, bot, entry, "0.002", "100")
+	assert.Empty(t, bot.TriggeredStopLosses(decimal.NewFromInt(45001)))
+
+	/** A fixed stop stays at the entry price after a new high */
+	assert.Empty(t, bot.TriggeredStopLosses(decimal.NewFromInt(60000)))
+	assert.Equal(t, "45000", entry.StopLossPrice.String())
+
+	highest, ok := bot.HighestStopLossPrice()
+	assert.True(t, ok)
+	assert.Equal(t, "45000", highest.String())
+
+	triggered := bot.TriggeredStopLosses(decimal.NewFromInt(45000))
+	assert.Len(t, triggered, 1)
+	assert.Equal(t, entry.ID, triggered[0].ID)
+}
+
+func TestTriggeredStopLossesAbsolute(t *testing.T) {
+	stopLoss, err := NewStopLoss(StopLossTypeAbsolute, decimal.NewFromInt(2000), false)
+	assert.NoError(t, err)
+	bot := newTestBot(t, StrategyGrid, nil, stopLoss)
+
+	entry, err := buy(bot, 50000, 100, NewRiskManager(RiskLimits{}))
+	assert.NoError(t, err)
+	/** Filled lower than estimated, the stop follows the executed price */
+	fillOrder(t, bot, entry, "0.002", "98")
+	assert.Equal(t, "47000", entry.StopLossPrice.String())
+
+	assert.Empty(t, bot.TriggeredStopLosses(decimal.NewFromInt(47500)))
+	assert.Len(t, bot.TriggeredStopLosses(decimal.NewFromInt(46900)), 1)
+}
+
+func TestTriggeredStopLossesTrailing(t *testing.T) {
+	stopLoss, err := NewStopLoss(StopLossTypePercentage, decimal.RequireFromString("0.1"), true)
+	assert.NoError(t, err)
+	bot := newTestBot(t, StrategyGrid, nil, stopLoss)
+	risk := NewRiskManager(RiskLimits{})
+
+	first, err := buy(bot, 50000, 100, risk)
+	assert.NoError(t, err)
+	fillOrder(t, bot, first, "0.002", "100")
+	second, err := buy(bot, 58000, 116, risk)
+	assert.NoError(t, err)
+	fillOrder(t, bot, second, "0.002", "116")
+	assert.Equal(t, "52200", second.StopLossPrice.String())
+
+	/** A new high raises the stop of every position */
+	assert.Empty(t, bot.TriggeredStopLosses(decimal.NewFromInt(60000)))
+	assert.Equal(t, "54000", first.StopLossPrice.String())
+	assert.Equal(t, "54000", second.StopLossPrice.String())
+
+	/** Falling back does not lower it */
+	assert.Empty(t, bot.TriggeredStopLosses(decimal.NewFromInt(55000)))
+	assert.Equal(t, "54000", first.StopLossPrice.String())
+
+	highest, ok := bot.HighestStopLossPrice()
+	assert.True(t, ok)
+	assert.Equal(t, "54000", highest.String())
+
+	/** Both positions are sold at the trailed stop, the first one at a profit */
+	assert.Len(t, bot.TriggeredStopLosses(decimal.NewFromInt(54000)), 2)
+}
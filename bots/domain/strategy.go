@@ -0,0 +1,193 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+	"github.com/shopspring/decimal"
+)
+
+type Strategy interface {
+	Name() string
+	Evaluate(ctx context.Context, bot *Bot, tick Tick) ([]Decision, error)
+}
+
+// StrategyParamsValidator is implemented by the strategies that check their
+// params when a bot is created, instead of failing on every evaluation.
+type StrategyParamsValidator interface {
+	ValidateParams(params StrategyParams) error
+}
+
+type Tick struct {
+	Price decimal.Decimal
+	Time  time.Time
+}
+
+func NewTick(price decimal.Decimal, time time.Time) Tick {
+	return Tick{
+		Price: price,
+		Time:  time,
+	}
+}
+
+const (
+	DecisionActionBuy = "BUY"
+)
+
+type Decision struct {
+	Action      string
+	PriceRange  int
+	QuoteAmount decimal.Decimal
+	OrderType   string
+	// Limit price of LIMIT orders.
+	Price decimal.Decimal
+	// Open buy whose position the order adds to, if any.
+	PositionID *models.ID
+}
+
+// NewBuyDecision buys at market.
+func NewBuyDecision(priceRange int, quoteAmount decimal.Decimal) Decision {
+	return Decision{
+		Action:      DecisionActionBuy,
+		PriceRange:  priceRange,
+		QuoteAmount: quoteAmount,
+		OrderType:   OrderTypeMarket,
+	}
+}
+
+// NewLimitBuyDecision places a buy in the book at the given price.
+func NewLimitBuyDecision(priceRange int, price decimal.Decimal, quoteAmount decimal.Decimal) Decision {
+	return Decision{
+		Action:      DecisionActionBuy,
+		PriceRange:  priceRange,
+		QuoteAmount: quoteAmount,
+		OrderType:   OrderTypeLimit,
+		Price:       price,
+	}
+}
+
+// NewPositionBuyDecision buys at market to add to the position of an open buy.
+func NewPositionBuyDecision(positionID models.ID, priceRange int, quoteAmount decimal.Decimal) Decision {
+	decision := NewBuyDecision(priceRange, quoteAmount)
+	decision.PositionID = &positionID
+
+	return decision
+}
+
+/** Params */
+type StrategyParams map[string]string
+
+func (p StrategyParams) Decimal(key string, defaultValue decimal.Decimal) (decimal.Decimal, error) {
+	value, ok := p[key]
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Decimal{}, errors.Wrap(
+			ErrInvalid,
+			err,
+			fmt.Sprintf("invalid strategy param %s", key),
+			errors.WithMetadata(key, value),
+		)
+	}
+
+	return d, nil
+}
+
+func (p StrategyParams) Int(key string, defaultValue int) (int, error) {
+	value, ok := p[key]
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.Wrap(
+			ErrInvalid,
+			err,
+			fmt.Sprintf("invalid strategy param %s", key),
+			errors.WithMetadata(key, value),
+		)
+	}
+
+	return i, nil
+}
+
+func (p StrategyParams) Bool(key string, defaultValue bool) (bool, error) {
+	value, ok := p[key]
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.Wrap(
+			ErrInvalid,
+			err,
+			fmt.Sprintf("invalid strategy param %s", key),
+			errors.WithMetadata(key, value),
+		)
+	}
+
+	return b, nil
+}
+
+/** Registry */
+type StrategyRegistry struct {
+	strategies map[string]Strategy
+	mu         sync.RWMutex
+}
+
+func NewStrategyRegistry(strategies ...Strategy) (*StrategyRegistry, error) {
+	registry := &StrategyRegistry{
+		strategies: make(map[string]Strategy),
+	}
+
+	for _, strategy := range strategies {
+		if err := registry.Register(strategy); err != nil {
+			return nil, err
+		}
+	}
+
+	return registry, nil
+}
+
+func (r *StrategyRegistry) Register(strategy Strategy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.strategies[strategy.Name()]; ok {
+		return errors.New(
+			ErrInvalid,
+			"strategy already registered",
+			errors.WithMetadata("strategy", strategy.Name()),
+		)
+	}
+
+	r.strategies[strategy.Name()] = strategy
+
+	return nil
+}
+
+func (r *StrategyRegistry) Get(name string) (Strategy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	strategy, ok := r.strategies[name]
+	if !ok {
+		return nil, errors.New(
+			ErrNotFound,
+			"strategy not found",
+			errors.WithMetadata("strategy", name),
+		)
+	}
+
+	return strategy, nil
+}
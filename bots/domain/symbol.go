@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+)
+
+// SymbolValidator checks a pair against the exchange before a bot trades it.
+type SymbolValidator interface {
+	// ValidateSymbol fails with ErrNotFound if the pair is not listed or not
+	// trading.
+	ValidateSymbol(ctx context.Context, symbol Symbol) error
+}
+
+var assetPattern = regexp.MustCompile(`^[A-Z0-9]{1,20}$`)
+
+// Symbol is a trading pair, the base asset bought and sold for the quote
+// asset. Every provider names it its own way: BTCUSDT for Binance and
+// BTC-USDT, the form stored with the orders, for the rest.
+type Symbol struct {
+	Base  string
+	Quote string
+}
+
+func NewSymbol(base string, quote string) (Symbol, error) {
+	base = strings.ToUpper(strings.TrimSpace(base))
+	quote = strings.ToUpper(strings.TrimSpace(quote))
+
+	if !assetPattern.MatchString(base) || !assetPattern.MatchString(quote) {
+		return Symbol{}, errors.New(
+			ErrInvalid,
+			"invalid asset",
+			errors.WithMetadata("base", base),
+			errors.WithMetadata("quote", quote),
+		)
+	}
+
+	if base == quote {
+		return Symbol{}, errors.New(ErrInvalid, "base and quote assets must be different", errors.WithMetadata("asset", base))
+	}
+
+	return Symbol{Base: base, Quote: quote}, nil
+}
+
+// ParseSymbol parses a symbol in the BTC-USDT form. Without a separator, like
+// BTCUSDT, the assets can't be told apart.
+func ParseSymbol(symbol string) (Symbol, error) {
+	base, quote, ok := strings.Cut(symbol, "-")
+	if !ok {
+		return Symbol{}, errors.New(ErrInvalid, "invalid symbol", errors.WithMetadata("symbol", symbol))
+	}
+
+	return NewSymbol(base, quote)
+}
+
+func (s Symbol) String() string {
+	return s.Base + "-" + s.Quote
+}
+
+// Binance returns the symbol as Binance names it, e.g. BTCUSDT.
+func (s Symbol) Binance() string {
+	return s.Base + s.Quote
+}
@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+)
+
+// SymbolFiltersProvider fetches the trading rules of a pair from the exchange.
+type SymbolFiltersProvider interface {
+	GetSymbolFilters(ctx context.Context, baseCurrency string, quoteCurrency string) (*SymbolFilters, error)
+}
+
+// SymbolFilters are the rules the exchange enforces on the orders of a pair,
+// like the PRICE_FILTER, LOT_SIZE and MIN_NOTIONAL filters of Binance. Zero
+// values mean the rule does not apply.
+type SymbolFilters struct {
+	BaseCurrency  string
+	QuoteCurrency string
+	TickSize      decimal.Decimal
+	MinPrice      decimal.Decimal
+	MaxPrice      decimal.Decimal
+	StepSize      decimal.Decimal
+	MinQuantity   decimal.Decimal
+	MaxQuantity   decimal.Decimal
+	MinNotional   decimal.Decimal
+}
+
+func NewSymbolFilters(
+	baseCurrency string,
+	quoteCurrency string,
+	tickSize decimal.Decimal,
+	minPrice decimal.Decimal,
+	maxPrice decimal.Decimal,
+	stepSize decimal.Decimal,
+	minQuantity decimal.Decimal,
+	maxQuantity decimal.Decimal,
+	minNotional decimal.Decimal,
+) (*SymbolFilters, error) {
+	if baseCurrency == "" || quoteCurrency == "" {
+		return nil, errors.New(
+			ErrInvalid,
+			"invalid pair",
+			errors.WithMetadata("base_currency", baseCurrency),
+			errors.WithMetadata("quote_currency", quoteCurrency),
+		)
+	}
+
+	for _, value := range []decimal.Decimal{tickSize, minPrice, maxPrice, stepSize, minQuantity, maxQuantity, minNotional} {
+		if value.IsNegative() {
+			return nil, errors.New(ErrInvalid, "filters can't be negative", errors.WithMetadata("symbol", baseCurrency+quoteCurrency))
+		}
+	}
+
+	return &SymbolFilters{
+		BaseCurrency:  baseCurrency,
+		QuoteCurrency: quoteCurrency,
+		TickSize:      tickSize,
+		MinPrice:      minPrice,
+		MaxPrice:      maxPrice,
+		StepSize:      stepSize,
+		MinQuantity:   minQuantity,
+		MaxQuantity:   maxQuantity,
+		MinNotional:   minNotional,
+	}, nil
+}
+
+// RoundQuantity rounds the quantity down to the step size, so we never buy or
+// sell more than we meant to.
+func (f *SymbolFilters) RoundQuantity(quantity decimal.Decimal) decimal.Decimal {
+	return floorTo(quantity, f.StepSize)
+}
+
+// RoundPriceDown rounds the price down to the tick size.
+func (f *SymbolFilters) RoundPriceDown(price decimal.Decimal) decimal.Decimal {
+	return floorTo(price, f.TickSize)
+}
+
+// RoundPriceUp rounds the price up to the tick size, so a take profit never
+// sells below its target.
+func (f *SymbolFilters) RoundPriceUp(price decimal.Decimal) decimal.Decimal {
+	if !f.TickSize.IsPositive() {
+		return price
+	}
+
+	return price.Div(f.TickSize).Ceil().Mul(f.TickSize)
+}
+
+// Validate checks an order, already rounded, against every filter.
+func (f *SymbolFilters) Validate(quantity decimal.Decimal, price decimal.Decimal) error {
+	symbol := f.BaseCurrency + f.QuoteCurrency
+
+	if !quantity.IsPositive() || quantity.LessThan(f.MinQuantity) {
+		return errors.New(
+			ErrInvalid,
+			"quantity below the minimum",
+			errors.WithMetadata("symbol", symbol),
+			errors.WithMetadata("quantity", quantity.String()),
+			errors.WithMetadata("min_quantity", f.MinQuantity.String()),
+		)
+	}
+
+	if f.MaxQuantity.IsPositive() && quantity.GreaterThan(f.MaxQuantity) {
+		return errors.New(
+			ErrInvalid,
+			"quantity above the maximum",
+			errors.WithMetadata("symbol", symbol),
+			errors.WithMetadata("quantity", quantity.String()),
+			errors.WithMetadata("max_quantity", f.MaxQuantity.String()),
+		)
+	}
+
+	if price.LessThan(f.MinPrice) || (f.MaxPrice.IsPositive() && price.GreaterThan(f.MaxPrice)) {
+		return errors.New(
+			ErrInvalid,
+			"price out of range",
+			errors.WithMetadata("symbol", symbol),
+			errors.WithMetadata("price", price.String()),
+			errors.WithMetadata("min_price", f.MinPrice.String()),
+			errors.WithMetadata("max_price", f.MaxPrice.String()),
+		)
+	}
+
+	if notional := quantity.Mul(price); notional.LessThan(f.MinNotional) {
+		return errors.New(
+			ErrInvalid,
+			"order below the minimum notional",
+			errors.WithMetadata("symbol", symbol),
+			errors.WithMetadata("notional", notional.String()),
+			errors.WithMetadata("min_notional", f.MinNotional.String()),
+		)
+	}
+
+	return nil
+}
+
+func floorTo(value decimal.Decimal, step decimal.Decimal) decimal.Decimal {
+	if !step.IsPositive() {
+		return value
+	}
+
+	return value.Div(step).Floor().Mul(step)
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSymbolFilters(t *testing.T) *SymbolFilters {
+	filters, err := NewSymbolFilters(
+		"BTC",
+		"USDT",
+		decimal.RequireFromString("0.01"),
+		decimal.RequireFromString("0.01"),
+		decimal.NewFromInt(1000000),
+		decimal.RequireFromString("0.00001"),
+		decimal.RequireFromString("0.0001"),
+		decimal.NewFromInt(9000),
+		decimal.NewFromInt(5),
+	)
+	assert.NoError(t, err)
+	return filters
+}
+
+func TestSymbolFiltersRounding(t *testing.T) {
+	filters := newTestSymbolFilters(t)
+
+	for _, tt := range []struct {
+		value     string
+		quantity  string
+		priceDown string
+		priceUp   string
+	}{
+		{"0.123456789", "0.12345", "0.12", "0.13"},
+		{"50000.015", "50000.015", "50000.01", "50000.02"},
+		{"50000.01", "50000.01", "50000.01", "50000.01"},
+		{"0.000019", "0.00001", "0", "0.01"},
+		{"0.000009", "0", "0", "0.01"},
+	} {
+		value := decimal.RequireFromString(tt.value)
+		assert.Equal(t, tt.quantity, filters.RoundQuantity(value).String(), tt.value)
+		assert.Equal(t, tt.priceDown, filters.RoundPriceDown(value).String(), tt.value)
+		assert.Equal(t, tt.priceUp, filters.RoundPriceUp(value).String(), tt.value)
+	}
+
+	/** Without the filters nothing is rounded */
+	none := &SymbolFilters{BaseCurrency: "BTC", QuoteCurrency: "USDT"}
+	value := decimal.RequireFromString("0.123456789")
+	assert.Equal(t, value, none.RoundQuantity(value))
+	assert.Equal(t, value, none.RoundPriceDown(value))
+	assert.Equal(t, value, none.RoundPriceUp(value))
+}
+
+func TestSymbolFiltersValidate(t *testing.T) {
+	filters := newTestSymbolFilters(t)
+
+	/** Exactly at the minimum quantity and notional */
+	assert.NoError(t, filters.Validate(decimal.RequireFromString("0.0001"), decimal.NewFromInt(50000)))
+	assert.NoError(t, filters.Validate(decimal.RequireFromString("0.001"), decimal.NewFromInt(5000)))
+
+	for _, tt := range []struct {
+		name     string
+		quantity string
+		price    string
+	}{
+		{"zero quantity", "0", "50000"},
+		{"below min quantity", "0.00009", "100000"},
+		{"above max quantity", "9000.1", "1"},
+		{"below min price", "1000", "0.001"},
+		{"above max price", "0.001", "1000001"},
+		{"below min notional", "0.0001", "49999.99"},
+	} {
+		err := filters.Validate(decimal.RequireFromString(tt.quantity), decimal.RequireFromString(tt.price))
+		assert.True(t, errors.Is(err, ErrInvalid), tt.name)
+	}
+}
+
+func TestNewSymbolFilters(t *testing.T) {
+	_, err := NewSymbolFilters("", "USDT", decimal.Zero, decimal.Zero, decimal.Zero, decimal.Zero, decimal.Zero, decimal.Zero, decimal.Zero)
+	assert.True(t, errors.Is(err, ErrInvalid))
+
+	_, err = NewSymbolFilters("BTC", "USDT", decimal.NewFromInt(-1), decimal.Zero, decimal.Zero, decimal.Zero, decimal.Zero, decimal.Zero, decimal.Zero)
+	assert.True(t, errors.Is(err, ErrInvalid))
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSymbol(t *testing.T) {
+	for _, tt := range []struct {
+		symbol  string
+		base    string
+		quote   string
+		binance string
+	}{
+		{"BTC-USDT", "BTC", "USDT", "BTCUSDT"},
+		{"btc-usdt", "BTC", "USDT", "BTCUSDT"},
+		{" eth - btc ", "ETH", "BTC", "ETHBTC"},
+		{"1000SATS-FDUSD", "1000SATS", "FDUSD", "1000SATSFDUSD"},
+	} {
+		symbol, err := ParseSymbol(tt.symbol)
+		assert.NoError(t, err, tt.symbol)
+		assert.Equal(t, Symbol{Base: tt.base, Quote: tt.quote}, symbol, tt.symbol)
+		assert.Equal(t, tt.base+"-"+tt.quote, symbol.String(), tt.symbol)
+		assert.Equal(t, tt.binance, symbol.Binance(), tt.symbol)
+
+		/** The stored form parses back to the same pair */
+		parsed, err := ParseSymbol(symbol.String())
+		assert.NoError(t, err, tt.symbol)
+		assert.Equal(t, symbol, parsed, tt.symbol)
+	}
+
+	for _, symbol := range []string{
+		/** Without a separator the assets can't be told apart */
+		"BTCUSDT",
+		"",
+		"-USDT",
+		"BTC-",
+		"-",
+		"BTC-USDT-ETH",
+		"BTC/USDT-ETH",
+		"USDT-usdt",
+	} {
+		_, err := ParseSymbol(symbol)
+		assert.True(t, errors.Is(err, ErrInvalid), symbol)
+	}
+}
+
+func TestNewSymbol(t *testing.T) {
+	symbol, err := NewSymbol("btc", "usdt")
+	assert.NoError(t, err)
+	assert.Equal(t, "BTC-USDT", symbol.String())
+
+	for _, assets := range [][2]string{{"", "USDT"}, {"BTC", ""}, {"BTC", "BTC"}, {"BT C", "USDT"}} {
+		_, err := NewSymbol(assets[0], assets[1])
+		assert.True(t, errors.Is(err, ErrInvalid), assets[0]+"-"+assets[1])
+	}
+}
@@ -1,18 +1,661 @@
 package bots
 
 import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/application"
+	"github.com/juankohler/crypto-bot/bots/domain"
 	"github.com/juankohler/crypto-bot/common"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/http/server"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+	"github.com/shopspring/decimal"
 )
 
-// var errorsToCode = map[error]int{
-// 	domain.ErrInternal: http.StatusInternalServerError,
-// 	domain.ErrNotFound: http.StatusNotFound,
-// 	domain.ErrInvalid:  http.StatusBadRequest,
-// }
+var errorsToCode = map[error]int{
+	domain.ErrInternal: http.StatusInternalServerError,
+	domain.ErrNotFound: http.StatusNotFound,
+	domain.ErrInvalid:  http.StatusBadRequest,
+	domain.ErrConflict: http.StatusConflict,
+}
 
 type Handlers struct {
+	createBot    *application.CreateBot
+	listBots     *application.ListBots
+	getBot       *application.GetBot
+	pauseBot     *application.PauseBot
+	resumeBot    *application.ResumeBot
+	deleteBot    *application.DeleteBot
+	getCandles   *application.GetCandles
+	botRunner    *application.BotRunner
+	getPnL       *application.GetPnL
+	getBotPnL    *application.GetBotPnL
+	getBotEquity *application.GetBotEquity
 }
 
 func NewHandlers(cfg *common.Config, deps *Dependencies) *Handlers {
-	return &Handlers{}
+	return &Handlers{
+		createBot:    deps.CreateBot,
+		listBots:     deps.ListBots,
+		getBot:       deps.GetBot,
+		pauseBot:     deps.PauseBot,
+		resumeBot:    deps.ResumeBot,
+		deleteBot:    deps.DeleteBot,
+		getCandles:   deps.GetCandles,
+		botRunner:    deps.BotRunner,
+		getPnL:       deps.GetPnL,
+		getBotPnL:    deps.GetBotPnL,
+		getBotEquity: deps.GetBotEquity,
+	}
+}
+
+func (h *Handlers) CreateBot(w http.ResponseWriter, r *http.Request) {
+	var input application.CreateBotInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		server.RenderErrorResponse(w, r, errors.Wrap(domain.ErrInvalid, err, "invalid body"), errorsToCode)
+		return
+	}
+
+	bot, err := h.createBot.Exec(r.Context(), &input)
+	if err != nil {
+		server.RenderErrorResponse(w, r, err, errorsToCode)
+		return
+	}
+
+	server.RenderReponse(w, r, newBotResponse(bot), http.StatusCreated)
+}
+
+func (h *Handlers) ListBots(w http.ResponseWriter, r *http.Request) {
+	input := application.ListBotsInput{
+		Cursor: r.URL.Query().Get("cursor"),
+	}
+
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil {
+			server.RenderErrorResponse(w, r, errors.Wrap(domain.ErrInvalid, err, "invalid limit"), errorsToCode)
+			return
+		}
+		input.Limit = value
+	}
+
+	page, err := h.listBots.Exec(r.Context(), &input)
+	if err != nil {
+		server.RenderErrorResponse(w, r, err, errorsToCode)
+		return
+	}
+
+	items := make([]BotResponse, 0, len(page.Items))
+	for _, bot := range page.Items {
+		items = append(items, newBotResponse(bot))
+	}
+
+	server.RenderReponse(w, r, models.Page[BotResponse]{
+		Items:  items,
+		Count:  page.Count,
+		Total:  page.Total,
+		Cursor: page.Cursor,
+	}, http.StatusOK)
+}
+
+func (h *Handlers) GetBot(w http.ResponseWriter, r *http.Request) {
+	bot, err := h.getBot.Exec(r.Context(), &application.GetBotInput{
+		ID: models.ID(r.PathValue("id")),
+	})
+	if err != nil {
+		server.RenderErrorResponse(w, r, err, errorsToCode)
+		return
+	}
+
+	server.RenderReponse(w, r, newBotResponse(bot), http.StatusOK)
+}
+
+func (h *Handlers) PauseBot(w http.ResponseWriter, r *http.Request) {
+	bot, err := h.pauseBot.Exec(r.Context(), &application.PauseBotInput{
+		ID: models.ID(r.PathValue("id")),
+	})
+	if err != nil {
+		server.RenderErrorResponse(w, r, err, errorsToCode)
+		return
+	}
+
+	server.RenderReponse(w, r, newBotResponse(bot), http.StatusOK)
+}
+
+func (h *Handlers) ResumeBot(w http.ResponseWriter, r *http.Request) {
+	bot, err := h.resumeBot.Exec(r.Context(), &application.ResumeBotInput{
+		ID: models.ID(r.PathValue("id")),
+	})
+	if err != nil {
+		server.RenderErrorResponse(w, r, err, errorsToCode)
+		return
+	}
+
+	server.RenderReponse(w, r, newBotResponse(bot), http.StatusOK)
+}
+
+func (h *Handlers) DeleteBot(w http.ResponseWriter, r *http.Request) {
+	err := h.deleteBot.Exec(r.Context(), &application.DeleteBotInput{
+		ID: models.ID(r.PathValue("id")),
+	})
+	if err != nil {
+		server.RenderErrorResponse(w, r, err, errorsToCode)
+		return
+	}
+
+	server.RenderReponse(w, r, nil, http.StatusNoContent)
+}
+
+func (h *Handlers) ListBotWorkers(w http.ResponseWriter, r *http.Request) {
+	statuses := h.botRunner.Status()
+
+	items := make([]BotWorkerResponse, 0, len(statuses))
+	for _, status := range statuses {
+		items = append(items, newBotWorkerResponse(status))
+	}
+
+	server.RenderReponse(w, r, items, http.StatusOK)
+}
+
+func (h *Handlers) StartBotWorker(w http.ResponseWriter, r *http.Request) {
+	if err := h.botRunner.StartBot(r.Context(), models.ID(r.PathValue("id"))); err != nil {
+		server.RenderErrorResponse(w, r, err, errorsToCode)
+		return
+	}
+
+	server.RenderReponse(w, r, nil, http.StatusNoContent)
+}
+
+func (h *Handlers) StopBotWorker(w http.ResponseWriter, r *http.Request) {
+	if err := h.botRunner.StopBot(r.Context(), models.ID(r.PathValue("id"))); err != nil {
+		server.RenderErrorResponse(w, r, err, errorsToCode)
+		return
+	}
+
+	server.RenderReponse(w, r, nil, http.StatusNoContent)
+}
+
+func (h *Handlers) GetPnL(w http.ResponseWriter, r *http.Request) {
+	output, err := h.getPnL.Exec(r.Context(), &application.GetPnLInput{})
+	if err != nil {
+		server.RenderErrorResponse(w, r, err, errorsToCode)
+		return
+	}
+
+	server.RenderReponse(w, r, PnLSummaryResponse{
+		Bots:   newPnLResponses(output.Bots),
+		Totals: newPnLResponses(output.Totals),
+	}, http.StatusOK)
+}
+
+const (
+	// Snapshots returned when no range is given.
+	defaultPnLHistory = 7 * 24 * time.Hour
+)
+
+func (h *Handlers) GetBotPnL(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	input := application.GetBotPnLInput{
+		ID:  models.ID(r.PathValue("id")),
+		End: time.Now(),
+	}
+
+	var err error
+	if to := query.Get("to"); to != "" {
+		input.End, err = time.Parse(time.RFC3339, to)
+		if err != nil {
+			server.RenderErrorResponse(w, r, errors.Wrap(domain.ErrInvalid, err, "invalid to"), errorsToCode)
+			return
+		}
+	}
+
+	input.Start = input.End.Add(-defaultPnLHistory)
+	if from := query.Get("from"); from != "" {
+		input.Start, err = time.Parse(time.RFC3339, from)
+		if err != nil {
+			server.RenderErrorResponse(w, r, errors.Wrap(domain.ErrInvalid, err, "invalid from"), errorsToCode)
+			return
+		}
+	}
+
+	output, err := h.getBotPnL.Exec(r.Context(), &input)
+	if err != nil {
+		server.RenderErrorResponse(w, r, err, errorsToCode)
+		return
+	}
+
+	server.RenderReponse(w, r, BotPnLResponse{
+		Current: newPnLResponse(output.Current),
+		History: newPnLResponses(output.History),
+	}, http.StatusOK)
+}
+
+const (
+	// Maximum number of snapshots per request.
+	maxEquitySnapshotsPerRequest = 5000
+)
+
+func (h *Handlers) GetBotEquity(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	input := application.GetBotEquityInput{
+		ID:         models.ID(r.PathValue("id")),
+		Resolution: query.Get("resolution"),
+		End:        time.Now(),
+	}
+
+	if input.Resolution == "" {
+		input.Resolution = domain.EquityResolutionHour
+	}
+
+	duration, err := domain.EquityResolutionDuration(input.Resolution)
+	if err != nil {
+		server.RenderErrorResponse(w, r, err, errorsToCode)
+		return
+	}
+
+	if to := query.Get("to"); to != "" {
+		input.End, err = time.Parse(time.RFC3339, to)
+		if err != nil {
+			server.RenderErrorResponse(w, r, errors.Wrap(domain.ErrInvalid, err, "invalid to"), errorsToCode)
+			return
+		}
+	}
+
+	input.Start = input.End.Add(-100 * duration)
+	if from := query.Get("from"); from != "" {
+		input.Start, err = time.Parse(time.RFC3339, from)
+		if err != nil {
+			server.RenderErrorResponse(w, r, errors.Wrap(domain.ErrInvalid, err, "invalid from"), errorsToCode)
+			return
+		}
+	}
+
+	if input.End.Sub(input.Start) > maxEquitySnapshotsPerRequest*duration {
+		server.RenderErrorResponse(w, r, errors.New(
+			domain.ErrInvalid,
+			"too many snapshots requested",
+			errors.WithMetadata("max_snapshots", maxEquitySnapshotsPerRequest),
+		), errorsToCode)
+		return
+	}
+
+	output, err := h.getBotEquity.Exec(r.Context(), &input)
+	if err != nil {
+		server.RenderErrorResponse(w, r, err, errorsToCode)
+		return
+	}
+
+	items := make([]EquitySnapshotResponse, 0, len(output.Snapshots))
+	for _, snapshot := range output.Snapshots {
+		items = append(items, newEquitySnapshotResponse(snapshot))
+	}
+
+	server.RenderReponse(w, r, BotEquityResponse{
+		Resolution:  input.Resolution,
+		MaxDrawdown: output.MaxDrawdown,
+		Snapshots:   items,
+	}, http.StatusOK)
+}
+
+const (
+	// Maximum number of candles per request, to bound the backfill.
+	maxCandlesPerRequest = 5000
+)
+
+func (h *Handlers) GetCandles(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	input := application.GetCandlesInput{
+		Symbol:   query.Get("symbol"),
+		Interval: query.Get("interval"),
+		End:      time.Now(),
+	}
+
+	if input.Interval == "" {
+		input.Interval = "1h"
+	}
+
+	duration, err := domain.CandleIntervalDuration(input.Interval)
+	if err != nil {
+		server.RenderErrorResponse(w, r, err, errorsToCode)
+		return
+	}
+
+	if to := query.Get("to"); to != "" {
+		input.End, err = time.Parse(time.RFC3339, to)
+		if err != nil {
+			server.RenderErrorResponse(w, r, errors.Wrap(domain.ErrInvalid, err, "invalid to"), errorsToCode)
+			return
+		}
+	}
+
+	input.Start = input.End.Add(-100 * duration)
+	if from := query.Get("from"); from != "" {
+		input.Start, err = time.Parse(time.RFC3339, from)
+		if err != nil {
+			server.RenderErrorResponse(w, r, errors.Wrap(domain.ErrInvalid, err, "invalid from"), errorsToCode)
+			return
+		}
+	}
+
+	if input.End.Sub(input.Start) > maxCandlesPerRequest*duration {
+		server.RenderErrorResponse(w, r, errors.New(
+			domain.ErrInvalid,
+			"too many candles requested",
+			errors.WithMetadata("max_candles", maxCandlesPerRequest),
+		), errorsToCode)
+		return
+	}
+
+	candles, err := h.getCandles.Exec(r.Context(), &input)
+	if err != nil {
+		server.RenderErrorResponse(w, r, err, errorsToCode)
+		return
+	}
+
+	items := make([]CandleResponse, 0, len(candles))
+	for _, candle := range candles {
+		items = append(items, newCandleResponse(candle))
+	}
+
+	server.RenderReponse(w, r, items, http.StatusOK)
+}
+
+/** Responses */
+type BotResponse struct {
+	ID                   models.ID              `json:"id"`
+	Name                 string                 `json:"name"`
+	Status               string                 `json:"status"`
+	Mode                 string                 `json:"mode"`
+	Currency             string                 `json:"currency"`
+	TargetCurrency       string                 `json:"target_currency"`
+	Symbol               string                 `json:"symbol"`
+	TakeProfitPercentaje decimal.Decimal        `json:"take_profit_percentaje"`
+	InitialCapital       decimal.Decimal        `json:"initial_capital"`
+	AvailableCapital     decimal.Decimal        `json:"available_capital"`
+	InvestedCapital      decimal.Decimal        `json:"invested_capital"`
+	TotalCapital         decimal.Decimal        `json:"total_capital"`
+	Delta                decimal.Decimal        `json:"delta"`
+	MonitorInterval      string                 `json:"monitor_interval"`
+	Strategy             string                 `json:"strategy"`
+	StrategyParams       domain.StrategyParams  `json:"strategy_params"`
+	LastSalePrice        *decimal.Decimal       `json:"last_sale_price"`
+	RealizedPnL          decimal.Decimal        `json:"realized_pnl"`
+	FeesPaid             decimal.Decimal        `json:"fees_paid"`
+	StopLoss             *StopLossResponse      `json:"stop_loss"`
+	AdaptiveDelta        *AdaptiveDeltaResponse `json:"adaptive_delta"`
+	OpenOrders           []OrderResponse        `json:"open_orders"`
+	Timestamps           models.Timestamps      `json:"timestamps"`
+	Version              models.Version         `json:"version"`
+}
+
+type OrderResponse struct {
+	ID                  models.ID         `json:"id"`
+	Symbol              string            `json:"symbol"`
+	Side                string            `json:"side"`
+	Type                string            `json:"type"`
+	Status              string            `json:"status"`
+	Quantity            decimal.Decimal   `json:"quantity"`
+	InitialQuoteAmount  decimal.Decimal   `json:"initial_quote_amount"`
+	FinalQuoteAmount    decimal.Decimal   `json:"final_quote_amount"`
+	EntryPrice          decimal.Decimal   `json:"entry_price"`
+	TakeProfitPrice     decimal.Decimal   `json:"take_profit_price"`
+	ExecutedQuantity    decimal.Decimal   `json:"executed_quantity"`
+	ExecutedQuoteAmount decimal.Decimal   `json:"executed_quote_amount"`
+	Fee                 decimal.Decimal   `json:"fee"`
+	FeeCurrency         string            `json:"fee_currency"`
+	FeeQuoteAmount      decimal.Decimal   `json:"fee_quote_amount"`
+	RealizedPnL         decimal.Decimal   `json:"realized_pnl"`
+	StopLossPrice       decimal.Decimal   `json:"stop_loss_price"`
+	ExitReason          string            `json:"exit_reason,omitempty"`
+	ExternalId          *string           `json:"external_id"`
+	PositionID          *models.ID        `json:"position_id,omitempty"`
+	PriceRange          int               `json:"price_range"`
+	Delta               decimal.Decimal   `json:"delta"`
+	TakeProfitOrder     *OrderResponse    `json:"take_profit_order"`
+	Timestamps          models.Timestamps `json:"timestamps"`
+}
+
+type StopLossResponse struct {
+	Type     string          `json:"type"`
+	Value    decimal.Decimal `json:"value"`
+	Trailing bool            `json:"trailing"`
+}
+
+type AdaptiveDeltaResponse struct {
+	Interval   string          `json:"interval"`
+	Period     int             `json:"period"`
+	Multiplier decimal.Decimal `json:"multiplier"`
+	MinDelta   decimal.Decimal `json:"min_delta"`
+	MaxDelta   decimal.Decimal `json:"max_delta"`
+	Hysteresis decimal.Decimal `json:"hysteresis"`
+}
+
+func newBotResponse(bot *domain.Bot) BotResponse {
+	openOrders := make([]OrderResponse, 0, len(bot.OpenOrders))
+	for _, order := range bot.OpenOrders {
+		openOrders = append(openOrders, newOrderResponse(order))
+	}
+
+	var stopLoss *StopLossResponse
+	if bot.StopLoss != nil {
+		stopLoss = &StopLossResponse{
+			Type:     bot.StopLoss.Type,
+			Value:    bot.StopLoss.Value,
+			Trailing: bot.StopLoss.Trailing,
+		}
+	}
+
+	var adaptiveDelta *AdaptiveDeltaResponse
+	if bot.AdaptiveDelta != nil {
+		adaptiveDelta = &AdaptiveDeltaResponse{
+			Interval:   bot.AdaptiveDelta.Interval,
+			Period:     bot.AdaptiveDelta.Period,
+			Multiplier: bot.AdaptiveDelta.Multiplier,
+			MinDelta:   bot.AdaptiveDelta.MinDelta,
+			MaxDelta:   bot.AdaptiveDelta.MaxDelta,
+			Hysteresis: bot.AdaptiveDelta.Hysteresis,
+		}
+	}
+
+	return BotResponse{
+		ID:                   bot.ID,
+		Name:                 bot.Name,
+		Status:               bot.Status,
+		Mode:                 bot.Mode,
+		Currency:             bot.Currency,
+		TargetCurrency:       bot.TargetCurrency,
+		Symbol:               bot.Symbol().String(),
+		TakeProfitPercentaje: bot.TakeProfitPercentaje,
+		InitialCapital:       bot.InitialCapital,
+		AvailableCapital:     bot.AvailableCapital,
+		InvestedCapital:      bot.InvestedCapital,
+		TotalCapital:         bot.TotalCapital,
+		Delta:                bot.Delta,
+		MonitorInterval:      bot.MonitorInterval.Round(time.Millisecond).String(),
+		Strategy:             bot.Strategy,
+		StrategyParams:       bot.StrategyParams,
+		LastSalePrice:        bot.LastSalePrice,
+		RealizedPnL:          bot.RealizedPnL,
+		FeesPaid:             bot.FeesPaid,
+		StopLoss:             stopLoss,
+		AdaptiveDelta:        adaptiveDelta,
+		OpenOrders:           openOrders,
+		Timestamps:           bot.Timestamps,
+		Version:              bot.Version,
+	}
+}
+
+func newOrderResponse(order *domain.Order) OrderResponse {
+	var takeProfitOrder *OrderResponse
+	if order.TakeProfitOrder != nil {
+		response := newOrderResponse(order.TakeProfitOrder)
+		takeProfitOrder = &response
+	}
+
+	return OrderResponse{
+		ID:                  order.ID,
+		Symbol:              order.Symbol,
+		Side:                order.Side,
+		Type:                order.Type,
+		Status:              order.Status,
+		Quantity:            order.Quantity,
+		InitialQuoteAmount:  order.InitialQuoteAmount,
+		FinalQuoteAmount:    order.FinalQuoteAmount,
+		EntryPrice:          order.EntryPrice,
+		TakeProfitPrice:     order.TakeProfitPrice,
+		ExecutedQuantity:    order.ExecutedQuantity,
+		ExecutedQuoteAmount: order.ExecutedQuoteAmount,
+		Fee:                 order.Fee,
+		FeeCurrency:         order.FeeCurrency,
+		FeeQuoteAmount:      order.FeeQuoteAmount,
+		RealizedPnL:         order.RealizedPnL,
+		StopLossPrice:       order.StopLossPrice,
+		ExitReason:          order.ExitReason,
+		ExternalId:          order.ExternalId,
+		PositionID:          order.PositionID,
+		PriceRange:          order.PriceRange,
+		Delta:               order.Delta,
+		TakeProfitOrder:     takeProfitOrder,
+		Timestamps:          order.Timestamps,
+	}
+}
+
+type CandleResponse struct {
+	Symbol    string          `json:"symbol"`
+	Interval  string          `json:"interval"`
+	OpenTime  time.Time       `json:"open_time"`
+	CloseTime time.Time       `json:"close_time"`
+	Open      decimal.Decimal `json:"open"`
+	High      decimal.Decimal `json:"high"`
+	Low       decimal.Decimal `json:"low"`
+	Close     decimal.Decimal `json:"close"`
+	Volume    decimal.Decimal `json:"volume"`
+}
+
+func newCandleResponse(candle *domain.Candle) CandleResponse {
+	return CandleResponse{
+		Symbol:    candle.Symbol,
+		Interval:  candle.Interval,
+		OpenTime:  candle.OpenTime,
+		CloseTime: candle.CloseTime,
+		Open:      candle.Open,
+		High:      candle.High,
+		Low:       candle.Low,
+		Close:     candle.Close,
+		Volume:    candle.Volume,
+	}
+}
+
+type PnLResponse struct {
+	BotID          *models.ID       `json:"bot_id,omitempty"`
+	Currency       string           `json:"currency"`
+	Time           time.Time        `json:"time"`
+	Price          *decimal.Decimal `json:"price,omitempty"`
+	InitialCapital decimal.Decimal  `json:"initial_capital"`
+	RealizedPnL    decimal.Decimal  `json:"realized_pnl"`
+	UnrealizedPnL  decimal.Decimal  `json:"unrealized_pnl"`
+	TotalPnL       decimal.Decimal  `json:"total_pnl"`
+	Equity         decimal.Decimal  `json:"equity"`
+	FeesPaid       decimal.Decimal  `json:"fees_paid"`
+	ROI            decimal.Decimal  `json:"roi"`
+}
+
+type PnLSummaryResponse struct {
+	Bots   []PnLResponse `json:"bots"`
+	Totals []PnLResponse `json:"totals"`
+}
+
+type BotPnLResponse struct {
+	Current PnLResponse   `json:"current"`
+	History []PnLResponse `json:"history"`
+}
+
+func newPnLResponse(pnl *domain.PnL) PnLResponse {
+	response := PnLResponse{
+		Currency:       pnl.Currency,
+		Time:           pnl.Time,
+		InitialCapital: pnl.InitialCapital,
+		RealizedPnL:    pnl.RealizedPnL,
+		UnrealizedPnL:  pnl.UnrealizedPnL,
+		TotalPnL:       pnl.TotalPnL(),
+		Equity:         pnl.Equity,
+		FeesPaid:       pnl.FeesPaid,
+		ROI:            pnl.ROI,
+	}
+
+	/** Totals are not of a bot nor a pair */
+	if pnl.BotID != "" {
+		botID := pnl.BotID
+		price := pnl.Price
+		response.BotID = &botID
+		response.Price = &price
+	}
+
+	return response
+}
+
+func newPnLResponses(pnls []*domain.PnL) []PnLResponse {
+	items := make([]PnLResponse, 0, len(pnls))
+	for _, pnl := range pnls {
+		items = append(items, newPnLResponse(pnl))
+	}
+
+	return items
+}
+
+type EquitySnapshotResponse struct {
+	Time             time.Time       `json:"time"`
+	AvailableCapital decimal.Decimal `json:"available_capital"`
+	InvestedCapital  decimal.Decimal `json:"invested_capital"`
+	TotalCapital     decimal.Decimal `json:"total_capital"`
+	MarkToMarket     decimal.Decimal `json:"mark_to_market"`
+	LowMarkToMarket  decimal.Decimal `json:"low_mark_to_market"`
+	HighMarkToMarket decimal.Decimal `json:"high_mark_to_market"`
+	OpenOrders       int             `json:"open_orders"`
+}
+
+type BotEquityResponse struct {
+	Resolution  string                   `json:"resolution"`
+	MaxDrawdown decimal.Decimal          `json:"max_drawdown"`
+	Snapshots   []EquitySnapshotResponse `json:"snapshots"`
+}
+
+func newEquitySnapshotResponse(snapshot *domain.EquitySnapshot) EquitySnapshotResponse {
+	return EquitySnapshotResponse{
+		Time:             snapshot.Time,
+		AvailableCapital: snapshot.AvailableCapital,
+		InvestedCapital:  snapshot.InvestedCapital,
+		TotalCapital:     snapshot.TotalCapital,
+		MarkToMarket:     snapshot.MarkToMarket,
+		LowMarkToMarket:  snapshot.LowMarkToMarket,
+		HighMarkToMarket: snapshot.HighMarkToMarket,
+		OpenOrders:       snapshot.OpenOrders,
+	}
+}
+
+type BotWorkerResponse struct {
+	BotID         models.ID  `json:"bot_id"`
+	BotName       string     `json:"bot_name"`
+	State         string     `json:"state"`
+	StartedAt     time.Time  `json:"started_at"`
+	LastExecution *time.Time `json:"last_execution"`
+	NextExecution *time.Time `json:"next_execution"`
+	Restarts      int        `json:"restarts"`
+	LastError     *string    `json:"last_error"`
+}
+
+func newBotWorkerResponse(status application.BotWorkerStatus) BotWorkerResponse {
+	return BotWorkerResponse{
+		BotID:         status.BotID,
+		BotName:       status.BotName,
+		State:         status.State,
+		StartedAt:     status.StartedAt,
+		LastExecution: status.LastExecution,
+		NextExecution: status.NextExecution,
+		Restarts:      status.Restarts,
+		LastError:     status.LastError,
+	}
 }
@@ -0,0 +1,202 @@
+package bots
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/juankohler/crypto-bot/bots/application"
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/bots/infrastructure"
+	"github.com/juankohler/crypto-bot/database"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/migrations"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSymbolValidator struct{}
+
+func (v fakeSymbolValidator) ValidateSymbol(ctx context.Context, symbol domain.Symbol) error {
+	if symbol.Base == "FOO" {
+		return errors.New(domain.ErrNotFound, "symbol not found")
+	}
+
+	return nil
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	db, err := sqlx.Connect("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	/** Every connection to :memory: is a different database */
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db, database.Migrations())
+	assert.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	assert.NoError(t, err)
+
+	botRepo, err := infrastructure.NewSQLiteBotRepo(db)
+	assert.NoError(t, err)
+	strategies, err := newStrategies()
+	assert.NoError(t, err)
+
+	mux := http.NewServeMux()
+	registerRoutes(mux, NewHandlers(nil, &Dependencies{
+		CreateBot: application.NewCreateBot(botRepo, strategies, fakeSymbolValidator{}),
+		ListBots:  application.NewListBots(botRepo),
+		GetBot:    application.NewGetBot(botRepo),
+		PauseBot:  application.NewPauseBot(botRepo),
+		ResumeBot: application.NewResumeBot(botRepo),
+		DeleteBot: application.NewDeleteBot(botRepo),
+	}))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func doRequest(t *testing.T, method string, url string, body string, response any) int {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	assert.NoError(t, err)
+
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+
+	if response != nil && res.StatusCode != http.StatusNoContent {
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(response))
+	}
+
+	return res.StatusCode
+}
+
+func createBotBody(name string, targetCurrency string, strategyParams string) string {
+	return fmt.Sprintf(`{
+		"name": %q,
+		"currency": "usdt",
+		"target_currency": %q,
+		"take_profit_percentaje": "0.01",
+		"initial_capital": "1000",
+		"delta": "100",
+		"monitor_interval": "1m",
+		"strategy": "GRID",
+		"strategy_params": %s,
+		"mode": "paper"
+	}`, name, targetCurrency, strategyParams)
+}
+
+func TestCreateBotHandler(t *testing.T) {
+	server := newTestServer(t)
+
+	var bot BotResponse
+	status := doRequest(t, "POST", server.URL+"/v1/bots", createBotBody("grid", "btc", `{"orders": "10"}`), &bot)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "grid", bot.Name)
+	assert.Equal(t, "BTC-USDT", bot.Symbol)
+	assert.Equal(t, domain.BotStatusActive, bot.Status)
+	assert.Equal(t, domain.BotModePaper, bot.Mode)
+
+	var stored BotResponse
+	status = doRequest(t, "GET", server.URL+"/v1/bots/"+bot.ID.String(), "", &stored)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, bot.ID, stored.ID)
+
+	for name, body := range map[string]string{
+		"malformed body":   `{"name": `,
+		"missing name":     createBotBody("", "btc", `{}`),
+		"invalid params":   createBotBody("grid", "btc", `{"orders": "0"}`),
+		"same assets":      createBotBody("grid", "usdt", `{}`),
+		"unknown symbol":   createBotBody("grid", "foo", `{}`),
+		"invalid interval": strings.Replace(createBotBody("grid", "btc", `{}`), `"1m"`, `"soon"`, 1),
+		"unknown strategy": strings.Replace(createBotBody("grid", "btc", `{}`), `"GRID"`, `"MOON"`, 1),
+	} {
+		var response map[string]string
+		status := doRequest(t, "POST", server.URL+"/v1/bots", body, &response)
+		assert.Equal(t, http.StatusBadRequest, status, name)
+		assert.Equal(t, domain.ErrInvalid.String(), response["code"], name)
+	}
+}
+
+func TestBotLifecycleHandlers(t *testing.T) {
+	server := newTestServer(t)
+
+	var bot BotResponse
+	doRequest(t, "POST", server.URL+"/v1/bots", createBotBody("grid", "btc", `{}`), &bot)
+	url := server.URL + "/v1/bots/" + bot.ID.String()
+
+	var response map[string]string
+	for _, path := range []string{"", "/pause", "/resume"} {
+		method := "POST"
+		if path == "" {
+			method = "GET"
+		}
+
+		status := doRequest(t, method, server.URL+"/v1/bots/unknown"+path, "", &response)
+		assert.Equal(t, http.StatusNotFound, status, path)
+		assert.Equal(t, domain.ErrNotFound.String(), response["code"], path)
+	}
+	assert.Equal(t, http.StatusNotFound, doRequest(t, "DELETE", server.URL+"/v1/bots/unknown", "", &response))
+
+	var paused BotResponse
+	assert.Equal(t, http.StatusOK, doRequest(t, "POST", url+"/pause", "", &paused))
+	assert.Equal(t, domain.BotStatusPaused, paused.Status)
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, "POST", url+"/pause", "", &response))
+
+	var resumed BotResponse
+	assert.Equal(t, http.StatusOK, doRequest(t, "POST", url+"/resume", "", &resumed))
+	assert.Equal(t, domain.BotStatusActive, resumed.Status)
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, "POST", url+"/resume", "", &response))
+
+	assert.Equal(t, http.StatusNoContent, doRequest(t, "DELETE", url, "", nil))
+	assert.Equal(t, http.StatusNotFound, doRequest(t, "GET", url, "", &response))
+	assert.Equal(t, http.StatusNotFound, doRequest(t, "DELETE", url, "", &response))
+}
+
+func TestListBotsHandler(t *testing.T) {
+	server := newTestServer(t)
+
+	for i := 0; i < 3; i++ {
+		status := doRequest(t, "POST", server.URL+"/v1/bots", createBotBody(fmt.Sprintf("grid-%d", i), "btc", `{}`), nil)
+		assert.Equal(t, http.StatusCreated, status)
+	}
+
+	type page struct {
+		Items  []BotResponse `json:"items"`
+		Count  int           `json:"count"`
+		Total  int           `json:"total"`
+		Cursor *string       `json:"cursor"`
+	}
+
+	var all page
+	assert.Equal(t, http.StatusOK, doRequest(t, "GET", server.URL+"/v1/bots", "", &all))
+	assert.Len(t, all.Items, 3)
+	assert.Equal(t, 3, all.Total)
+	assert.Nil(t, all.Cursor)
+
+	var first page
+	assert.Equal(t, http.StatusOK, doRequest(t, "GET", server.URL+"/v1/bots?limit=2", "", &first))
+	assert.Len(t, first.Items, 2)
+	assert.Equal(t, 3, first.Total)
+	assert.NotNil(t, first.Cursor)
+
+	var second page
+	assert.Equal(t, http.StatusOK, doRequest(t, "GET", server.URL+"/v1/bots?cursor="+*first.Cursor, "", &second))
+	assert.Len(t, second.Items, 1)
+	assert.Equal(t, "grid-2", second.Items[0].Name)
+	assert.Nil(t, second.Cursor)
+
+	for _, query := range []string{"limit=two", "limit=101", "limit=-1", "cursor=invalid"} {
+		var response map[string]string
+		status := doRequest(t, "GET", server.URL+"/v1/bots?"+query, "", &response)
+		assert.Equal(t, http.StatusBadRequest, status, query)
+		assert.Equal(t, domain.ErrInvalid.String(), response["code"], query)
+	}
+}
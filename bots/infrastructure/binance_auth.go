@@ -0,0 +1,140 @@
+package infrastructure
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/restclient"
+)
+
+const (
+	binanceApiKeyHeader      = "X-MBX-APIKEY"
+	binanceDefaultRecvWindow = 5000
+	binanceTimeSyncInterval  = 30 * time.Minute
+)
+
+type BinanceCredentials struct {
+	ApiKey       string
+	SecretKey    string
+	RecvWindowMs int
+}
+
+// binanceSigner signs requests for the USER_DATA and TRADE endpoints, keeping
+// the local clock in sync with the server time so timestamps are accepted.
+type binanceSigner struct {
+	credentials        BinanceCredentials
+	serverTimeEndpoint restclient.Endpoint
+
+	mu         sync.Mutex
+	timeOffset time.Duration
+	syncedAt   time.Time
+}
+
+func newBinanceSigner(credentials BinanceCredentials, serverTimeEndpoint restclient.Endpoint) *binanceSigner {
+	if credentials.RecvWindowMs <= 0 {
+		credentials.RecvWindowMs = binanceDefaultRecvWindow
+	}
+
+	return &binanceSigner{
+		credentials:        credentials,
+		serverTimeEndpoint: serverTimeEndpoint,
+	}
+}
+
+// Sign returns the query string with timestamp, recvWindow and signature,
+// ready to be sent with restclient.RawQuery.
+func (s *binanceSigner) Sign(ctx context.Context, params url.Values) (string, error) {
+	if s.credentials.ApiKey == "" || s.credentials.SecretKey == "" {
+		return "", errors.New(domain.ErrInvalid, "missing binance api credentials")
+	}
+
+	offset, err := s.offset(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	timestamp := time.Now().Add(offset).UnixMilli()
+
+	signed := url.Values{}
+	for key, values := range params {
+		signed[key] = values
+	}
+	signed.Set("recvWindow", strconv.Itoa(s.credentials.RecvWindowMs))
+	signed.Set("timestamp", strconv.FormatInt(timestamp, 10))
+
+	query := signed.Encode()
+
+	return query + "&signature=" + s.signature(query), nil
+}
+
+func (s *binanceSigner) signature(payload string) string {
+	mac := hmac.New(sha256.New, []byte(s.credentials.SecretKey))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *binanceSigner) ApiKeyHeader() restclient.EndpointOption {
+	return restclient.Header(binanceApiKeyHeader, s.credentials.ApiKey)
+}
+
+// Invalidate forces a server time sync before the next signed request, used
+// when Binance rejects a timestamp outside the recvWindow.
+func (s *binanceSigner) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.syncedAt = time.Time{}
+}
+
+func (s *binanceSigner) offset(ctx context.Context) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.syncedAt.IsZero() && time.Since(s.syncedAt) < binanceTimeSyncInterval {
+		return s.timeOffset, nil
+	}
+
+	offset, err := s.syncServerTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	s.timeOffset = offset
+	s.syncedAt = time.Now()
+
+	return s.timeOffset, nil
+}
+
+type GetServerTimeResponse struct {
+	ServerTime int64 `json:"serverTime"`
+}
+
+func (s *binanceSigner) syncServerTime(ctx context.Context) (time.Duration, error) {
+	requestedAt := time.Now()
+
+	res := s.serverTimeEndpoint.DoRequest(ctx)
+	if res.Err() != nil {
+		return 0, errors.Wrap(domain.ErrInternal, res.Err(), "Failed to get server time.")
+	}
+
+	var respMsg GetServerTimeResponse
+	if err := json.Unmarshal(res.Body(), &respMsg); err != nil {
+		return 0, errors.Wrap(domain.ErrInternal, err, fmt.Sprintf("Failed to unmarshal server time. body: %s", string(res.Body())))
+	}
+
+	/** Assume the server time was taken halfway through the request */
+	latency := time.Since(requestedAt)
+	localTime := requestedAt.Add(latency / 2)
+
+	return time.UnixMilli(respMsg.ServerTime).Sub(localTime), nil
+}
@@ -4,23 +4,79 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/juankohler/crypto-bot/bots/domain"
 	"github.com/juankohler/crypto-bot/libs/go/errors"
 	"github.com/juankohler/crypto-bot/libs/go/logs"
-	"github.com/juankohler/crypto-bot/libs/go/models"
 	"github.com/juankohler/crypto-bot/libs/go/restclient"
 	"github.com/shopspring/decimal"
 )
 
+const (
+	// Timestamp for this request is outside of the recvWindow.
+	binanceErrCodeInvalidTimestamp = -1021
+	// Order does not exist.
+	binanceErrCodeNoSuchOrder = -2013
+
+	// Maximum number of candles returned by /v3/klines.
+	binanceKlinesLimit = 1000
+
+	// The filters of a symbol rarely change, so they are fetched again only
+	// after this long.
+	binanceExchangeInfoTTL = time.Hour
+
+	// Decimals Binance takes in the quote amount of a MARKET buy, the
+	// quoteAssetPrecision of every pair.
+	binanceQuotePrecision = 8
+
+	// Rate limits of Binance, the names of /v3/exchangeInfo.
+	BinanceRateLimitWeight = "REQUEST_WEIGHT"
+	BinanceRateLimitOrders = "ORDERS"
+	// Usage of the limits reported in every response.
+	BinanceWeightHeader     = "X-MBX-USED-WEIGHT-1M"
+	BinanceOrderCountHeader = "X-MBX-ORDER-COUNT-10S"
+)
+
 type repository struct {
-	getPriceEndpoint restclient.Endpoint
+	getPriceEndpoint        restclient.Endpoint
+	getPricesEndpoint       restclient.Endpoint
+	createOrderEndpoint     restclient.Endpoint
+	cancelOrderEndpoint     restclient.Endpoint
+	getOrderEndpoint        restclient.Endpoint
+	getTradesEndpoint       restclient.Endpoint
+	getKlinesEndpoint       restclient.Endpoint
+	getExchangeInfoEndpoint restclient.Endpoint
+	signer                  *binanceSigner
+	fees                    domain.FeeSchedule
+
+	filtersMu sync.Mutex
+	filters   map[string]cachedSymbolFilters
 }
 
-func NewBinanceRepo(config *restclient.Config) (*repository, error) {
+type cachedSymbolFilters struct {
+	filters   *domain.SymbolFilters
+	fetchedAt time.Time
+}
+
+// NewBinanceRepo creates the Binance repository. Every request goes through
+// the rate limiter with its weight, when one is given. The fee schedule values
+// the commissions of an order charged in several assets.
+func NewBinanceRepo(config *restclient.Config, credentials BinanceCredentials, fees domain.FeeSchedule, rateLimiter *restclient.RateLimiter) (*repository, error) {
 	client := restclient.New(*config)
 
+	limited := func(weights restclient.RateWeights, endpoint restclient.Endpoint) restclient.Endpoint {
+		if rateLimiter == nil {
+			return endpoint
+		}
+
+		return restclient.EndpointWithRateLimiter(rateLimiter, weights, endpoint)
+	}
+
 	failAtInternalErrorCodes := func(req restclient.Request, res restclient.Response) error {
 		if res.StatusCode() < 200 || res.StatusCode() >= 300 {
 			bodyString := string(res.Body())
@@ -37,11 +93,53 @@ func NewBinanceRepo(config *restclient.Config) (*repository, error) {
 	}
 
 	repo := &repository{
-		getPriceEndpoint: client.GET(
+		getPriceEndpoint: limited(restclient.RateWeights{BinanceRateLimitWeight: 2}, client.GET(
 			"/v3/ticker/price",
 			restclient.Header("content-type", "application/json"),
 			restclient.FailAt(failAtInternalErrorCodes),
+		)),
+		getPricesEndpoint: limited(restclient.RateWeights{BinanceRateLimitWeight: 4}, client.GET(
+			"/v3/ticker/price",
+			restclient.Header("content-type", "application/json"),
+			restclient.FailAt(failAtInternalErrorCodes),
+		)),
+		createOrderEndpoint: limited(restclient.RateWeights{BinanceRateLimitWeight: 1, BinanceRateLimitOrders: 1}, client.POST(
+			"/v3/order",
+			restclient.FailAt(failAtInternalErrorCodes),
+		)),
+		cancelOrderEndpoint: limited(restclient.RateWeights{BinanceRateLimitWeight: 1}, client.DELETE(
+			"/v3/order",
+			restclient.FailAt(failAtInternalErrorCodes),
+		)),
+		getOrderEndpoint: limited(restclient.RateWeights{BinanceRateLimitWeight: 4}, client.GET(
+			"/v3/order",
+			restclient.FailAt(failAtInternalErrorCodes),
+		)),
+		/** Always filtered by orderId */
+		getTradesEndpoint: limited(restclient.RateWeights{BinanceRateLimitWeight: 5}, client.GET(
+			"/v3/myTrades",
+			restclient.FailAt(failAtInternalErrorCodes),
+		)),
+		getKlinesEndpoint: limited(restclient.RateWeights{BinanceRateLimitWeight: 2}, client.GET(
+			"/v3/klines",
+			restclient.Header("content-type", "application/json"),
+			restclient.FailAt(failAtInternalErrorCodes),
+		)),
+		getExchangeInfoEndpoint: limited(restclient.RateWeights{BinanceRateLimitWeight: 20}, client.GET(
+			"/v3/exchangeInfo",
+			restclient.Header("content-type", "application/json"),
+			restclient.FailAt(failAtInternalErrorCodes),
+		)),
+		signer: newBinanceSigner(
+			credentials,
+			limited(restclient.RateWeights{BinanceRateLimitWeight: 1}, client.GET(
+				"/v3/time",
+				restclient.Header("content-type", "application/json"),
+				restclient.FailAt(failAtInternalErrorCodes),
+			)),
 		),
+		fees:    fees,
+		filters: make(map[string]cachedSymbolFilters),
 	}
 
 	return repo, nil
@@ -57,7 +155,7 @@ func (r *repository) GetPrice(ctx context.Context, baseCurrency string, quoteCur
 	var restclientOptions []restclient.EndpointOption
 	restclientOptions = append(
 		restclientOptions,
-		restclient.QueryParam("symbol", baseCurrency+quoteCurrency),
+		restclient.QueryParam("symbol", domain.Symbol{Base: baseCurrency, Quote: quoteCurrency}.Binance()),
 	)
 
 	/** Do request */
@@ -91,7 +189,527 @@ func (r *repository) GetPrice(ctx context.Context, baseCurrency string, quoteCur
 	return entity, nil
 }
 
+// GetPrices fetches the price of many pairs in a single request, with the
+// symbols=[...] form of /v3/ticker/price, which weighs the same as two single
+// requests.
+func (r *repository) GetPrices(ctx context.Context, pairs []domain.CurrencyPair) ([]*domain.Price, error) {
+	if len(pairs) == 1 {
+		price, err := r.GetPrice(ctx, pairs[0].BaseCurrency, pairs[0].QuoteCurrency)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*domain.Price{price}, nil
+	}
+
+	bySymbol := make(map[string]domain.CurrencyPair, len(pairs))
+	symbols := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		symbol := domain.Symbol{Base: pair.BaseCurrency, Quote: pair.QuoteCurrency}.Binance()
+		if _, ok := bySymbol[symbol]; !ok {
+			symbols = append(symbols, symbol)
+		}
+		bySymbol[symbol] = pair
+	}
+
+	symbolsParam, err := json.Marshal(symbols)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "Failed to marshal symbols.")
+	}
+
+	res := r.getPricesEndpoint.DoRequest(
+		ctx,
+		restclient.QueryParam("symbols", string(symbolsParam)),
+	)
+	if res.Err() != nil {
+		/** An unknown symbol fails the whole request */
+		if res.StatusCode() == 400 {
+			return nil, errors.Wrap(domain.ErrNotFound, res.Err(), "Failed to do request.")
+		}
+
+		return nil, errors.Wrap(domain.ErrInternal, res.Err(), "Failed to do request.")
+	}
+
+	var respMsg []GetPriceResponse
+	if err := json.Unmarshal(res.Body(), &respMsg); err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, fmt.Sprintf("Failed to unmarshal items. body: %s", string(res.Body())))
+	}
+
+	prices := make([]*domain.Price, 0, len(respMsg))
+	for _, item := range respMsg {
+		pair, ok := bySymbol[item.Symbol]
+		if !ok {
+			continue
+		}
+
+		price, err := domain.NewPrice(pair.BaseCurrency, pair.QuoteCurrency, item.Price)
+		if err != nil {
+			return nil, errors.Wrap(domain.ErrInternal, err, fmt.Sprintf("failed to parse to entity. body: %s", string(res.Body())))
+		}
+		prices = append(prices, price)
+	}
+
+	return prices, nil
+}
+
+type ExchangeInfoResponse struct {
+	Symbols []ExchangeInfoSymbol `json:"symbols"`
+}
+
+type ExchangeInfoSymbol struct {
+	Symbol     string               `json:"symbol"`
+	Status     string               `json:"status"`
+	BaseAsset  string               `json:"baseAsset"`
+	QuoteAsset string               `json:"quoteAsset"`
+	Filters    []ExchangeInfoFilter `json:"filters"`
+}
+
+// ExchangeInfoFilter holds the fields of every filter type we use, the ones
+// that don't apply to its type are left empty.
+type ExchangeInfoFilter struct {
+	FilterType  string          `json:"filterType"`
+	TickSize    decimal.Decimal `json:"tickSize"`
+	MinPrice    decimal.Decimal `json:"minPrice"`
+	MaxPrice    decimal.Decimal `json:"maxPrice"`
+	StepSize    decimal.Decimal `json:"stepSize"`
+	MinQty      decimal.Decimal `json:"minQty"`
+	MaxQty      decimal.Decimal `json:"maxQty"`
+	MinNotional decimal.Decimal `json:"minNotional"`
+}
+
+// GetSymbolFilters fetches the PRICE_FILTER, LOT_SIZE and MIN_NOTIONAL (or
+// NOTIONAL) filters of a pair from /v3/exchangeInfo, cached per symbol.
+func (r *repository) GetSymbolFilters(ctx context.Context, baseCurrency string, quoteCurrency string) (*domain.SymbolFilters, error) {
+	symbol, err := domain.NewSymbol(baseCurrency, quoteCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	r.filtersMu.Lock()
+	cached, ok := r.filters[symbol.Binance()]
+	r.filtersMu.Unlock()
+	if ok && time.Since(cached.fetchedAt) <= binanceExchangeInfoTTL {
+		return cached.filters, nil
+	}
+
+	info, err := r.getExchangeInfo(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	var priceFilter, lotSize, notional ExchangeInfoFilter
+	for _, filter := range info.Filters {
+		switch filter.FilterType {
+		case "PRICE_FILTER":
+			priceFilter = filter
+		case "LOT_SIZE":
+			lotSize = filter
+		case "MIN_NOTIONAL", "NOTIONAL":
+			notional = filter
+		}
+	}
+
+	filters, err := domain.NewSymbolFilters(
+		baseCurrency,
+		quoteCurrency,
+		priceFilter.TickSize,
+		priceFilter.MinPrice,
+		priceFilter.MaxPrice,
+		lotSize.StepSize,
+		lotSize.MinQty,
+		lotSize.MaxQty,
+		notional.MinNotional,
+	)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "failed to parse to entity.", errors.WithMetadata("symbol", symbol.Binance()))
+	}
+
+	r.filtersMu.Lock()
+	r.filters[symbol.Binance()] = cachedSymbolFilters{filters: filters, fetchedAt: time.Now()}
+	r.filtersMu.Unlock()
+
+	return filters, nil
+}
+
+// ValidateSymbol checks that the pair is listed in /v3/exchangeInfo with the
+// TRADING status.
+func (r *repository) ValidateSymbol(ctx context.Context, symbol domain.Symbol) error {
+	info, err := r.getExchangeInfo(ctx, symbol)
+	if err != nil {
+		return err
+	}
+
+	if info.Status != "TRADING" {
+		return errors.New(
+			domain.ErrNotFound,
+			"symbol not trading",
+			errors.WithMetadata("symbol", symbol.Binance()),
+			errors.WithMetadata("status", info.Status),
+		)
+	}
+
+	return nil
+}
+
+func (r *repository) getExchangeInfo(ctx context.Context, symbol domain.Symbol) (*ExchangeInfoSymbol, error) {
+	res := r.getExchangeInfoEndpoint.DoRequest(
+		ctx,
+		restclient.QueryParam("symbol", symbol.Binance()),
+	)
+	if res.Err() != nil {
+		/** Binance answers 400 for unknown symbols */
+		if res.StatusCode() == 400 {
+			return nil, errors.Wrap(domain.ErrNotFound, res.Err(), "Failed to do request.")
+		}
+
+		return nil, errors.Wrap(domain.ErrInternal, res.Err(), "Failed to do request.")
+	}
+
+	var respMsg ExchangeInfoResponse
+	if err := json.Unmarshal(res.Body(), &respMsg); err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, fmt.Sprintf("Failed to unmarshal item. body: %s", string(res.Body())))
+	}
+
+	for i := range respMsg.Symbols {
+		if respMsg.Symbols[i].Symbol == symbol.Binance() {
+			return &respMsg.Symbols[i], nil
+		}
+	}
+
+	return nil, errors.New(domain.ErrNotFound, "symbol not found", errors.WithMetadata("symbol", symbol.Binance()))
+}
+
+// GetCandles pages through /v3/klines, which returns at most 1000 candles per
+// request.
+func (r *repository) GetCandles(ctx context.Context, symbol string, interval string, start time.Time, end time.Time) ([]*domain.Candle, error) {
+	duration, err := domain.CandleIntervalDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	var candles []*domain.Candle
+	for start.Before(end) {
+		page, err := r.getKlines(ctx, symbol, interval, start, end)
+		if err != nil {
+			return nil, err
+		}
+
+		candles = append(candles, page...)
+		if len(page) < binanceKlinesLimit {
+			break
+		}
+
+		start = page[len(page)-1].OpenTime.Add(duration)
+	}
+
+	return candles, nil
+}
+
+func (r *repository) getKlines(ctx context.Context, symbol string, interval string, start time.Time, end time.Time) ([]*domain.Candle, error) {
+	res := r.getKlinesEndpoint.DoRequest(
+		ctx,
+		restclient.QueryParam("symbol", symbol),
+		restclient.QueryParam("interval", interval),
+		restclient.QueryParam("startTime", strconv.FormatInt(start.UnixMilli(), 10)),
+		/** endTime is inclusive */
+		restclient.QueryParam("endTime", strconv.FormatInt(end.UnixMilli()-1, 10)),
+		restclient.QueryParam("limit", strconv.Itoa(binanceKlinesLimit)),
+	)
+	if res.Err() != nil {
+		return nil, errors.Wrap(domain.ErrInternal, res.Err(), "Failed to do request.")
+	}
+
+	/** Each kline is [openTime, open, high, low, close, volume, closeTime, ...] */
+	var respMsg [][]json.RawMessage
+	if err := json.Unmarshal(res.Body(), &respMsg); err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, fmt.Sprintf("Failed to unmarshal klines. body: %s", string(res.Body())))
+	}
+
+	candles := make([]*domain.Candle, 0, len(respMsg))
+	for _, kline := range respMsg {
+		candle, err := klineToCandle(symbol, interval, kline)
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, candle)
+	}
+
+	return candles, nil
+}
+
+func klineToCandle(symbol string, interval string, kline []json.RawMessage) (*domain.Candle, error) {
+	if len(kline) < 7 {
+		return nil, errors.New(domain.ErrInternal, fmt.Sprintf("Invalid kline. kline: %v", kline))
+	}
+
+	var openTime, closeTime int64
+	var prices [5]decimal.Decimal
+	err := json.Unmarshal(kline[0], &openTime)
+	if err == nil {
+		err = json.Unmarshal(kline[6], &closeTime)
+	}
+	for i := 0; err == nil && i < len(prices); i++ {
+		err = json.Unmarshal(kline[i+1], &prices[i])
+	}
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "Failed to unmarshal kline.")
+	}
+
+	candle, err := domain.NewCandle(
+		symbol,
+		interval,
+		time.UnixMilli(openTime).UTC(),
+		time.UnixMilli(closeTime).UTC(),
+		prices[0],
+		prices[1],
+		prices[2],
+		prices[3],
+		prices[4],
+	)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "Invalid kline.")
+	}
+
+	return candle, nil
+}
+
+type OrderResponse struct {
+	Symbol              string          `json:"symbol"`
+	OrderId             int64           `json:"orderId"`
+	ClientOrderId       string          `json:"clientOrderId"`
+	Status              string          `json:"status"`
+	ExecutedQty         decimal.Decimal `json:"executedQty"`
+	CummulativeQuoteQty decimal.Decimal `json:"cummulativeQuoteQty"`
+}
+
 func (r *repository) CreateOrderInProvider(ctx context.Context, order *domain.Order, botName string) (string, error) {
-	logs.Info(ctx, fmt.Sprintf("%s: Compra %s BTC a %s USDT (%s USDT), take_profit_price: %s", botName, order.Quantity.String(), order.EntryPrice.String(), order.InitialQuoteAmount.String(), order.TakeProfitPrice.String()))
-	return models.GenerateUUID().String(), nil
+	symbol, err := domain.ParseSymbol(order.Symbol)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("symbol", symbol.Binance())
+	params.Set("side", order.Side)
+	params.Set("type", order.Type)
+	if order.Side == domain.OrderSideBuy && order.Type == domain.OrderTypeMarket {
+		/** Spends the quote amount reserved for it, whatever the price moves */
+		params.Set("quoteOrderQty", order.InitialQuoteAmount.Truncate(binanceQuotePrecision).String())
+	} else {
+		params.Set("quantity", order.Quantity.String())
+	}
+	params.Set("newClientOrderId", order.ID.String())
+	params.Set("newOrderRespType", "RESULT")
+
+	if order.Type == domain.OrderTypeLimit {
+		params.Set("price", order.EntryPrice.String())
+		params.Set("timeInForce", "GTC")
+	}
+
+	respMsg, err := r.doOrderRequest(ctx, r.createOrderEndpoint, params)
+	if err != nil {
+		return "", err
+	}
+
+	if order.Side == domain.OrderSideSell {
+		logs.Info(ctx, fmt.Sprintf("%s: Orden de venta %s %s a %s %s (%s %s)", botName, order.Quantity.String(), symbol.Base, order.EntryPrice.String(), symbol.Quote, order.InitialQuoteAmount.String(), symbol.Quote))
+	} else {
+		logs.Info(ctx, fmt.Sprintf("%s: Compra %s %s a %s %s (%s %s), take_profit_price: %s", botName, order.Quantity.String(), symbol.Base, order.EntryPrice.String(), symbol.Quote, order.InitialQuoteAmount.String(), symbol.Quote, order.TakeProfitPrice.String()))
+	}
+
+	return strconv.FormatInt(respMsg.OrderId, 10), nil
+}
+
+func (r *repository) CancelOrderInProvider(ctx context.Context, order *domain.Order) error {
+	_, err := r.doOrderRequest(ctx, r.cancelOrderEndpoint, orderParams(order))
+
+	return err
+}
+
+func (r *repository) GetOrderFromProvider(ctx context.Context, order *domain.Order) (*domain.ProviderOrder, error) {
+	respMsg, err := r.doOrderRequest(ctx, r.getOrderEndpoint, orderParams(order))
+	if err != nil {
+		return nil, err
+	}
+
+	providerOrder := &domain.ProviderOrder{
+		ExternalId:          strconv.FormatInt(respMsg.OrderId, 10),
+		Status:              binanceOrderStatus(respMsg.Status),
+		ExecutedQuantity:    respMsg.ExecutedQty,
+		ExecutedQuoteAmount: respMsg.CummulativeQuoteQty,
+	}
+
+	/** The commission is only reported per trade */
+	if providerOrder.ExecutedQuantity.IsPositive() {
+		symbol, err := domain.ParseSymbol(order.Symbol)
+		if err != nil {
+			return nil, err
+		}
+
+		trades, err := r.getTrades(ctx, respMsg.Symbol, respMsg.OrderId)
+		if err != nil {
+			return nil, err
+		}
+
+		mixed := false
+		quoteValue := decimal.Zero
+		for _, trade := range trades {
+			if !trade.Commission.IsPositive() {
+				continue
+			}
+
+			if providerOrder.FeeCurrency != "" && trade.CommissionAsset != providerOrder.FeeCurrency {
+				mixed = true
+			}
+
+			providerOrder.Fee = providerOrder.Fee.Add(trade.Commission)
+			providerOrder.FeeCurrency = trade.CommissionAsset
+			quoteValue = quoteValue.Add(r.fees.QuoteValue(&domain.Order{
+				Type:                order.Type,
+				Fee:                 trade.Commission,
+				FeeCurrency:         trade.CommissionAsset,
+				ExecutedQuantity:    trade.Qty,
+				ExecutedQuoteAmount: trade.QuoteQty,
+			}, symbol.Base, symbol.Quote))
+		}
+
+		/** Binance only mixes assets when the BNB balance runs out during the
+		fill. Each commission is valued in the quote currency and the order is
+		settled with their sum */
+		if mixed {
+			providerOrder.Fee = quoteValue
+			providerOrder.FeeCurrency = symbol.Quote
+		}
+	}
+
+	return providerOrder, nil
+}
+
+type TradeResponse struct {
+	Id              int64           `json:"id"`
+	OrderId         int64           `json:"orderId"`
+	Price           decimal.Decimal `json:"price"`
+	Qty             decimal.Decimal `json:"qty"`
+	QuoteQty        decimal.Decimal `json:"quoteQty"`
+	Commission      decimal.Decimal `json:"commission"`
+	CommissionAsset string          `json:"commissionAsset"`
+}
+
+func (r *repository) getTrades(ctx context.Context, symbol string, orderId int64) ([]TradeResponse, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("orderId", strconv.FormatInt(orderId, 10))
+
+	body, err := r.doSignedRequest(ctx, r.getTradesEndpoint, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var respMsg []TradeResponse
+	if err := json.Unmarshal(body, &respMsg); err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, fmt.Sprintf("Failed to unmarshal trades. body: %s", string(body)))
+	}
+
+	return respMsg, nil
+}
+
+// orderParams identifies the order by the exchange id or, if the response to
+// the creation was lost, by the client id the bot sent.
+func orderParams(order *domain.Order) url.Values {
+	params := url.Values{}
+	params.Set("symbol", binanceSymbol(order.Symbol))
+
+	if order.ExternalId != nil {
+		params.Set("orderId", *order.ExternalId)
+	} else {
+		params.Set("origClientOrderId", order.ID.String())
+	}
+
+	return params
+}
+
+func (r *repository) doOrderRequest(ctx context.Context, endpoint restclient.Endpoint, params url.Values) (*OrderResponse, error) {
+	body, err := r.doSignedRequest(ctx, endpoint, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var respMsg OrderResponse
+	if err := json.Unmarshal(body, &respMsg); err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, fmt.Sprintf("Failed to unmarshal order. body: %s", string(body)))
+	}
+
+	return &respMsg, nil
+}
+
+// doSignedRequest sends a signed request, syncing the server time and retrying
+// once if Binance rejects the timestamp.
+func (r *repository) doSignedRequest(ctx context.Context, endpoint restclient.Endpoint, params url.Values) ([]byte, error) {
+	var res restclient.Response
+	for attempt := 0; attempt < 2; attempt++ {
+		query, err := r.signer.Sign(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		res = endpoint.DoRequest(
+			ctx,
+			r.signer.ApiKeyHeader(),
+			restclient.RawQuery(query),
+		)
+		if res.Err() == nil || binanceErrorCode(res.Body()) != binanceErrCodeInvalidTimestamp {
+			break
+		}
+
+		r.signer.Invalidate()
+	}
+
+	if res.Err() != nil {
+		if res.StatusCode() == 404 || binanceErrorCode(res.Body()) == binanceErrCodeNoSuchOrder {
+			return nil, errors.Wrap(domain.ErrNotFound, res.Err(), "Failed to do request.")
+		}
+
+		return nil, errors.Wrap(domain.ErrInternal, res.Err(), "Failed to do request.")
+	}
+
+	return res.Body(), nil
+}
+
+type ErrorResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+func binanceErrorCode(body []byte) int {
+	var respMsg ErrorResponse
+	if err := json.Unmarshal(body, &respMsg); err != nil {
+		return 0
+	}
+
+	return respMsg.Code
+}
+
+// binanceSymbol renders the symbol of an order as BTCUSDT, leaving it as it is
+// if it can't be parsed so Binance rejects it.
+func binanceSymbol(symbol string) string {
+	parsed, err := domain.ParseSymbol(symbol)
+	if err != nil {
+		return symbol
+	}
+
+	return parsed.Binance()
+}
+
+func binanceOrderStatus(status string) string {
+	switch status {
+	case "NEW", "PENDING_NEW", "PENDING_CANCEL":
+		return domain.OrderStatusOpen
+	case "PARTIALLY_FILLED":
+		return domain.OrderStatusPartiallyFilled
+	case "FILLED":
+		return domain.OrderStatusCompleted
+	default:
+		/** CANCELED, REJECTED, EXPIRED, EXPIRED_IN_MATCH */
+		return domain.OrderStatusCanceled
+	}
 }
@@ -0,0 +1,350 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+	"github.com/juankohler/crypto-bot/libs/go/restclient"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBinanceSignature(t *testing.T) {
+	// Example from the Binance API documentation.
+	signer := newBinanceSigner(BinanceCredentials{
+		ApiKey:    "vmPUZE6mv9SD5VNHk4HlWFsOr6aKE2zvsw0MuIgwCIPy6utIco14y7Ju91duEh8A",
+		SecretKey: "NhqPtmdSJYdKjVHjA7PZj4Mge3R5YNiP1e3UZjInClVN65XAbvqqM6A7H5fATj0j",
+	}, nil)
+
+	assert.Equal(
+		t,
+		"c8db56825ae71d6d79447849e617115f4a920fa2acdcab2b053c4b2838bd6b71",
+		signer.signature("symbol=LTCBTC&side=BUY&type=LIMIT&timeInForce=GTC&quantity=1&price=0.1&recvWindow=5000&timestamp=1499827319559"),
+	)
+}
+
+func TestBinanceCreateOrder(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+
+	serverTime := time.Now().Add(2 * time.Second)
+	transport.RegisterResponder("GET", "https://api.binance.com/api/v3/time",
+		httpmock.NewStringResponder(200, fmt.Sprintf(`{"serverTime": %d}`, serverTime.UnixMilli())))
+
+	var query string
+	var apiKey string
+	transport.RegisterResponder("POST", "https://api.binance.com/api/v3/order", func(req *http.Request) (*http.Response, error) {
+		query = req.URL.RawQuery
+		apiKey = req.Header.Get(binanceApiKeyHeader)
+		return httpmock.NewStringResponse(200, `{"symbol":"BTCUSDT","orderId":28,"clientOrderId":"abc","status":"FILLED","executedQty":"0.001","cummulativeQuoteQty":"60"}`), nil
+	})
+
+	repo, err := NewBinanceRepo(&restclient.Config{
+		BaseUrl:         "https://api.binance.com/api",
+		CustomTransport: transport,
+	}, BinanceCredentials{
+		ApiKey:    "key",
+		SecretKey: "secret",
+	}, domain.FeeSchedule{}, nil)
+	assert.NoError(t, err)
+
+	order, err := domain.NewOrder(
+		models.ID("order-id-1"),
+		models.ID("bot-id-1"),
+		nil,
+		nil,
+		"BTC-USDT",
+		domain.OrderSideBuy,
+		domain.OrderTypeMarket,
+		decimal.RequireFromString("0.001"),
+		decimal.RequireFromString("60"),
+		decimal.RequireFromString("60.3"),
+		decimal.RequireFromString("60000"),
+		decimal.RequireFromString("60300"),
+		decimal.Zero,
+		decimal.Zero,
+		decimal.Zero,
+		"",
+		decimal.Zero,
+		decimal.Zero,
+		decimal.Zero,
+		"",
+		nil,
+		domain.OrderStatusPending,
+		300,
+		decimal.Zero,
+		nil,
+		models.CreateTimestamps(),
+		models.CreateVersion(),
+	)
+	assert.NoError(t, err)
+
+	externalId, err := repo.CreateOrderInProvider(context.Background(), order, "TEST")
+	assert.NoError(t, err)
+	assert.Equal(t, "28", externalId)
+	assert.Equal(t, "key", apiKey)
+	assert.Regexp(t, `^newClientOrderId=order-id-1&newOrderRespType=RESULT&quoteOrderQty=60&recvWindow=5000&side=BUY&symbol=BTCUSDT&timestamp=\d+&type=MARKET&signature=[0-9a-f]{64}$`, query)
+
+	payload, signature, _ := strings.Cut(query, "&signature=")
+	assert.Equal(t, repo.signer.signature(payload), signature)
+
+	t.Run("missing credentials", func(t *testing.T) {
+		repo, err := NewBinanceRepo(&restclient.Config{
+			BaseUrl:         "https://api.binance.com/api",
+			CustomTransport: transport,
+		}, BinanceCredentials{}, domain.FeeSchedule{}, nil)
+		assert.NoError(t, err)
+
+		_, err = repo.CreateOrderInProvider(context.Background(), order, "TEST")
+		assert.ErrorIs(t, err, domain.ErrInvalid)
+	})
+}
+
+func TestBinanceGetOrder(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+
+	transport.RegisterResponder("GET", "https://api.binance.com/api/v3/time",
+		httpmock.NewStringResponder(200, fmt.Sprintf(`{"serverTime": %d}`, time.Now().UnixMilli())))
+
+	fees, err := domain.NewFeeSchedule(decimal.RequireFromString("0.001"), decimal.RequireFromString("0.001"), decimal.RequireFromString("0.25"), true)
+	assert.NoError(t, err)
+
+	var orderQuery string
+	transport.RegisterResponder("GET", "https://api.binance.com/api/v3/order", func(req *http.Request) (*http.Response, error) {
+		orderQuery = req.URL.RawQuery
+		if req.URL.Query().Get("origClientOrderId") == "missing" {
+			return httpmock.NewStringResponse(400, `{"code":-2013,"msg":"Order does not exist."}`), nil
+		}
+		return httpmock.NewStringResponse(200, `{"symbol":"BTCUSDT","orderId":28,"clientOrderId":"order-id-1","status":"FILLED","executedQty":"0.002","cummulativeQuoteQty":"120"}`), nil
+	})
+	trades := `[
+		{"id":1,"orderId":28,"price":"60000","qty":"0.0015","quoteQty":"90","commission":"0.0000015","commissionAsset":"BTC"},
+		{"id":2,"orderId":28,"price":"60000","qty":"0.0005","quoteQty":"30","commission":"0.0000005","commissionAsset":"BTC"}
+	]`
+	transport.RegisterResponder("GET", "https://api.binance.com/api/v3/myTrades", func(req *http.Request) (*http.Response, error) {
+		return httpmock.NewStringResponse(200, trades), nil
+	})
+
+	repo, err := NewBinanceRepo(&restclient.Config{
+		BaseUrl:         "https://api.binance.com/api",
+		CustomTransport: transport,
+	}, BinanceCredentials{
+		ApiKey:    "key",
+		SecretKey: "secret",
+	}, fees, nil)
+	assert.NoError(t, err)
+
+	newOrder := func(id string) *domain.Order {
+		order, err := domain.NewOrder(
+			models.ID(id),
+			models.ID("bot-id-1"),
+			nil,
+			nil,
+			"BTC-USDT",
+			domain.OrderSideBuy,
+			domain.OrderTypeMarket,
+			decimal.RequireFromString("0.002"),
+			decimal.RequireFromString("120"),
+			decimal.RequireFromString("120.6"),
+			decimal.RequireFromString("60000"),
+			decimal.RequireFromString("60300"),
+			decimal.Zero,
+			decimal.Zero,
+			decimal.Zero,
+			"",
+			decimal.Zero,
+			decimal.Zero,
+			decimal.Zero,
+			"",
+			nil,
+			domain.OrderStatusPending,
+			300,
+			decimal.Zero,
+			nil,
+			models.CreateTimestamps(),
+			models.CreateVersion(),
+		)
+		assert.NoError(t, err)
+		return order
+	}
+
+	providerOrder, err := repo.GetOrderFromProvider(context.Background(), newOrder("order-id-1"))
+	assert.NoError(t, err)
+	assert.Contains(t, orderQuery, "origClientOrderId=order-id-1")
+	assert.Equal(t, "28", providerOrder.ExternalId)
+	assert.Equal(t, domain.OrderStatusCompleted, providerOrder.Status)
+	assert.Equal(t, "0.002", providerOrder.ExecutedQuantity.String())
+	assert.Equal(t, "0.000002", providerOrder.Fee.String())
+	assert.Equal(t, "BTC", providerOrder.FeeCurrency)
+
+	_, err = repo.GetOrderFromProvider(context.Background(), newOrder("missing"))
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	/** The BNB balance ran out during the fill, each commission is valued in
+	USDT: 90 * 0.001 * 0.75 for the BNB one and 0.0000005 * 60000 for the BTC one */
+	trades = `[
+		{"id":1,"orderId":28,"price":"60000","qty":"0.0015","quoteQty":"90","commission":"0.00009","commissionAsset":"BNB"},
+		{"id":2,"orderId":28,"price":"60000","qty":"0.0005","quoteQty":"30","commission":"0.0000005","commissionAsset":"BTC"}
+	]`
+	providerOrder, err = repo.GetOrderFromProvider(context.Background(), newOrder("order-id-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "0.0975", providerOrder.Fee.String())
+	assert.Equal(t, "USDT", providerOrder.FeeCurrency)
+
+	/** Trades without commission don't count */
+	trades = `[
+		{"id":1,"orderId":28,"price":"60000","qty":"0.0015","quoteQty":"90","commission":"0","commissionAsset":"BNB"},
+		{"id":2,"orderId":28,"price":"60000","qty":"0.0005","quoteQty":"30","commission":"0.0000005","commissionAsset":"BTC"}
+	]`
+	providerOrder, err = repo.GetOrderFromProvider(context.Background(), newOrder("order-id-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "0.0000005", providerOrder.Fee.String())
+	assert.Equal(t, "BTC", providerOrder.FeeCurrency)
+}
+
+func TestBinanceGetCandles(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(1500 * time.Minute)
+
+	var startTimes []string
+	transport.RegisterResponder("GET", "https://api.binance.com/api/v3/klines", func(req *http.Request) (*http.Response, error) {
+		query := req.URL.Query()
+		startTimes = append(startTimes, query.Get("startTime"))
+		assert.Equal(t, "BTCUSDT", query.Get("symbol"))
+		assert.Equal(t, "1m", query.Get("interval"))
+		assert.Equal(t, fmt.Sprint(end.UnixMilli()-1), query.Get("endTime"))
+
+		from, _ := strconv.ParseInt(query.Get("startTime"), 10, 64)
+		var klines []string
+		for openTime := from; openTime < end.UnixMilli() && len(klines) < binanceKlinesLimit; openTime += time.Minute.Milliseconds() {
+			klines = append(klines, fmt.Sprintf(`[%d,"100.0","101.0","99.0","100.5","3.2",%d,"320.0",10,"1.0","100.0","0"]`, openTime, openTime+time.Minute.Milliseconds()-1))
+		}
+
+		return httpmock.NewStringResponse(200, "["+strings.Join(klines, ",")+"]"), nil
+	})
+
+	repo, err := NewBinanceRepo(&restclient.Config{
+		BaseUrl:         "https://api.binance.com/api",
+		CustomTransport: transport,
+	}, BinanceCredentials{}, domain.FeeSchedule{}, nil)
+	assert.NoError(t, err)
+
+	candles, err := repo.GetCandles(context.Background(), "BTCUSDT", "1m", start, end)
+	assert.NoError(t, err)
+	assert.Len(t, candles, 1500)
+	assert.Equal(t, []string{fmt.Sprint(start.UnixMilli()), fmt.Sprint(start.Add(1000 * time.Minute).UnixMilli())}, startTimes)
+	assert.Equal(t, start.Add(1499*time.Minute), candles[1499].OpenTime)
+	assert.Equal(t, "100.5", candles[0].Close.String())
+}
+
+func TestBinanceGetPrices(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+
+	transport.RegisterResponder("GET", "https://api.binance.com/api/v3/ticker/price", func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, `["BTCUSDT","ETHUSDT"]`, req.URL.Query().Get("symbols"))
+		return httpmock.NewStringResponse(200, `[{"symbol":"BTCUSDT","price":"42000.10"},{"symbol":"ETHUSDT","price":"2500.5"}]`), nil
+	})
+
+	repo, err := NewBinanceRepo(&restclient.Config{
+		BaseUrl:         "https://api.binance.com/api",
+		CustomTransport: transport,
+	}, BinanceCredentials{}, domain.FeeSchedule{}, nil)
+	assert.NoError(t, err)
+
+	prices, err := repo.GetPrices(context.Background(), []domain.CurrencyPair{
+		{BaseCurrency: "BTC", QuoteCurrency: "USDT"},
+		{BaseCurrency: "ETH", QuoteCurrency: "USDT"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, prices, 2)
+	assert.Equal(t, "BTC", prices[0].BaseCurrency)
+	assert.Equal(t, "42000.1", prices[0].Price.String())
+	assert.Equal(t, "ETH", prices[1].BaseCurrency)
+	assert.Equal(t, "2500.5", prices[1].Price.String())
+	assert.Equal(t, 1, transport.GetTotalCallCount())
+}
+
+func TestBinanceGetSymbolFilters(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+
+	transport.RegisterResponder("GET", "https://api.binance.com/api/v3/exchangeInfo", func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "BTCUSDT", req.URL.Query().Get("symbol"))
+		return httpmock.NewStringResponse(200, `{"symbols":[{"symbol":"BTCUSDT","baseAsset":"BTC","quoteAsset":"USDT","filters":[
+			{"filterType":"PRICE_FILTER","minPrice":"0.01000000","maxPrice":"1000000.00000000","tickSize":"0.01000000"},
+			{"filterType":"LOT_SIZE","minQty":"0.00001000","maxQty":"9000.00000000","stepSize":"0.00001000"},
+			{"filterType":"NOTIONAL","minNotional":"5.00000000","applyMinToMarket":true}
+		]}]}`), nil
+	})
+
+	repo, err := NewBinanceRepo(&restclient.Config{
+		BaseUrl:         "https://api.binance.com/api",
+		CustomTransport: transport,
+	}, BinanceCredentials{}, domain.FeeSchedule{}, nil)
+	assert.NoError(t, err)
+
+	filters, err := repo.GetSymbolFilters(context.Background(), "BTC", "USDT")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.01", filters.TickSize.String())
+	assert.Equal(t, "0.00001", filters.StepSize.String())
+	assert.Equal(t, "5", filters.MinNotional.String())
+
+	/** Cached for the next calls */
+	_, err = repo.GetSymbolFilters(context.Background(), "BTC", "USDT")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, transport.GetTotalCallCount())
+
+	quantity := filters.RoundQuantity(decimal.RequireFromString("0.000123456"))
+	assert.Equal(t, "0.00012", quantity.String())
+	assert.Equal(t, "42000.11", filters.RoundPriceUp(decimal.RequireFromString("42000.101")).String())
+	assert.Equal(t, "42000.1", filters.RoundPriceDown(decimal.RequireFromString("42000.109")).String())
+	assert.NoError(t, filters.Validate(quantity, decimal.RequireFromString("42000")))
+
+	/** 0.0001 BTC at 42000 is 4.2 USDT, below the minimum notional */
+	err = filters.Validate(decimal.RequireFromString("0.0001"), decimal.RequireFromString("42000"))
+	assert.True(t, errors.Is(err, domain.ErrInvalid))
+}
+
+func TestBinanceValidateSymbol(t *testing.T) {
+	transport := httpmock.NewMockTransport()
+
+	transport.RegisterResponder("GET", "https://api.binance.com/api/v3/exchangeInfo", func(req *http.Request) (*http.Response, error) {
+		switch req.URL.Query().Get("symbol") {
+		case "ETHBTC":
+			return httpmock.NewStringResponse(200, `{"symbols":[{"symbol":"ETHBTC","status":"TRADING","baseAsset":"ETH","quoteAsset":"BTC","filters":[]}]}`), nil
+		case "LUNAUSDT":
+			return httpmock.NewStringResponse(200, `{"symbols":[{"symbol":"LUNAUSDT","status":"BREAK","baseAsset":"LUNA","quoteAsset":"USDT","filters":[]}]}`), nil
+		default:
+			return httpmock.NewStringResponse(400, `{"code":-1121,"msg":"Invalid symbol."}`), nil
+		}
+	})
+
+	repo, err := NewBinanceRepo(&restclient.Config{
+		BaseUrl:         "https://api.binance.com/api",
+		CustomTransport: transport,
+	}, BinanceCredentials{}, domain.FeeSchedule{}, nil)
+	assert.NoError(t, err)
+
+	symbol, err := domain.ParseSymbol("eth-btc")
+	assert.NoError(t, err)
+	assert.Equal(t, "ETH-BTC", symbol.String())
+	assert.NoError(t, repo.ValidateSymbol(context.Background(), symbol))
+
+	err = repo.ValidateSymbol(context.Background(), domain.Symbol{Base: "LUNA", Quote: "USDT"})
+	assert.True(t, errors.Is(err, domain.ErrNotFound))
+
+	err = repo.ValidateSymbol(context.Background(), domain.Symbol{Base: "FOO", Quote: "USDT"})
+	assert.True(t, errors.Is(err, domain.ErrNotFound))
+
+	_, err = domain.ParseSymbol("BTCUSDT")
+	assert.True(t, errors.Is(err, domain.ErrInvalid))
+}
@@ -0,0 +1,338 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/logs"
+	"github.com/juankohler/crypto-bot/libs/go/wsclient"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// Events buffered for each subscriber before dropping them.
+	binanceStreamBufferSize = 100
+	binanceStreamInterval   = "1m"
+)
+
+// Streams subscribed to for every pair.
+var binanceStreamKinds = []string{"trade", "bookTicker", "kline_" + binanceStreamInterval}
+
+// binanceStream is the market data of the Binance combined streams, e.g.
+// wss://stream.binance.com:9443/stream. A single connection carries the
+// streams of every pair with subscribers, subscribed to when the first
+// subscriber of a pair arrives, unsubscribed when the last one leaves and
+// subscribed again after every reconnection.
+type binanceStream struct {
+	client wsclient.Client
+
+	mu      sync.Mutex
+	nextId  int
+	streams map[string]*binancePairStream
+}
+
+type binancePairStream struct {
+	baseCurrency  string
+	quoteCurrency string
+	subscriptions map[*binanceSubscription]struct{}
+}
+
+type binanceSubscription struct {
+	stream *binanceStream
+	symbol string
+	events chan *domain.MarketEvent
+}
+
+func NewBinanceStream(cfg wsclient.Config) (*binanceStream, error) {
+	stream := &binanceStream{
+		streams: make(map[string]*binancePairStream),
+	}
+
+	client, err := wsclient.New(
+		cfg,
+		stream.onMessage,
+		wsclient.WithConnectHandler(stream.onConnect),
+		wsclient.WithDisconnectHandler(stream.onDisconnect),
+	)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "could not create the Binance stream")
+	}
+	stream.client = client
+
+	return stream, nil
+}
+
+// Run keeps the stream connected until the context is canceled.
+func (s *binanceStream) Run(ctx context.Context) error {
+	return s.client.Run(ctx)
+}
+
+func (s *binanceStream) Subscribe(ctx context.Context, baseCurrency string, quoteCurrency string) (domain.MarketSubscription, error) {
+	if baseCurrency == "" || quoteCurrency == "" {
+		return nil, errors.New(
+			domain.ErrInvalid,
+			"invalid pair",
+			errors.WithMetadata("base_currency", baseCurrency),
+			errors.WithMetadata("quote_currency", quoteCurrency),
+		)
+	}
+
+	symbol := strings.ToLower(domain.Symbol{Base: baseCurrency, Quote: quoteCurrency}.Binance())
+	subscription := &binanceSubscription{
+		stream: s,
+		symbol: symbol,
+		events: make(chan *domain.MarketEvent, binanceStreamBufferSize),
+	}
+
+	s.mu.Lock()
+	pair, ok := s.streams[symbol]
+	if !ok {
+		pair = &binancePairStream{
+			baseCurrency:  baseCurrency,
+			quoteCurrency: quoteCurrency,
+			subscriptions: make(map[*binanceSubscription]struct{}),
+		}
+		s.streams[symbol] = pair
+	}
+	pair.subscriptions[subscription] = struct{}{}
+	s.mu.Unlock()
+
+	if !ok {
+		s.send(ctx, "SUBSCRIBE", binanceStreamNames(symbol))
+	}
+
+	return subscription, nil
+}
+
+func (s *binanceSubscription) Events() <-chan *domain.MarketEvent {
+	return s.events
+}
+
+func (s *binanceSubscription) Close() {
+	s.stream.unsubscribe(s)
+}
+
+func (s *binanceStream) unsubscribe(subscription *binanceSubscription) {
+	s.mu.Lock()
+	pair, ok := s.streams[subscription.symbol]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+
+	if _, ok := pair.subscriptions[subscription]; !ok {
+		s.mu.Unlock()
+		return
+	}
+
+	delete(pair.subscriptions, subscription)
+	close(subscription.events)
+
+	last := len(pair.subscriptions) == 0
+	if last {
+		delete(s.streams, subscription.symbol)
+	}
+	s.mu.Unlock()
+
+	if last {
+		s.send(context.Background(), "UNSUBSCRIBE", binanceStreamNames(subscription.symbol))
+	}
+}
+
+// send writes a subscription request. When it can't be written, the
+// connection is being replaced and onConnect subscribes again.
+func (s *binanceStream) send(ctx context.Context, method string, params []string) {
+	s.mu.Lock()
+	s.nextId++
+	id := s.nextId
+	s.mu.Unlock()
+
+	err := s.client.SendJSON(binanceStreamRequest{
+		Method: method,
+		Params: params,
+		Id:     id,
+	})
+	if err != nil && !errors.Is(err, wsclient.ErrNotConnected) {
+		logs.Warn(ctx, fmt.Sprintf("could not %s to the Binance stream", strings.ToLower(method)), logs.NewAttr("error", err))
+	}
+}
+
+func (s *binanceStream) onConnect(ctx context.Context) error {
+	s.mu.Lock()
+	var params []string
+	for symbol := range s.streams {
+		params = append(params, binanceStreamNames(symbol)...)
+	}
+	s.mu.Unlock()
+
+	logs.Info(ctx, fmt.Sprintf("Conectado al stream de Binance, streams: %d", len(params)))
+	if len(params) == 0 {
+		return nil
+	}
+
+	s.send(ctx, "SUBSCRIBE", params)
+
+	return nil
+}
+
+func (s *binanceStream) onDisconnect(ctx context.Context, err error) {
+	logs.Error(ctx, "Binance stream disconnected", logs.NewAttr("error", err))
+}
+
+func (s *binanceStream) onMessage(ctx context.Context, message []byte) {
+	var envelope binanceStreamMessage
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		logs.Error(ctx, "invalid Binance stream message", logs.NewAttr("error", err), logs.NewAttr("message", string(message)))
+		return
+	}
+
+	/** Responses to SUBSCRIBE and UNSUBSCRIBE */
+	if envelope.Stream == "" {
+		if envelope.Error != nil {
+			logs.Error(ctx, "Binance stream request failed", logs.NewAttr("id", envelope.Id), logs.NewAttr("code", envelope.Error.Code), logs.NewAttr("msg", envelope.Error.Msg))
+		}
+		return
+	}
+
+	symbol, kind, _ := strings.Cut(envelope.Stream, "@")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pair, ok := s.streams[symbol]
+	if !ok {
+		return
+	}
+
+	event, err := binanceStreamEvent(pair, kind, envelope.Data)
+	if err != nil {
+		logs.Error(ctx, "invalid Binance stream event", logs.NewAttr("stream", envelope.Stream), logs.NewAttr("error", err))
+		return
+	}
+
+	for subscription := range pair.subscriptions {
+		select {
+		case subscription.events <- event:
+		default:
+		}
+	}
+}
+
+func binanceStreamEvent(pair *binancePairStream, kind string, data json.RawMessage) (*domain.MarketEvent, error) {
+	event := &domain.MarketEvent{
+		BaseCurrency:  pair.baseCurrency,
+		QuoteCurrency: pair.quoteCurrency,
+	}
+
+	switch kind {
+	case "trade":
+		var trade binanceStreamTrade
+		if err := json.Unmarshal(data, &trade); err != nil {
+			return nil, errors.Wrap(domain.ErrInternal, err, "Failed to unmarshal trade.")
+		}
+
+		event.Trade = &domain.Trade{
+			Price:    trade.Price,
+			Quantity: trade.Quantity,
+			Time:     time.UnixMilli(trade.TradeTime).UTC(),
+		}
+	case "bookTicker":
+		var ticker binanceStreamBookTicker
+		if err := json.Unmarshal(data, &ticker); err != nil {
+			return nil, errors.Wrap(domain.ErrInternal, err, "Failed to unmarshal book ticker.")
+		}
+
+		event.BookTicker = &domain.BookTicker{
+			BidPrice:    ticker.BidPrice,
+			BidQuantity: ticker.BidQuantity,
+			AskPrice:    ticker.AskPrice,
+			AskQuantity: ticker.AskQuantity,
+		}
+	case "kline_" + binanceStreamInterval:
+		var kline binanceStreamKline
+		if err := json.Unmarshal(data, &kline); err != nil {
+			return nil, errors.Wrap(domain.ErrInternal, err, "Failed to unmarshal kline.")
+		}
+
+		candle, err := domain.NewCandle(
+			kline.Kline.Symbol,
+			kline.Kline.Interval,
+			time.UnixMilli(kline.Kline.OpenTime).UTC(),
+			time.UnixMilli(kline.Kline.CloseTime).UTC(),
+			kline.Kline.Open,
+			kline.Kline.High,
+			kline.Kline.Low,
+			kline.Kline.Close,
+			kline.Kline.Volume,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		event.Candle = candle
+		event.CandleClosed = kline.Kline.Closed
+	default:
+		return nil, errors.New(domain.ErrInternal, "unknown stream", errors.WithMetadata("kind", kind))
+	}
+
+	return event, nil
+}
+
+func binanceStreamNames(symbol string) []string {
+	names := make([]string, 0, len(binanceStreamKinds))
+	for _, kind := range binanceStreamKinds {
+		names = append(names, symbol+"@"+kind)
+	}
+
+	return names
+}
+
+type binanceStreamRequest struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	Id     int      `json:"id"`
+}
+
+type binanceStreamMessage struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+	Id     int             `json:"id"`
+	Error  *struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	} `json:"error"`
+}
+
+type binanceStreamTrade struct {
+	Price     decimal.Decimal `json:"p"`
+	Quantity  decimal.Decimal `json:"q"`
+	TradeTime int64           `json:"T"`
+}
+
+type binanceStreamBookTicker struct {
+	BidPrice    decimal.Decimal `json:"b"`
+	BidQuantity decimal.Decimal `json:"B"`
+	AskPrice    decimal.Decimal `json:"a"`
+	AskQuantity decimal.Decimal `json:"A"`
+}
+
+type binanceStreamKline struct {
+	Kline struct {
+		OpenTime  int64           `json:"t"`
+		CloseTime int64           `json:"T"`
+		Symbol    string          `json:"s"`
+		Interval  string          `json:"i"`
+		Open      decimal.Decimal `json:"o"`
+		Close     decimal.Decimal `json:"c"`
+		High      decimal.Decimal `json:"h"`
+		Low       decimal.Decimal `json:"l"`
+		Volume    decimal.Decimal `json:"v"`
+		Closed    bool            `json:"x"`
+	} `json:"k"`
+}
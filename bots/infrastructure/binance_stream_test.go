@@ -0,0 +1,110 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/wsclient"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+func TestBinanceStream(t *testing.T) {
+	/** Stand-in for the combined streams: every connection waits for a
+	SUBSCRIBE, answers it, pushes one event of each stream and is closed */
+	requests := make(chan binanceStreamRequest, 10)
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		var request binanceStreamRequest
+		if err := websocket.JSON.Receive(conn, &request); err != nil {
+			return
+		}
+		requests <- request
+
+		messages := []string{
+			fmt.Sprintf(`{"result":null,"id":%d}`, request.Id),
+			`{"stream":"btcusdt@trade","data":{"e":"trade","E":1704067200100,"s":"BTCUSDT","t":1,"p":"42000.50","q":"0.01","T":1704067200000,"m":true}}`,
+			`{"stream":"btcusdt@bookTicker","data":{"u":1,"s":"BTCUSDT","b":"42000.00","B":"1.5","a":"42001.00","A":"2"}}`,
+			`{"stream":"btcusdt@kline_1m","data":{"e":"kline","E":1704067200100,"s":"BTCUSDT","k":{"t":1704067200000,"T":1704067259999,"s":"BTCUSDT","i":"1m","o":"42000","c":"42010","h":"42020","l":"41990","v":"12.5","x":false}}}`,
+		}
+		for _, message := range messages {
+			if err := websocket.Message.Send(conn, message); err != nil {
+				return
+			}
+		}
+
+		/** Waits for the client to read everything before closing */
+		var discard []byte
+		conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		_ = websocket.Message.Receive(conn, &discard)
+	}))
+	defer server.Close()
+
+	backoff := 10
+	stream, err := NewBinanceStream(wsclient.Config{
+		Url:          "ws" + strings.TrimPrefix(server.URL, "http") + "/stream",
+		MinBackoffMs: &backoff,
+		MaxBackoffMs: &backoff,
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first, err := stream.Subscribe(ctx, "BTC", "USDT")
+	assert.NoError(t, err)
+	second, err := stream.Subscribe(ctx, "BTC", "USDT")
+	assert.NoError(t, err)
+
+	go stream.Run(ctx)
+
+	receive := func(subscription domain.MarketSubscription) *domain.MarketEvent {
+		select {
+		case event := <-subscription.Events():
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("event not received")
+			return nil
+		}
+	}
+
+	/** Subscribes again after every reconnection */
+	for connection := 0; connection < 2; connection++ {
+		select {
+		case request := <-requests:
+			assert.Equal(t, "SUBSCRIBE", request.Method)
+			assert.Equal(t, []string{"btcusdt@trade", "btcusdt@bookTicker", "btcusdt@kline_1m"}, request.Params)
+		case <-time.After(5 * time.Second):
+			t.Fatal("subscription not received")
+		}
+
+		/** Fan-out to every subscriber of the pair */
+		for _, subscription := range []domain.MarketSubscription{first, second} {
+			trade := receive(subscription)
+			assert.Equal(t, "BTC", trade.BaseCurrency)
+			assert.Equal(t, "USDT", trade.QuoteCurrency)
+			assert.Equal(t, "42000.5", trade.Trade.Price.String())
+			assert.Equal(t, time.UnixMilli(1704067200000).UTC(), trade.Trade.Time)
+
+			ticker := receive(subscription)
+			assert.Equal(t, "42000", ticker.BookTicker.BidPrice.String())
+			assert.Equal(t, "42001", ticker.BookTicker.AskPrice.String())
+
+			kline := receive(subscription)
+			assert.Equal(t, "42010", kline.Candle.Close.String())
+			assert.Equal(t, "1m", kline.Candle.Interval)
+			assert.False(t, kline.CandleClosed)
+		}
+	}
+
+	first.Close()
+	second.Close()
+
+	_, open := <-first.Events()
+	assert.False(t, open)
+	assert.Empty(t, stream.streams)
+}
@@ -0,0 +1,85 @@
+package infrastructure
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+)
+
+// ReadCandlesCSV reads candles in the format of the Binance kline files:
+// open time, open, high, low, close, volume, close time and other columns that
+// are ignored. Times are in milliseconds, or microseconds as in the files since
+// 2025, and a header line is skipped.
+func ReadCandlesCSV(reader io.Reader, symbol string, interval string) ([]*domain.Candle, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+
+	var candles []*domain.Candle
+	for line := 1; ; line++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(domain.ErrInvalid, err, "could not read candles", errors.WithMetadata("line", line))
+		}
+
+		if len(record) < 7 {
+			return nil, errors.New(domain.ErrInvalid, "candle must have at least 7 columns", errors.WithMetadata("line", line))
+		}
+
+		openTime, err := strconv.ParseInt(record[0], 10, 64)
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, errors.Wrap(domain.ErrInvalid, err, "invalid open time", errors.WithMetadata("line", line))
+		}
+
+		closeTime, err := strconv.ParseInt(record[6], 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(domain.ErrInvalid, err, "invalid close time", errors.WithMetadata("line", line))
+		}
+
+		var prices [5]decimal.Decimal
+		for i := range prices {
+			prices[i], err = decimal.NewFromString(record[i+1])
+			if err != nil {
+				return nil, errors.Wrap(domain.ErrInvalid, err, "invalid candle price", errors.WithMetadata("line", line))
+			}
+		}
+
+		candle, err := domain.NewCandle(
+			symbol,
+			interval,
+			klineTime(openTime),
+			klineTime(closeTime),
+			prices[0],
+			prices[1],
+			prices[2],
+			prices[3],
+			prices[4],
+		)
+		if err != nil {
+			return nil, errors.Wrap(domain.ErrInvalid, err, "invalid candle", errors.WithMetadata("line", line))
+		}
+
+		candles = append(candles, candle)
+	}
+
+	return candles, nil
+}
+
+func klineTime(value int64) time.Time {
+	/** Microseconds have 16 digits */
+	if value > 1e14 {
+		return time.UnixMicro(value).UTC()
+	}
+
+	return time.UnixMilli(value).UTC()
+}
@@ -0,0 +1,31 @@
+package infrastructure
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadCandlesCSV(t *testing.T) {
+	file := `open_time,open,high,low,close,volume,close_time,quote_volume,count,taker_buy_volume,taker_buy_quote_volume,ignore
+1704067200000,42283.58,42298.62,42261.02,42298.61,35.92724,1704067259999,1519032.31,1327,20.98,887068.12,0
+1735689600000000,93576.00,93610.93,93537.50,93610.93,8.21827,1735689659999999,768978.21,2042,4.50,421398.35,0
+`
+
+	candles, err := ReadCandlesCSV(strings.NewReader(file), "BTCUSDT", "1m")
+	assert.NoError(t, err)
+	assert.Len(t, candles, 2)
+
+	assert.Equal(t, "BTCUSDT", candles[0].Symbol)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), candles[0].OpenTime)
+	assert.Equal(t, "42298.61", candles[0].Close.String())
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), candles[1].OpenTime)
+
+	t.Run("invalid candle", func(t *testing.T) {
+		_, err := ReadCandlesCSV(strings.NewReader("1704067200000,10,9,8,10,1,1704067259999\n"), "BTCUSDT", "1m")
+		assert.ErrorIs(t, err, domain.ErrInvalid)
+	})
+}
@@ -0,0 +1,476 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/logs"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// Prefix of the exchange id of simulated orders, which is derived from the
+	// client id so it stays unique across restarts.
+	paperOrderIdPrefix = "paper-"
+
+	// Decimals of the quantity bought by a MARKET buy, rounded down so it never
+	// spends more than its quote amount.
+	paperQuantityPrecision = 8
+)
+
+type PaperConfig struct {
+	// Fee rate for LIMIT orders filled from the book.
+	MakerFee decimal.Decimal
+	// Fee rate for MARKET orders and LIMIT orders filled when placed.
+	TakerFee decimal.Decimal
+	// Fraction of the price MARKET orders move against us.
+	Slippage decimal.Decimal
+	Balances map[string]decimal.Decimal
+}
+
+type priceProvider interface {
+	GetPrice(ctx context.Context, baseCurrency string, quoteCurrency string) (*domain.Price, error)
+}
+
+// paperRepository simulates an exchange against live prices. It keeps our
+// orders in an in-memory book, fills LIMIT orders when a price fed through
+// GetPrice crosses them and tracks the balance of every currency, charging the
+// fee in the currency received like Binance does without BNB.
+//
+// Nothing is persisted by it: after a restart Restore rebuilds the balances
+// and the book from the orders the bots saved.
+type paperRepository struct {
+	prices priceProvider
+	config PaperConfig
+
+	mu           sync.Mutex
+	orders       map[string]*paperOrder
+	clientOrders map[models.ID]string
+	balances     map[string]decimal.Decimal
+	locked       map[string]decimal.Decimal
+}
+
+type paperOrder struct {
+	externalId          string
+	symbol              domain.Symbol
+	side                string
+	orderType           string
+	quantity            decimal.Decimal
+	price               decimal.Decimal
+	status              string
+	executedQuantity    decimal.Decimal
+	executedQuoteAmount decimal.Decimal
+	fee                 decimal.Decimal
+	feeCurrency         string
+	reserved            bool
+}
+
+func NewPaperRepo(prices priceProvider, config PaperConfig) (*paperRepository, error) {
+	balances := make(map[string]decimal.Decimal)
+	for currency, amount := range config.Balances {
+		balances[currency] = amount
+	}
+
+	return &paperRepository{
+		prices:       prices,
+		config:       config,
+		orders:       make(map[string]*paperOrder),
+		clientOrders: make(map[models.ID]string),
+		balances:     balances,
+		locked:       make(map[string]decimal.Decimal),
+	}, nil
+}
+
+// GetPrice returns the live price and fills the LIMIT orders it crosses.
+func (r *paperRepository) GetPrice(ctx context.Context, baseCurrency string, quoteCurrency string) (*domain.Price, error) {
+	price, err := r.prices.GetPrice(ctx, baseCurrency, quoteCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.FeedPrice(ctx, baseCurrency, quoteCurrency, price.Price); err != nil {
+		return nil, err
+	}
+
+	return price, nil
+}
+
+// FeedPrice fills the LIMIT orders of the pair crossed by a price seen outside
+// GetPrice, like a trade from the market data stream.
+func (r *paperRepository) FeedPrice(ctx context.Context, baseCurrency string, quoteCurrency string, price decimal.Decimal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	symbol := domain.Symbol{Base: baseCurrency, Quote: quoteCurrency}
+	for _, order := range r.orders {
+		if order.symbol != symbol || order.status != domain.OrderStatusOpen {
+			continue
+		}
+
+		if crosses(order, price) {
+			r.fill(order, order.price, r.config.MakerFee)
+			r.logFill(ctx, order)
+		}
+	}
+
+	return nil
+}
+
+func (r *paperRepository) CreateOrderInProvider(ctx context.Context, order *domain.Order, botName string) (string, error) {
+	symbol, err := domain.ParseSymbol(order.Symbol)
+	if err != nil {
+		return "", err
+	}
+
+	if !order.Quantity.IsPositive() {
+		return "", errors.New(domain.ErrInvalid, "quantity must be positive", errors.WithMetadata("id", order.ID))
+	}
+
+	price, err := r.prices.GetPrice(ctx, symbol.Base, symbol.Quote)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.clientOrders[order.ID]; ok {
+		return "", errors.New(domain.ErrInvalid, "duplicate order", errors.WithMetadata("id", order.ID))
+	}
+
+	paper := &paperOrder{
+		externalId: paperOrderIdPrefix + order.ID.String(),
+		symbol:     symbol,
+		side:       order.Side,
+		orderType:  order.Type,
+		quantity:   order.Quantity,
+		price:      order.EntryPrice,
+		status:     domain.OrderStatusOpen,
+	}
+
+	switch paper.orderType {
+	case domain.OrderTypeMarket:
+		fillPrice := price.Price.Mul(decimal.NewFromInt(1).Add(r.config.Slippage))
+		if paper.side == domain.OrderSideSell {
+			fillPrice = price.Price.Mul(decimal.NewFromInt(1).Sub(r.config.Slippage))
+		} else {
+			/** Buys spend their quote amount like Binance does with quoteOrderQty */
+			paper.quantity, _ = order.InitialQuoteAmount.QuoRem(fillPrice, paperQuantityPrecision)
+		}
+
+		if err := r.checkBalance(paper, fillPrice); err != nil {
+			return "", err
+		}
+
+		r.fill(paper, fillPrice, r.config.TakerFee)
+	case domain.OrderTypeLimit:
+		if err := r.checkBalance(paper, paper.price); err != nil {
+			return "", err
+		}
+
+		r.reserve(paper)
+		if crosses(paper, price.Price) {
+			r.fill(paper, price.Price, r.config.TakerFee)
+		}
+	default:
+		return "", errors.New(domain.ErrInvalid, "invalid order type", errors.WithMetadata("type", order.Type))
+	}
+
+	r.orders[paper.externalId] = paper
+	r.clientOrders[order.ID] = paper.externalId
+
+	logs.Info(ctx, fmt.Sprintf("%s: [PAPER] Orden %s %s %s %s a %s %s", botName, paper.side, paper.orderType, paper.quantity.String(), symbol.Base, paper.price.String(), symbol.Quote))
+	if paper.status == domain.OrderStatusCompleted {
+		r.logFill(ctx, paper)
+	}
+
+	return paper.externalId, nil
+}
+
+func (r *paperRepository) CancelOrderInProvider(ctx context.Context, order *domain.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	paper, err := r.find(order)
+	if err != nil {
+		return err
+	}
+
+	if paper.status != domain.OrderStatusOpen {
+		return errors.New(domain.ErrInvalid, "order is not open", errors.WithMetadata("id", order.ID))
+	}
+
+	r.release(paper)
+	paper.status = domain.OrderStatusCanceled
+
+	return nil
+}
+
+func (r *paperRepository) GetOrderFromProvider(ctx context.Context, order *domain.Order) (*domain.ProviderOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	paper, err := r.find(order)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ProviderOrder{
+		ExternalId:          paper.externalId,
+		Status:              paper.status,
+		ExecutedQuantity:    paper.executedQuantity,
+		ExecutedQuoteAmount: paper.executedQuoteAmount,
+		Fee:                 paper.fee,
+		FeeCurrency:         paper.feeCurrency,
+	}, nil
+}
+
+// Balances returns the free balance of every currency.
+func (r *paperRepository) Balances() map[string]decimal.Decimal {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	balances := make(map[string]decimal.Decimal, len(r.balances))
+	for currency, amount := range r.balances {
+		balances[currency] = amount
+	}
+
+	return balances
+}
+
+func (r *paperRepository) find(order *domain.Order) (*paperOrder, error) {
+	externalId, ok := r.clientOrders[order.ID]
+	if order.ExternalId != nil {
+		externalId, ok = *order.ExternalId, true
+	}
+
+	paper, found := r.orders[externalId]
+	if !ok || !found {
+		return nil, errors.New(domain.ErrNotFound, "order not found", errors.WithMetadata("id", order.ID))
+	}
+
+	return paper, nil
+}
+
+// Restore rebuilds the state of the exchange from the orders placed in it
+// before a restart: the balances start from the configuration and move with
+// every execution, and the orders not final yet go back to the book. LIMIT
+// orders reserve their balance again and MARKET orders, filled when they were
+// placed, are filled at their estimated price.
+func (r *paperRepository) Restore(ctx context.Context, orders []*domain.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, order := range orders {
+		if order.ExternalId == nil {
+			continue
+		}
+
+		symbol, err := domain.ParseSymbol(order.Symbol)
+		if err != nil {
+			return err
+		}
+
+		if order.IsFinal() {
+			r.replay(order, symbol)
+			continue
+		}
+
+		paper := &paperOrder{
+			externalId: *order.ExternalId,
+			symbol:     symbol,
+			side:       order.Side,
+			orderType:  order.Type,
+			quantity:   order.Quantity,
+			price:      order.EntryPrice,
+			status:     domain.OrderStatusOpen,
+		}
+
+		if paper.orderType == domain.OrderTypeMarket {
+			r.fill(paper, paper.price, r.config.TakerFee)
+		} else {
+			r.reserve(paper)
+		}
+
+		r.orders[paper.externalId] = paper
+		r.clientOrders[order.ID] = paper.externalId
+	}
+
+	logs.Info(ctx, fmt.Sprintf("[PAPER] %d órdenes restauradas", len(orders)))
+
+	return nil
+}
+
+// replay moves the balances with the execution of a final order.
+func (r *paperRepository) replay(order *domain.Order, symbol domain.Symbol) {
+	spent, received := symbol.Quote, symbol.Base
+	spentAmount, receivedAmount := order.ExecutedQuoteAmount, order.ExecutedQuantity
+	if order.Side == domain.OrderSideSell {
+		spent, received = symbol.Base, symbol.Quote
+		spentAmount, receivedAmount = order.ExecutedQuantity, order.ExecutedQuoteAmount
+	}
+
+	r.balances[spent] = r.balances[spent].Sub(spentAmount)
+	r.balances[received] = r.balances[received].Add(receivedAmount)
+	if order.FeeCurrency != "" {
+		r.balances[order.FeeCurrency] = r.balances[order.FeeCurrency].Sub(order.Fee)
+	}
+}
+
+func crosses(order *paperOrder, price decimal.Decimal) bool {
+	if order.side == domain.OrderSideSell {
+		return price.GreaterThanOrEqual(order.price)
+	}
+
+	return price.LessThanOrEqual(order.price)
+}
+
+// checkBalance verifies there is enough free balance of the currency the order
+// spends.
+func (r *paperRepository) checkBalance(order *paperOrder, price decimal.Decimal) error {
+	currency, amount := order.symbol.Quote, order.quantity.Mul(price)
+	if order.side == domain.OrderSideSell {
+		currency, amount = order.symbol.Base, order.quantity
+	}
+
+	if r.balances[currency].LessThan(amount) {
+		return errors.New(
+			domain.ErrInvalid,
+			"insufficient balance",
+			errors.WithMetadata("currency", currency),
+			errors.WithMetadata("balance", r.balances[currency].String()),
+			errors.WithMetadata("amount", amount.String()),
+		)
+	}
+
+	return nil
+}
+
+func (r *paperRepository) reservation(order *paperOrder) (string, decimal.Decimal) {
+	if order.side == domain.OrderSideSell {
+		return order.symbol.Base, order.quantity
+	}
+
+	return order.symbol.Quote, order.quantity.Mul(order.price)
+}
+
+func (r *paperRepository) reserve(order *paperOrder) {
+	currency, amount := r.reservation(order)
+	r.balances[currency] = r.balances[currency].Sub(amount)
+	r.locked[currency] = r.locked[currency].Add(amount)
+	order.reserved = true
+}
+
+func (r *paperRepository) release(order *paperOrder) {
+	if !order.reserved {
+		return
+	}
+
+	currency, amount := r.reservation(order)
+	r.balances[currency] = r.balances[currency].Add(amount)
+	r.locked[currency] = r.locked[currency].Sub(amount)
+	order.reserved = false
+}
+
+// fill executes the whole order at the given price, moving the balances and
+// charging the fee in the currency received.
+func (r *paperRepository) fill(order *paperOrder, price decimal.Decimal, feeRate decimal.Decimal) {
+	r.release(order)
+
+	quoteAmount := order.quantity.Mul(price)
+	if order.side == domain.OrderSideSell {
+		order.fee = quoteAmount.Mul(feeRate)
+		order.feeCurrency = order.symbol.Quote
+		r.balances[order.symbol.Base] = r.balances[order.symbol.Base].Sub(order.quantity)
+		r.balances[order.symbol.Quote] = r.balances[order.symbol.Quote].Add(quoteAmount).Sub(order.fee)
+	} else {
+		order.fee = order.quantity.Mul(feeRate)
+		order.feeCurrency = order.symbol.Base
+		r.balances[order.symbol.Quote] = r.balances[order.symbol.Quote].Sub(quoteAmount)
+		r.balances[order.symbol.Base] = r.balances[order.symbol.Base].Add(order.quantity).Sub(order.fee)
+	}
+
+	order.executedQuantity = order.quantity
+	order.executedQuoteAmount = quoteAmount
+	order.status = domain.OrderStatusCompleted
+}
+
+func (r *paperRepository) logFill(ctx context.Context, order *paperOrder) {
+	logs.Info(ctx, fmt.Sprintf(
+		"[PAPER] Orden %s ejecutada: %s %s %s por %s %s (fee: %s %s), saldo: %s %s, %s %s",
+		order.externalId,
+		order.side,
+		order.executedQuantity.String(),
+		order.symbol.Base,
+		order.executedQuoteAmount.String(),
+		order.symbol.Quote,
+		order.fee.String(),
+		order.feeCurrency,
+		r.balances[order.symbol.Base].String(),
+		order.symbol.Base,
+		r.balances[order.symbol.Quote].String(),
+		order.symbol.Quote,
+	))
+}
+
+// replayRepository is the paper exchange fed with historical prices instead of
+// live ones, used by backtests.
+type replayRepository struct {
+	*paperRepository
+	prices *replayPrices
+}
+
+func NewReplayRepo(config PaperConfig) (*replayRepository, error) {
+	prices := &replayPrices{
+		prices: make(map[domain.Symbol]decimal.Decimal),
+	}
+
+	paper, err := NewPaperRepo(prices, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &replayRepository{
+		paperRepository: paper,
+		prices:          prices,
+	}, nil
+}
+
+func (r *replayRepository) FeedPrice(ctx context.Context, baseCurrency string, quoteCurrency string, price decimal.Decimal) error {
+	r.prices.set(baseCurrency, quoteCurrency, price)
+
+	return r.paperRepository.FeedPrice(ctx, baseCurrency, quoteCurrency, price)
+}
+
+type replayPrices struct {
+	mu     sync.Mutex
+	prices map[domain.Symbol]decimal.Decimal
+}
+
+func (p *replayPrices) set(baseCurrency string, quoteCurrency string, price decimal.Decimal) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prices[domain.Symbol{Base: baseCurrency, Quote: quoteCurrency}] = price
+}
+
+func (p *replayPrices) GetPrice(ctx context.Context, baseCurrency string, quoteCurrency string) (*domain.Price, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	price, ok := p.prices[domain.Symbol{Base: baseCurrency, Quote: quoteCurrency}]
+	if !ok {
+		return nil, errors.New(
+			domain.ErrNotFound,
+			"price was not fed",
+			errors.WithMetadata("base_currency", baseCurrency),
+			errors.WithMetadata("quote_currency", quoteCurrency),
+		)
+	}
+
+	return domain.NewPrice(baseCurrency, quoteCurrency, price)
+}
@@ -0,0 +1,221 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type fixedPrices struct {
+	price decimal.Decimal
+}
+
+func (p *fixedPrices) GetPrice(ctx context.Context, baseCurrency string, quoteCurrency string) (*domain.Price, error) {
+	return domain.NewPrice(baseCurrency, quoteCurrency, p.price)
+}
+
+func newPaperOrder(t *testing.T, id string, side string, orderType string, quantity string, price string) *domain.Order {
+	order, err := domain.NewOrder(
+		models.ID(id),
+		models.ID("bot-id-1"),
+		nil,
+		nil,
+		"BTC-USDT",
+		side,
+		orderType,
+		decimal.RequireFromString(quantity),
+		decimal.RequireFromString(quantity).Mul(decimal.RequireFromString(price)),
+		decimal.Zero,
+		decimal.RequireFromString(price),
+		decimal.RequireFromString(price),
+		decimal.Zero,
+		decimal.Zero,
+		decimal.Zero,
+		"",
+		decimal.Zero,
+		decimal.Zero,
+		decimal.Zero,
+		"",
+		nil,
+		domain.OrderStatusPending,
+		0,
+		decimal.Zero,
+		nil,
+		models.CreateTimestamps(),
+		models.CreateVersion(),
+	)
+	assert.NoError(t, err)
+	return order
+}
+
+func TestPaperRepo(t *testing.T) {
+	ctx := context.Background()
+	prices := &fixedPrices{price: decimal.NewFromInt(50000)}
+
+	repo, err := NewPaperRepo(prices, PaperConfig{
+		MakerFee: decimal.RequireFromString("0.001"),
+		TakerFee: decimal.RequireFromString("0.002"),
+		Slippage: decimal.RequireFromString("0.01"),
+		Balances: map[string]decimal.Decimal{"USDT": decimal.NewFromInt(1000)},
+	})
+	assert.NoError(t, err)
+
+	/** MARKET buy spends its quote amount at once with slippage and taker fee
+	in the base currency */
+	buy := newPaperOrder(t, "buy-1", domain.OrderSideBuy, domain.OrderTypeMarket, "0.01", "50500")
+	externalId, err := repo.CreateOrderInProvider(ctx, buy, "TEST")
+	assert.NoError(t, err)
+	buy.AddExternalId(externalId)
+
+	providerOrder, err := repo.GetOrderFromProvider(ctx, buy)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OrderStatusCompleted, providerOrder.Status)
+	assert.Equal(t, "505", providerOrder.ExecutedQuoteAmount.String())
+	assert.Equal(t, "0.00002", providerOrder.Fee.String())
+	assert.Equal(t, "BTC", providerOrder.FeeCurrency)
+	assert.Equal(t, "495", repo.Balances()["USDT"].String())
+	assert.Equal(t, "0.00998", repo.Balances()["BTC"].String())
+
+	/** LIMIT sell rests in the book until the price crosses it */
+	sell := newPaperOrder(t, "sell-1", domain.OrderSideSell, domain.OrderTypeLimit, "0.00998", "51000")
+	_, err = repo.CreateOrderInProvider(ctx, sell, "TEST")
+	assert.NoError(t, err)
+
+	providerOrder, err = repo.GetOrderFromProvider(ctx, sell)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OrderStatusOpen, providerOrder.Status)
+	assert.Equal(t, "0", repo.Balances()["BTC"].String())
+
+	prices.price = decimal.NewFromInt(51500)
+	_, err = repo.GetPrice(ctx, "BTC", "USDT")
+	assert.NoError(t, err)
+
+	providerOrder, err = repo.GetOrderFromProvider(ctx, sell)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OrderStatusCompleted, providerOrder.Status)
+	assert.Equal(t, "508.98", providerOrder.ExecutedQuoteAmount.String())
+	assert.Equal(t, "0.50898", providerOrder.Fee.String())
+	assert.Equal(t, "USDT", providerOrder.FeeCurrency)
+	assert.Equal(t, "1003.47102", repo.Balances()["USDT"].String())
+
+	t.Run("insufficient balance", func(t *testing.T) {
+		order := newPaperOrder(t, "buy-2", domain.OrderSideBuy, domain.OrderTypeMarket, "1", "51500")
+		_, err := repo.CreateOrderInProvider(ctx, order, "TEST")
+		assert.ErrorIs(t, err, domain.ErrInvalid)
+	})
+
+	t.Run("cancel releases the balance", func(t *testing.T) {
+		order := newPaperOrder(t, "buy-3", domain.OrderSideBuy, domain.OrderTypeLimit, "0.01", "40000")
+		_, err := repo.CreateOrderInProvider(ctx, order, "TEST")
+		assert.NoError(t, err)
+		assert.Equal(t, "603.47102", repo.Balances()["USDT"].String())
+
+		assert.NoError(t, repo.CancelOrderInProvider(ctx, order))
+		assert.Equal(t, "1003.47102", repo.Balances()["USDT"].String())
+
+		providerOrder, err := repo.GetOrderFromProvider(ctx, order)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OrderStatusCanceled, providerOrder.Status)
+	})
+
+	t.Run("unknown order", func(t *testing.T) {
+		_, err := repo.GetOrderFromProvider(ctx, newPaperOrder(t, "missing", domain.OrderSideBuy, domain.OrderTypeMarket, "1", "1"))
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("quote amount", func(t *testing.T) {
+		/** The quantity bought at the price with slippage is rounded down, never
+		spending more than the quote amount */
+		order := newPaperOrder(t, "buy-4", domain.OrderSideBuy, domain.OrderTypeMarket, "0.001", "30000")
+		externalId, err := repo.CreateOrderInProvider(ctx, order, "TEST")
+		assert.NoError(t, err)
+		order.AddExternalId(externalId)
+
+		providerOrder, err := repo.GetOrderFromProvider(ctx, order)
+		assert.NoError(t, err)
+		assert.Equal(t, "0.00057675", providerOrder.ExecutedQuantity.String())
+		assert.True(t, providerOrder.ExecutedQuoteAmount.LessThanOrEqual(order.InitialQuoteAmount))
+		assert.Equal(t, "29.99965125", providerOrder.ExecutedQuoteAmount.String())
+	})
+}
+
+// assertSameBalances compares the balances by value, the decimals restored from
+// the database don't keep the trailing zeros.
+func assertSameBalances(t *testing.T, expected *paperRepository, actual *paperRepository) {
+	assert.Len(t, actual.Balances(), len(expected.Balances()))
+	for currency, balance := range expected.Balances() {
+		assert.True(t, balance.Equal(actual.Balances()[currency]), currency)
+	}
+}
+
+func TestPaperRepoRestore(t *testing.T) {
+	ctx := context.Background()
+	orderRepo, err := NewSQLiteOrderRepo(newTestDB(t))
+	assert.NoError(t, err)
+	config := PaperConfig{
+		MakerFee: decimal.RequireFromString("0.001"),
+		TakerFee: decimal.RequireFromString("0.002"),
+		Balances: map[string]decimal.Decimal{"USDT": decimal.NewFromInt(1000)},
+	}
+
+	prices := &fixedPrices{price: decimal.NewFromInt(50000)}
+	repo, err := NewPaperRepo(prices, config)
+	assert.NoError(t, err)
+
+	orders := []*domain.Order{
+		newPaperOrder(t, "buy-1", domain.OrderSideBuy, domain.OrderTypeMarket, "0.01", "50000"),
+		newPaperOrder(t, "sell-1", domain.OrderSideSell, domain.OrderTypeLimit, "0.005", "51000"),
+		newPaperOrder(t, "buy-2", domain.OrderSideBuy, domain.OrderTypeLimit, "0.002", "40000"),
+	}
+	for _, order := range orders {
+		externalId, err := repo.CreateOrderInProvider(ctx, order, "TEST")
+		assert.NoError(t, err)
+		order.AddExternalId(externalId)
+
+		providerOrder, err := repo.GetOrderFromProvider(ctx, order)
+		assert.NoError(t, err)
+		order.Update(providerOrder)
+		assert.NoError(t, orderRepo.Save(ctx, order))
+	}
+	assert.Equal(t, "420", repo.Balances()["USDT"].String())
+	assert.Equal(t, "0.00498", repo.Balances()["BTC"].String())
+
+	/** Live orders and orders never placed are left out */
+	live := newPaperOrder(t, "live-1", domain.OrderSideBuy, domain.OrderTypeMarket, "0.01", "50000")
+	live.AddExternalId("12345")
+	assert.NoError(t, orderRepo.Save(ctx, live))
+	assert.NoError(t, orderRepo.Save(ctx, newPaperOrder(t, "pending-1", domain.OrderSideBuy, domain.OrderTypeMarket, "0.01", "50000")))
+
+	/** After the restart the balances and the book are the same */
+	paperOrders, err := orderRepo.FindPaperOrders(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, paperOrders, 3)
+
+	restored, err := NewPaperRepo(prices, config)
+	assert.NoError(t, err)
+	assert.NoError(t, restored.Restore(ctx, paperOrders))
+	assertSameBalances(t, repo, restored)
+
+	for _, order := range paperOrders[1:] {
+		providerOrder, err := restored.GetOrderFromProvider(ctx, order)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OrderStatusOpen, providerOrder.Status)
+	}
+
+	/** The restored LIMIT sell fills as the original one */
+	prices.price = decimal.NewFromInt(51500)
+	for _, paper := range []*paperRepository{repo, restored} {
+		_, err := paper.GetPrice(ctx, "BTC", "USDT")
+		assert.NoError(t, err)
+	}
+	assertSameBalances(t, repo, restored)
+	assert.Equal(t, "674.745", restored.Balances()["USDT"].String())
+
+	/** Canceling a restored LIMIT buy releases its balance */
+	assert.NoError(t, restored.CancelOrderInProvider(ctx, paperOrders[2]))
+	assert.Equal(t, "754.745", restored.Balances()["USDT"].String())
+}
@@ -0,0 +1,182 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/logs"
+)
+
+type PriceHubConfig struct {
+	// Age up to which a cached price is returned without asking the source.
+	MaxAge time.Duration
+	// Age up to which a cached price is returned when the source fails. Older
+	// prices are never returned.
+	MaxStaleness time.Duration
+	// Wait for more pairs before asking the source, so the pairs asked for by
+	// many bots at once go in a single request.
+	BatchWindow time.Duration
+}
+
+// priceHub shares the prices among every bot. Bots asking for the same pair at
+// the same time wait for a single request, the prices are cached for a while
+// and the pairs asked for within the batch window are fetched together.
+type priceHub struct {
+	source domain.PricesProvider
+	config PriceHubConfig
+
+	mu        sync.Mutex
+	cache     map[domain.CurrencyPair]cachedPrice
+	pending   map[domain.CurrencyPair]*priceCall
+	batch     []domain.CurrencyPair
+	scheduled bool
+}
+
+type cachedPrice struct {
+	price     *domain.Price
+	fetchedAt time.Time
+}
+
+// priceCall is a pair waiting in the batch or being fetched.
+type priceCall struct {
+	done  chan struct{}
+	price *domain.Price
+	err   error
+}
+
+func NewPriceHub(source domain.PricesProvider, config PriceHubConfig) *priceHub {
+	return &priceHub{
+		source:  source,
+		config:  config,
+		cache:   make(map[domain.CurrencyPair]cachedPrice),
+		pending: make(map[domain.CurrencyPair]*priceCall),
+	}
+}
+
+func (h *priceHub) GetPrice(ctx context.Context, baseCurrency string, quoteCurrency string) (*domain.Price, error) {
+	pair := domain.CurrencyPair{BaseCurrency: baseCurrency, QuoteCurrency: quoteCurrency}
+
+	h.mu.Lock()
+	if cached, ok := h.cache[pair]; ok && time.Since(cached.fetchedAt) <= h.config.MaxAge {
+		h.mu.Unlock()
+		return cached.price, nil
+	}
+
+	call, ok := h.pending[pair]
+	if !ok {
+		call = &priceCall{done: make(chan struct{})}
+		h.pending[pair] = call
+		h.batch = append(h.batch, pair)
+
+		if !h.scheduled {
+			h.scheduled = true
+			time.AfterFunc(h.config.BatchWindow, h.flush)
+		}
+	}
+	h.mu.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return nil, errors.Wrap(domain.ErrInternal, ctx.Err(), "price request canceled")
+	}
+
+	if call.err == nil {
+		return call.price, nil
+	}
+
+	h.mu.Lock()
+	cached, ok := h.cache[pair]
+	h.mu.Unlock()
+
+	if !ok || time.Since(cached.fetchedAt) > h.config.MaxStaleness {
+		return nil, call.err
+	}
+
+	logs.Warn(ctx, fmt.Sprintf("using the %s%s price from %s ago", baseCurrency, quoteCurrency, time.Since(cached.fetchedAt).Round(time.Second)), logs.NewAttr("error", call.err))
+
+	return cached.price, nil
+}
+
+// flush fetches every pair in the batch in one request and hands the result
+// to everyone waiting for them.
+func (h *priceHub) flush() {
+	h.mu.Lock()
+	pairs := h.batch
+	h.batch = nil
+	h.scheduled = false
+	h.mu.Unlock()
+
+	/** The request outlives the callers, that may give up waiting */
+	ctx := context.Background()
+	prices, err := h.source.GetPrices(ctx, pairs)
+
+	errs := make(map[domain.CurrencyPair]error)
+	if errors.Is(err, domain.ErrNotFound) && len(pairs) > 1 {
+		/** A single unknown pair fails the whole batch, so they are asked
+		for one by one to fail only that one */
+		prices, err = nil, nil
+		for _, pair := range pairs {
+			pairPrices, pairErr := h.source.GetPrices(ctx, []domain.CurrencyPair{pair})
+			if pairErr != nil {
+				errs[pair] = pairErr
+			}
+			prices = append(prices, pairPrices...)
+		}
+	}
+
+	byPair := make(map[domain.CurrencyPair]*domain.Price, len(prices))
+	for _, price := range prices {
+		byPair[domain.CurrencyPair{BaseCurrency: price.BaseCurrency, QuoteCurrency: price.QuoteCurrency}] = price
+	}
+
+	now := time.Now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, pair := range pairs {
+		call := h.pending[pair]
+		delete(h.pending, pair)
+
+		switch price, ok := byPair[pair]; {
+		case err != nil:
+			call.err = err
+		case errs[pair] != nil:
+			call.err = errs[pair]
+		case !ok:
+			call.err = errors.New(
+				domain.ErrNotFound,
+				"price not found",
+				errors.WithMetadata("base_currency", pair.BaseCurrency),
+				errors.WithMetadata("quote_currency", pair.QuoteCurrency),
+			)
+		default:
+			call.price = price
+			h.cache[pair] = cachedPrice{price: price, fetchedAt: now}
+		}
+
+		close(call.done)
+	}
+}
+
+// hubProvider is a provider that takes its prices from the hub.
+type hubProvider struct {
+	domain.ProviderRepository
+	hub *priceHub
+}
+
+func WithPriceHub(provider domain.ProviderRepository, hub *priceHub) domain.ProviderRepository {
+	return &hubProvider{
+		ProviderRepository: provider,
+		hub:                hub,
+	}
+}
+
+func (p *hubProvider) GetPrice(ctx context.Context, baseCurrency string, quoteCurrency string) (*domain.Price, error) {
+	return p.hub.GetPrice(ctx, baseCurrency, quoteCurrency)
+}
@@ -0,0 +1,122 @@
+package infrastructure
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePricesProvider struct {
+	mu      sync.Mutex
+	calls   [][]domain.CurrencyPair
+	prices  map[string]decimal.Decimal
+	failing bool
+}
+
+func (p *fakePricesProvider) GetPrices(ctx context.Context, pairs []domain.CurrencyPair) ([]*domain.Price, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls = append(p.calls, pairs)
+	if p.failing {
+		return nil, errors.New(domain.ErrInternal, "exchange down")
+	}
+
+	var prices []*domain.Price
+	for _, pair := range pairs {
+		price, ok := p.prices[pair.BaseCurrency+pair.QuoteCurrency]
+		if !ok {
+			return nil, errors.New(domain.ErrNotFound, "invalid symbol")
+		}
+		prices = append(prices, &domain.Price{BaseCurrency: pair.BaseCurrency, QuoteCurrency: pair.QuoteCurrency, Price: price})
+	}
+
+	return prices, nil
+}
+
+func (p *fakePricesProvider) callCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.calls)
+}
+
+func TestPriceHub(t *testing.T) {
+	ctx := context.Background()
+	source := &fakePricesProvider{prices: map[string]decimal.Decimal{
+		"BTCUSDT": decimal.NewFromInt(42000),
+		"ETHUSDT": decimal.NewFromInt(2500),
+	}}
+
+	hub := NewPriceHub(source, PriceHubConfig{
+		MaxAge:       time.Hour,
+		MaxStaleness: 2 * time.Hour,
+		BatchWindow:  20 * time.Millisecond,
+	})
+
+	/** Bots asking at once share a single request */
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		base := "BTC"
+		if i%2 == 0 {
+			base = "ETH"
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			price, err := hub.GetPrice(ctx, base, "USDT")
+			assert.NoError(t, err)
+			assert.Equal(t, base, price.BaseCurrency)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, source.callCount())
+	assert.Len(t, source.calls[0], 2)
+
+	/** Cached prices are reused */
+	price, err := hub.GetPrice(ctx, "BTC", "USDT")
+	assert.NoError(t, err)
+	assert.Equal(t, "42000", price.Price.String())
+	assert.Equal(t, 1, source.callCount())
+
+	t.Run("stale prices are used while the source fails", func(t *testing.T) {
+		hub.config.MaxAge = 0
+		source.failing = true
+
+		price, err := hub.GetPrice(ctx, "BTC", "USDT")
+		assert.NoError(t, err)
+		assert.Equal(t, "42000", price.Price.String())
+
+		hub.config.MaxStaleness = 0
+		_, err = hub.GetPrice(ctx, "BTC", "USDT")
+		assert.ErrorIs(t, err, domain.ErrInternal)
+
+		source.failing = false
+	})
+
+	t.Run("an unknown pair does not fail the rest", func(t *testing.T) {
+		hub.config.MaxAge = 0
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := hub.GetPrice(ctx, "XXX", "USDT")
+			assert.ErrorIs(t, err, domain.ErrNotFound)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := hub.GetPrice(ctx, "BTC", "USDT")
+			assert.NoError(t, err)
+		}()
+		wg.Wait()
+	})
+}
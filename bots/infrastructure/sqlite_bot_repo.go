@@ -0,0 +1,448 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+	"github.com/shopspring/decimal"
+)
+
+type sqliteBotRepository struct {
+	db *sqlx.DB
+}
+
+func NewSQLiteBotRepo(db *sqlx.DB) (*sqliteBotRepository, error) {
+	return &sqliteBotRepository{
+		db: db,
+	}, nil
+}
+
+func (r *sqliteBotRepository) FindByID(ctx context.Context, id models.ID) (*domain.Bot, error) {
+	var row botRow
+	err := r.db.GetContext(ctx, &row, "SELECT * FROM bots WHERE id = ? AND deleted_at IS NULL", id.String())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New(domain.ErrNotFound, "bot not found", errors.WithMetadata("id", id))
+		}
+
+		return nil, errors.Wrap(domain.ErrInternal, err, "could not find bot", errors.WithMetadata("id", id))
+	}
+
+	return r.toEntity(ctx, row)
+}
+
+func (r *sqliteBotRepository) FindAll(ctx context.Context) ([]*domain.Bot, error) {
+	var rows []botRow
+	err := r.db.SelectContext(ctx, &rows, "SELECT * FROM bots WHERE deleted_at IS NULL ORDER BY created_at")
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "could not find bots")
+	}
+
+	return r.toEntities(ctx, rows)
+}
+
+func (r *sqliteBotRepository) FindPage(ctx context.Context, offset int, limit int) ([]*domain.Bot, int, error) {
+	var total int
+	err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM bots WHERE deleted_at IS NULL")
+	if err != nil {
+		return nil, 0, errors.Wrap(domain.ErrInternal, err, "could not count bots")
+	}
+
+	var rows []botRow
+	err = r.db.SelectContext(ctx, &rows, "SELECT * FROM bots WHERE deleted_at IS NULL ORDER BY created_at, id LIMIT ? OFFSET ?", limit, offset)
+	if err != nil {
+		return nil, 0, errors.Wrap(domain.ErrInternal, err, "could not find bots")
+	}
+
+	bots, err := r.toEntities(ctx, rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return bots, total, nil
+}
+
+const (
+	insertBotQuery = `
+		INSERT INTO bots (
+			id, name, take_profit_percentaje, initial_capital, available_capital,
+			invested_capital, total_capital, currency, target_currency, delta,
+			monitor_interval_ms, strategy, strategy_params, status, mode, last_sale_price,
+			realized_pnl, fees_paid, stop_loss_type, stop_loss_value, stop_loss_trailing,
+			adaptive_delta_interval, adaptive_delta_period, adaptive_delta_multiplier,
+			adaptive_delta_min, adaptive_delta_max, adaptive_delta_hysteresis,
+			created_at, updated_at, deleted_at, version
+		) VALUES (
+			:id, :name, :take_profit_percentaje, :initial_capital, :available_capital,
+			:invested_capital, :total_capital, :currency, :target_currency, :delta,
+			:monitor_interval_ms, :strategy, :strategy_params, :status, :mode, :last_sale_price,
+			:realized_pnl, :fees_paid, :stop_loss_type, :stop_loss_value, :stop_loss_trailing,
+			:adaptive_delta_interval, :adaptive_delta_period, :adaptive_delta_multiplier,
+			:adaptive_delta_min, :adaptive_delta_max, :adaptive_delta_hysteresis,
+			:created_at, :updated_at, :deleted_at, :version
+		)`
+
+	updateBotQuery = `
+		UPDATE bots SET
+			name = :name,
+			take_profit_percentaje = :take_profit_percentaje,
+			initial_capital = :initial_capital,
+			available_capital = :available_capital,
+			invested_capital = :invested_capital,
+			total_capital = :total_capital,
+			currency = :currency,
+			target_currency = :target_currency,
+			delta = :delta,
+			monitor_interval_ms = :monitor_interval_ms,
+			strategy = :strategy,
+			strategy_params = :strategy_params,
+			mode = :mode,
+			last_sale_price = :last_sale_price,
+			realized_pnl = :realized_pnl,
+			fees_paid = :fees_paid,
+			stop_loss_type = :stop_loss_type,
+			stop_loss_value = :stop_loss_value,
+			stop_loss_trailing = :stop_loss_trailing,
+			adaptive_delta_interval = :adaptive_delta_interval,
+			adaptive_delta_period = :adaptive_delta_period,
+			adaptive_delta_multiplier = :adaptive_delta_multiplier,
+			adaptive_delta_min = :adaptive_delta_min,
+			adaptive_delta_max = :adaptive_delta_max,
+			adaptive_delta_hysteresis = :adaptive_delta_hysteresis,
+			updated_at = :updated_at,
+			version = :version
+		WHERE id = :id AND version = :version - 1`
+
+	updateBotStatusQuery = `
+		UPDATE bots SET
+			status = :status,
+			updated_at = :updated_at,
+			deleted_at = :deleted_at
+		WHERE id = :id AND deleted_at IS NULL`
+)
+
+// Save stores the bot, its open orders and the orders closed since the last
+// save in a single transaction. Every entity is written only if it changed and
+// its stored version is still the one it was loaded with, otherwise ErrConflict
+// is returned and nothing is written. The status and the deletion of the bot
+// are only written on insert, later on they are stored through SaveStatus.
+func (r *sqliteBotRepository) Save(ctx context.Context, bot *domain.Bot) error {
+	row, err := newBotRow(bot)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(domain.ErrInternal, err, "could not begin transaction")
+	}
+	defer tx.Rollback()
+
+	if bot.Version.IsUpdated() {
+		query := updateBotQuery
+		if bot.Version.Value == 1 {
+			query = insertBotQuery
+		}
+
+		res, err := sqlx.NamedExecContext(ctx, tx, query, row)
+		if err != nil {
+			return errors.Wrap(domain.ErrInternal, err, "could not save bot", errors.WithMetadata("id", bot.ID))
+		}
+
+		if err := checkAffectedRows(res, "bot", bot.ID, bot.Version); err != nil {
+			return err
+		}
+	}
+
+	orders := append(append([]*domain.Order{}, bot.OpenOrders...), bot.ClosedOrders...)
+	for _, order := range bot.OpenOrders {
+		if order.TakeProfitOrder != nil {
+			orders = append(orders, order.TakeProfitOrder)
+		}
+	}
+	for _, order := range orders {
+		if err := saveOrder(ctx, tx, order); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(domain.ErrInternal, err, "could not commit transaction")
+	}
+
+	bot.Version = persistedVersion(bot.Version)
+	for _, order := range orders {
+		order.Version = persistedVersion(order.Version)
+	}
+	bot.ClosedOrders = nil
+
+	return nil
+}
+
+// SaveStatus stores the status and the deletion of the bot whatever its
+// stored version, which is left as it is.
+func (r *sqliteBotRepository) SaveStatus(ctx context.Context, bot *domain.Bot) error {
+	row, err := newBotRow(bot)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.db.NamedExecContext(ctx, updateBotStatusQuery, row)
+	if err != nil {
+		return errors.Wrap(domain.ErrInternal, err, "could not save bot status", errors.WithMetadata("id", bot.ID))
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(domain.ErrInternal, err, "could not save bot status", errors.WithMetadata("id", bot.ID))
+	}
+
+	if affected == 0 {
+		return errors.New(domain.ErrNotFound, "bot not found", errors.WithMetadata("id", bot.ID))
+	}
+
+	return nil
+}
+
+func (r *sqliteBotRepository) findOpenOrders(ctx context.Context, botID string) ([]*domain.Order, error) {
+	var rows []orderRow
+	err := r.db.SelectContext(
+		ctx,
+		&rows,
+		"SELECT * FROM orders WHERE bot_id = ? AND closed_at IS NULL AND deleted_at IS NULL ORDER BY created_at",
+		botID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "could not find open orders", errors.WithMetadata("bot_id", botID))
+	}
+
+	orders, err := orderRowsToEntities(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return linkTakeProfitOrders(orders), nil
+}
+
+// linkTakeProfitOrders attaches the open take profit sells to the buys they
+// close and returns only the buys.
+func linkTakeProfitOrders(orders []*domain.Order) []*domain.Order {
+	entries := make(map[models.ID]*domain.Order)
+	for _, order := range orders {
+		if !order.IsTakeProfit() {
+			entries[order.ID] = order
+		}
+	}
+
+	openOrders := make([]*domain.Order, 0, len(entries))
+	for _, order := range orders {
+		if !order.IsTakeProfit() {
+			openOrders = append(openOrders, order)
+			continue
+		}
+
+		if entry, ok := entries[*order.ParentID]; ok {
+			entry.TakeProfitOrder = order
+		}
+	}
+
+	return openOrders
+}
+
+type botRow struct {
+	ID                      string              `db:"id"`
+	Name                    string              `db:"name"`
+	TakeProfitPercentaje    decimal.Decimal     `db:"take_profit_percentaje"`
+	InitialCapital          decimal.Decimal     `db:"initial_capital"`
+	AvailableCapital        decimal.Decimal     `db:"available_capital"`
+	InvestedCapital         decimal.Decimal     `db:"invested_capital"`
+	TotalCapital            decimal.Decimal     `db:"total_capital"`
+	Currency                string              `db:"currency"`
+	TargetCurrency          string              `db:"target_currency"`
+	Delta                   decimal.Decimal     `db:"delta"`
+	MonitorIntervalMs       int64               `db:"monitor_interval_ms"`
+	Strategy                string              `db:"strategy"`
+	StrategyParams          string              `db:"strategy_params"`
+	Status                  string              `db:"status"`
+	Mode                    string              `db:"mode"`
+	LastSalePrice           decimal.NullDecimal `db:"last_sale_price"`
+	RealizedPnL             decimal.Decimal     `db:"realized_pnl"`
+	FeesPaid                decimal.Decimal     `db:"fees_paid"`
+	StopLossType            *string             `db:"stop_loss_type"`
+	StopLossValue           decimal.NullDecimal `db:"stop_loss_value"`
+	StopLossTrailing        bool                `db:"stop_loss_trailing"`
+	AdaptiveDeltaInterval   *string             `db:"adaptive_delta_interval"`
+	AdaptiveDeltaPeriod     int                 `db:"adaptive_delta_period"`
+	AdaptiveDeltaMultiplier decimal.NullDecimal `db:"adaptive_delta_multiplier"`
+	AdaptiveDeltaMin        decimal.NullDecimal `db:"adaptive_delta_min"`
+	AdaptiveDeltaMax        decimal.NullDecimal `db:"adaptive_delta_max"`
+	AdaptiveDeltaHysteresis decimal.NullDecimal `db:"adaptive_delta_hysteresis"`
+	CreatedAt               time.Time           `db:"created_at"`
+	UpdatedAt               time.Time           `db:"updated_at"`
+	DeletedAt               *time.Time          `db:"deleted_at"`
+	Version                 int                 `db:"version"`
+}
+
+func newBotRow(bot *domain.Bot) (botRow, error) {
+	strategyParams, err := json.Marshal(bot.StrategyParams)
+	if err != nil {
+		return botRow{}, errors.Wrap(domain.ErrInternal, err, "could not marshal strategy params", errors.WithMetadata("id", bot.ID))
+	}
+
+	var lastSalePrice decimal.NullDecimal
+	if bot.LastSalePrice != nil {
+		lastSalePrice = decimal.NewNullDecimal(*bot.LastSalePrice)
+	}
+
+	var stopLossType *string
+	var stopLossValue decimal.NullDecimal
+	stopLossTrailing := false
+	if bot.StopLoss != nil {
+		stopLossType = &bot.StopLoss.Type
+		stopLossValue = decimal.NewNullDecimal(bot.StopLoss.Value)
+		stopLossTrailing = bot.StopLoss.Trailing
+	}
+
+	var adaptiveDeltaInterval *string
+	adaptiveDeltaPeriod := 0
+	var adaptiveDeltaMultiplier, adaptiveDeltaMin, adaptiveDeltaMax, adaptiveDeltaHysteresis decimal.NullDecimal
+	if bot.AdaptiveDelta != nil {
+		adaptiveDeltaInterval = &bot.AdaptiveDelta.Interval
+		adaptiveDeltaPeriod = bot.AdaptiveDelta.Period
+		adaptiveDeltaMultiplier = decimal.NewNullDecimal(bot.AdaptiveDelta.Multiplier)
+		adaptiveDeltaMin = decimal.NewNullDecimal(bot.AdaptiveDelta.MinDelta)
+		adaptiveDeltaMax = decimal.NewNullDecimal(bot.AdaptiveDelta.MaxDelta)
+		adaptiveDeltaHysteresis = decimal.NewNullDecimal(bot.AdaptiveDelta.Hysteresis)
+	}
+
+	return botRow{
+		ID:                      bot.ID.String(),
+		Name:                    bot.Name,
+		TakeProfitPercentaje:    bot.TakeProfitPercentaje,
+		InitialCapital:          bot.InitialCapital,
+		AvailableCapital:        bot.AvailableCapital,
+		InvestedCapital:         bot.InvestedCapital,
+		TotalCapital:            bot.TotalCapital,
+		Currency:                bot.Currency,
+		TargetCurrency:          bot.TargetCurrency,
+		Delta:                   bot.Delta,
+		MonitorIntervalMs:       bot.MonitorInterval.Milliseconds(),
+		Strategy:                bot.Strategy,
+		StrategyParams:          string(strategyParams),
+		Status:                  bot.Status,
+		Mode:                    bot.Mode,
+		LastSalePrice:           lastSalePrice,
+		RealizedPnL:             bot.RealizedPnL,
+		FeesPaid:                bot.FeesPaid,
+		StopLossType:            stopLossType,
+		StopLossValue:           stopLossValue,
+		StopLossTrailing:        stopLossTrailing,
+		AdaptiveDeltaInterval:   adaptiveDeltaInterval,
+		AdaptiveDeltaPeriod:     adaptiveDeltaPeriod,
+		AdaptiveDeltaMultiplier: adaptiveDeltaMultiplier,
+		AdaptiveDeltaMin:        adaptiveDeltaMin,
+		AdaptiveDeltaMax:        adaptiveDeltaMax,
+		AdaptiveDeltaHysteresis: adaptiveDeltaHysteresis,
+		CreatedAt:               bot.Timestamps.CreatedAt,
+		UpdatedAt:               bot.Timestamps.UpdatedAt,
+		DeletedAt:               bot.Timestamps.DeletedAt,
+		Version:                 bot.Version.Value,
+	}, nil
+}
+
+func (r *sqliteBotRepository) toEntities(ctx context.Context, rows []botRow) ([]*domain.Bot, error) {
+	bots := make([]*domain.Bot, 0, len(rows))
+	for _, row := range rows {
+		bot, err := r.toEntity(ctx, row)
+		if err != nil {
+			return nil, err
+		}
+		bots = append(bots, bot)
+	}
+
+	return bots, nil
+}
+
+func (r *sqliteBotRepository) toEntity(ctx context.Context, row botRow) (*domain.Bot, error) {
+	strategyParams := domain.StrategyParams{}
+	if err := json.Unmarshal([]byte(row.StrategyParams), &strategyParams); err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "invalid bot strategy params", errors.WithMetadata("id", row.ID))
+	}
+
+	var lastSalePrice *decimal.Decimal
+	if row.LastSalePrice.Valid {
+		lastSalePrice = &row.LastSalePrice.Decimal
+	}
+
+	var stopLoss *domain.StopLoss
+	if row.StopLossType != nil && row.StopLossValue.Valid {
+		var err error
+		stopLoss, err = domain.NewStopLoss(*row.StopLossType, row.StopLossValue.Decimal, row.StopLossTrailing)
+		if err != nil {
+			return nil, errors.Wrap(domain.ErrInternal, err, "invalid bot stop loss", errors.WithMetadata("id", row.ID))
+		}
+	}
+
+	var adaptiveDelta *domain.AdaptiveDelta
+	if row.AdaptiveDeltaInterval != nil {
+		var err error
+		adaptiveDelta, err = domain.NewAdaptiveDelta(
+			*row.AdaptiveDeltaInterval,
+			row.AdaptiveDeltaPeriod,
+			row.AdaptiveDeltaMultiplier.Decimal,
+			row.AdaptiveDeltaMin.Decimal,
+			row.AdaptiveDeltaMax.Decimal,
+			row.AdaptiveDeltaHysteresis.Decimal,
+		)
+		if err != nil {
+			return nil, errors.Wrap(domain.ErrInternal, err, "invalid bot adaptive delta", errors.WithMetadata("id", row.ID))
+		}
+	}
+
+	timestamps, err := models.NewTimestamps(row.CreatedAt, row.UpdatedAt, row.DeletedAt)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "invalid bot timestamps", errors.WithMetadata("id", row.ID))
+	}
+
+	version, err := models.NewVersion(row.Version)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "invalid bot version", errors.WithMetadata("id", row.ID))
+	}
+
+	openOrders, err := r.findOpenOrders(ctx, row.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewBot(
+		models.ID(row.ID),
+		row.Name,
+		row.Currency,
+		row.TargetCurrency,
+		row.TakeProfitPercentaje,
+		row.InitialCapital,
+		row.AvailableCapital,
+		row.InvestedCapital,
+		row.TotalCapital,
+		row.Delta,
+		time.Duration(row.MonitorIntervalMs)*time.Millisecond,
+		row.Strategy,
+		strategyParams,
+		row.Status,
+		row.Mode,
+		openOrders,
+		lastSalePrice,
+		row.RealizedPnL,
+		row.FeesPaid,
+		stopLoss,
+		adaptiveDelta,
+		timestamps,
+		version,
+	)
+}
@@ -0,0 +1,202 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/application"
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBot(t *testing.T) *domain.Bot {
+	bot, err := domain.CreateBot(
+		"test",
+		"USDT",
+		"BTC",
+		decimal.RequireFromString("0.01"),
+		decimal.NewFromInt(1000),
+		decimal.NewFromInt(100),
+		time.Minute,
+		domain.StrategyGrid,
+		nil,
+		domain.BotModePaper,
+		nil,
+		nil,
+	)
+	assert.NoError(t, err)
+	return bot
+}
+
+func TestSQLiteBotRepoStatusDuringCycle(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewSQLiteBotRepo(newTestDB(t))
+	assert.NoError(t, err)
+
+	bot := newTestBot(t)
+	assert.NoError(t, repo.Save(ctx, bot))
+
+	/** The worker loads the bot at the start of its cycle */
+	cycle, err := repo.FindByID(ctx, bot.ID)
+	assert.NoError(t, err)
+
+	/** The bot is paused through the API while the cycle runs */
+	_, err = application.NewPauseBot(repo).Exec(ctx, &application.PauseBotInput{ID: bot.ID})
+	assert.NoError(t, err)
+
+	order, err := cycle.GenerateOrder(decimal.NewFromInt(50000), 500, decimal.NewFromInt(100), nil, domain.FeeSchedule{}, domain.NewRiskManager(domain.RiskLimits{}))
+	assert.NoError(t, err)
+	order.AddExternalId("12345")
+
+	/** The order placed by the cycle is saved and the bot stays paused */
+	assert.NoError(t, repo.Save(ctx, cycle))
+
+	stored, err := repo.FindByID(ctx, bot.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BotStatusPaused, stored.Status)
+	assert.Len(t, stored.OpenOrders, 1)
+	assert.Equal(t, "12345", *stored.OpenOrders[0].ExternalId)
+	assert.Equal(t, "900", stored.AvailableCapital.String())
+	assert.Equal(t, "100", stored.InvestedCapital.String())
+
+	/** Deleted during the next cycle, the cycle is saved but the bot is gone */
+	cycle = stored
+	assert.NoError(t, application.NewDeleteBot(repo).Exec(ctx, &application.DeleteBotInput{ID: bot.ID}))
+	_, err = cycle.GenerateOrder(decimal.NewFromInt(49000), 490, decimal.NewFromInt(100), nil, domain.FeeSchedule{}, domain.NewRiskManager(domain.RiskLimits{}))
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Save(ctx, cycle))
+
+	_, err = repo.FindByID(ctx, bot.ID)
+	assert.True(t, errors.Is(err, domain.ErrNotFound))
+}
+
+func TestSQLiteBotRepo(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewSQLiteBotRepo(newTestDB(t))
+	assert.NoError(t, err)
+	risk := domain.NewRiskManager(domain.RiskLimits{})
+
+	/** Insert */
+	bot := newTestBot(t)
+	assert.NoError(t, repo.Save(ctx, bot))
+	assert.False(t, bot.Version.IsUpdated())
+
+	stored, err := repo.FindByID(ctx, bot.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stored.Version.Value)
+	assert.Equal(t, "test", stored.Name)
+	assert.Equal(t, "1000", stored.AvailableCapital.String())
+	assert.Equal(t, domain.BotStatusActive, stored.Status)
+
+	/** Update */
+	_, err = stored.GenerateOrder(decimal.NewFromInt(50000), 500, decimal.NewFromInt(100), nil, domain.FeeSchedule{}, risk)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Save(ctx, stored))
+	assert.Equal(t, 2, stored.Version.Value)
+
+	stored, err = repo.FindByID(ctx, bot.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stored.Version.Value)
+	assert.Equal(t, "900", stored.AvailableCapital.String())
+	assert.Len(t, stored.OpenOrders, 1)
+
+	/** Saving unchanged writes nothing nor bumps the version */
+	assert.NoError(t, repo.Save(ctx, stored))
+	assert.Equal(t, 2, stored.Version.Value)
+
+	/** Stale version */
+	first, err := repo.FindByID(ctx, bot.ID)
+	assert.NoError(t, err)
+	second, err := repo.FindByID(ctx, bot.ID)
+	assert.NoError(t, err)
+
+	_, err = first.GenerateOrder(decimal.NewFromInt(49000), 490, decimal.NewFromInt(100), nil, domain.FeeSchedule{}, risk)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Save(ctx, first))
+
+	_, err = second.GenerateOrder(decimal.NewFromInt(48000), 480, decimal.NewFromInt(100), nil, domain.FeeSchedule{}, risk)
+	assert.NoError(t, err)
+	err = repo.Save(ctx, second)
+	assert.True(t, errors.Is(err, domain.ErrConflict))
+
+	/** Nothing of the stale save was written */
+	stored, err = repo.FindByID(ctx, bot.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, stored.Version.Value)
+	assert.Equal(t, "800", stored.AvailableCapital.String())
+	assert.Len(t, stored.OpenOrders, 2)
+}
+
+func TestSQLiteBotRepoOrders(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo, err := NewSQLiteBotRepo(db)
+	assert.NoError(t, err)
+	orderRepo, err := NewSQLiteOrderRepo(db)
+	assert.NoError(t, err)
+
+	bot := newTestBot(t)
+	entry, err := bot.GenerateOrder(decimal.NewFromInt(50000), 500, decimal.NewFromInt(100), nil, domain.FeeSchedule{}, domain.NewRiskManager(domain.RiskLimits{}))
+	assert.NoError(t, err)
+	entry.AddExternalId("1")
+	assert.NoError(t, repo.Save(ctx, bot))
+	assert.False(t, entry.Version.IsUpdated())
+
+	/** The buy fills and its take profit is placed */
+	bot.ReconcileOrder(ctx, entry, &domain.ProviderOrder{
+		ExternalId:          "1",
+		Status:              domain.OrderStatusCompleted,
+		ExecutedQuantity:    decimal.RequireFromString("0.002"),
+		ExecutedQuoteAmount: decimal.NewFromInt(100),
+		FeeCurrency:         "USDT",
+	}, domain.FeeSchedule{}, time.Now())
+	takeProfit, err := bot.GenerateTakeProfitOrder(entry, nil)
+	assert.NoError(t, err)
+	takeProfit.AddExternalId("2")
+	assert.NoError(t, repo.Save(ctx, bot))
+
+	/** Every saved order is left ready for its next change */
+	assert.Equal(t, 2, entry.Version.Value)
+	assert.False(t, entry.Version.IsUpdated())
+	assert.Equal(t, 1, takeProfit.Version.Value)
+	assert.False(t, takeProfit.Version.IsUpdated())
+
+	stored, err := repo.FindByID(ctx, bot.ID)
+	assert.NoError(t, err)
+	assert.Len(t, stored.OpenOrders, 1)
+	assert.Equal(t, entry.ID, stored.OpenOrders[0].ID)
+	assert.Equal(t, domain.OrderStatusCompleted, stored.OpenOrders[0].Status)
+	assert.Equal(t, "0.002", stored.OpenOrders[0].Quantity.String())
+	assert.NotNil(t, stored.OpenOrders[0].TakeProfitOrder)
+	assert.Equal(t, takeProfit.ID, stored.OpenOrders[0].TakeProfitOrder.ID)
+	assert.Equal(t, entry.ID, *stored.OpenOrders[0].TakeProfitOrder.ParentID)
+
+	/** The take profit sells the position, both orders are closed */
+	bot.ReconcileOrder(ctx, takeProfit, &domain.ProviderOrder{
+		ExternalId:          "2",
+		Status:              domain.OrderStatusCompleted,
+		ExecutedQuantity:    decimal.RequireFromString("0.002"),
+		ExecutedQuoteAmount: decimal.NewFromInt(101),
+		FeeCurrency:         "USDT",
+	}, domain.FeeSchedule{}, time.Now())
+	assert.Len(t, bot.ClosedOrders, 2)
+	assert.NoError(t, repo.Save(ctx, bot))
+	assert.Empty(t, bot.ClosedOrders)
+
+	stored, err = repo.FindByID(ctx, bot.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, stored.OpenOrders)
+	assert.Equal(t, "1001", stored.AvailableCapital.String())
+	assert.Equal(t, "1", stored.RealizedPnL.String())
+
+	orders, err := orderRepo.FindByBotID(ctx, bot.ID)
+	assert.NoError(t, err)
+	assert.Len(t, orders, 2)
+	for _, order := range orders {
+		assert.True(t, order.IsClosed())
+		assert.Equal(t, domain.OrderStatusCompleted, order.Status)
+	}
+}
@@ -0,0 +1,180 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+)
+
+type sqliteCandleRepository struct {
+	db *sqlx.DB
+}
+
+func NewSQLiteCandleRepo(db *sqlx.DB) (*sqliteCandleRepository, error) {
+	return &sqliteCandleRepository{
+		db: db,
+	}, nil
+}
+
+func (r *sqliteCandleRepository) FindCandles(ctx context.Context, symbol string, interval string, start time.Time, end time.Time) ([]*domain.Candle, error) {
+	var rows []candleRow
+	err := r.db.SelectContext(
+		ctx,
+		&rows,
+		"SELECT * FROM candles WHERE symbol = ? AND interval = ? AND open_time >= ? AND open_time < ? ORDER BY open_time",
+		symbol,
+		interval,
+		start.UTC(),
+		end.UTC(),
+	)
+	if err != nil {
+		return nil, errors.Wrap(
+			domain.ErrInternal,
+			err,
+			"could not find candles",
+			errors.WithMetadata("symbol", symbol),
+			errors.WithMetadata("interval", interval),
+		)
+	}
+
+	candles := make([]*domain.Candle, 0, len(rows))
+	for _, row := range rows {
+		candle, err := row.toEntity()
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, candle)
+	}
+
+	return candles, nil
+}
+
+func (r *sqliteCandleRepository) FindGaps(ctx context.Context, symbol string, interval string, start time.Time, end time.Time) ([]domain.CandleGap, error) {
+	duration, err := domain.CandleIntervalDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	var openTimes []time.Time
+	err = r.db.SelectContext(
+		ctx,
+		&openTimes,
+		"SELECT open_time FROM candles WHERE symbol = ? AND interval = ? AND open_time >= ? AND open_time < ? ORDER BY open_time",
+		symbol,
+		interval,
+		start.UTC(),
+		end.UTC(),
+	)
+	if err != nil {
+		return nil, errors.Wrap(
+			domain.ErrInternal,
+			err,
+			"could not find candles",
+			errors.WithMetadata("symbol", symbol),
+			errors.WithMetadata("interval", interval),
+		)
+	}
+
+	for i := range openTimes {
+		openTimes[i] = openTimes[i].UTC()
+	}
+
+	return domain.FindCandleGaps(openTimes, duration, start.UTC(), end.UTC()), nil
+}
+
+const (
+	upsertCandleQuery = `
+		INSERT INTO candles (
+			symbol, interval, open_time, close_time, open, high, low, close, volume
+		) VALUES (
+			:symbol, :interval, :open_time, :close_time, :open, :high, :low, :close, :volume
+		)
+		ON CONFLICT (symbol, interval, open_time) DO UPDATE SET
+			close_time = excluded.close_time,
+			open = excluded.open,
+			high = excluded.high,
+			low = excluded.low,
+			close = excluded.close,
+			volume = excluded.volume`
+)
+
+func (r *sqliteCandleRepository) SaveCandles(ctx context.Context, candles []*domain.Candle) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(domain.ErrInternal, err, "could not begin transaction")
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareNamedContext(ctx, upsertCandleQuery)
+	if err != nil {
+		return errors.Wrap(domain.ErrInternal, err, "could not prepare candles query")
+	}
+	defer stmt.Close()
+
+	for _, candle := range candles {
+		if _, err := stmt.ExecContext(ctx, newCandleRow(candle)); err != nil {
+			return errors.Wrap(
+				domain.ErrInternal,
+				err,
+				"could not save candle",
+				errors.WithMetadata("symbol", candle.Symbol),
+				errors.WithMetadata("open_time", candle.OpenTime),
+			)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(domain.ErrInternal, err, "could not commit transaction")
+	}
+
+	return nil
+}
+
+type candleRow struct {
+	Symbol    string          `db:"symbol"`
+	Interval  string          `db:"interval"`
+	OpenTime  time.Time       `db:"open_time"`
+	CloseTime time.Time       `db:"close_time"`
+	Open      decimal.Decimal `db:"open"`
+	High      decimal.Decimal `db:"high"`
+	Low       decimal.Decimal `db:"low"`
+	Close     decimal.Decimal `db:"close"`
+	Volume    decimal.Decimal `db:"volume"`
+}
+
+func newCandleRow(candle *domain.Candle) candleRow {
+	return candleRow{
+		Symbol:    candle.Symbol,
+		Interval:  candle.Interval,
+		OpenTime:  candle.OpenTime.UTC(),
+		CloseTime: candle.CloseTime.UTC(),
+		Open:      candle.Open,
+		High:      candle.High,
+		Low:       candle.Low,
+		Close:     candle.Close,
+		Volume:    candle.Volume,
+	}
+}
+
+func (row candleRow) toEntity() (*domain.Candle, error) {
+	candle, err := domain.NewCandle(
+		row.Symbol,
+		row.Interval,
+		row.OpenTime.UTC(),
+		row.CloseTime.UTC(),
+		row.Open,
+		row.High,
+		row.Low,
+		row.Close,
+		row.Volume,
+	)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "invalid candle", errors.WithMetadata("open_time", row.OpenTime))
+	}
+
+	return candle, nil
+}
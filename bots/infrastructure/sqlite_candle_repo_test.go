@@ -0,0 +1,75 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/database"
+	"github.com/juankohler/crypto-bot/libs/go/migrations"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestDB(t *testing.T) *sqlx.DB {
+	db, err := sqlx.Connect("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	/** Every connection to :memory: is a different database */
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db, database.Migrations())
+	assert.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	assert.NoError(t, err)
+
+	return db
+}
+
+func newTestCandle(t *testing.T, openTime time.Time, close string) *domain.Candle {
+	price := decimal.RequireFromString(close)
+	candle, err := domain.NewCandle("BTCUSDT", "1m", openTime, openTime.Add(time.Minute-time.Millisecond), price, price, price, price, decimal.NewFromInt(1))
+	assert.NoError(t, err)
+	return candle
+}
+
+func TestSQLiteCandleRepo(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewSQLiteCandleRepo(newTestDB(t))
+	assert.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Minute)
+
+	gaps, err := repo.FindGaps(ctx, "BTCUSDT", "1m", start, end)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.CandleGap{{Start: start, End: end}}, gaps)
+
+	err = repo.SaveCandles(ctx, []*domain.Candle{
+		newTestCandle(t, start.Add(2*time.Minute), "100"),
+		newTestCandle(t, start.Add(3*time.Minute), "101"),
+		newTestCandle(t, start.Add(7*time.Minute), "102"),
+	})
+	assert.NoError(t, err)
+
+	gaps, err = repo.FindGaps(ctx, "BTCUSDT", "1m", start, end)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.CandleGap{
+		{Start: start, End: start.Add(2 * time.Minute)},
+		{Start: start.Add(4 * time.Minute), End: start.Add(7 * time.Minute)},
+		{Start: start.Add(8 * time.Minute), End: end},
+	}, gaps)
+
+	/** Saving again replaces the stored candle */
+	err = repo.SaveCandles(ctx, []*domain.Candle{newTestCandle(t, start.Add(3*time.Minute), "105")})
+	assert.NoError(t, err)
+
+	candles, err := repo.FindCandles(ctx, "BTCUSDT", "1m", start, end)
+	assert.NoError(t, err)
+	assert.Len(t, candles, 3)
+	assert.Equal(t, start.Add(3*time.Minute), candles[1].OpenTime)
+	assert.Equal(t, "105", candles[1].Close.String())
+}
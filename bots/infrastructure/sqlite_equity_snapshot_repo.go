@@ -0,0 +1,186 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+	"github.com/shopspring/decimal"
+)
+
+type sqliteEquitySnapshotRepository struct {
+	db *sqlx.DB
+}
+
+func NewSQLiteEquitySnapshotRepo(db *sqlx.DB) (*sqliteEquitySnapshotRepository, error) {
+	return &sqliteEquitySnapshotRepository{
+		db: db,
+	}, nil
+}
+
+func (r *sqliteEquitySnapshotRepository) FindByBotID(ctx context.Context, botID models.ID, resolution string, start time.Time, end time.Time) ([]*domain.EquitySnapshot, error) {
+	var rows []equitySnapshotRow
+	err := r.db.SelectContext(
+		ctx,
+		&rows,
+		"SELECT * FROM equity_snapshots WHERE bot_id = ? AND resolution = ? AND time >= ? AND time < ? ORDER BY time",
+		botID.String(),
+		resolution,
+		start.UTC(),
+		end.UTC(),
+	)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "could not find equity snapshots", errors.WithMetadata("bot_id", botID))
+	}
+
+	return equitySnapshotRowsToEntities(rows)
+}
+
+const (
+	upsertEquitySnapshotQuery = `
+		INSERT INTO equity_snapshots (
+			bot_id, resolution, time, available_capital, invested_capital, total_capital,
+			mark_to_market, low_mark_to_market, high_mark_to_market, open_orders
+		) VALUES (
+			:bot_id, :resolution, :time, :available_capital, :invested_capital, :total_capital,
+			:mark_to_market, :low_mark_to_market, :high_mark_to_market, :open_orders
+		)
+		ON CONFLICT (bot_id, resolution, time) DO UPDATE SET
+			available_capital = excluded.available_capital,
+			invested_capital = excluded.invested_capital,
+			total_capital = excluded.total_capital,
+			mark_to_market = excluded.mark_to_market,
+			low_mark_to_market = excluded.low_mark_to_market,
+			high_mark_to_market = excluded.high_mark_to_market,
+			open_orders = excluded.open_orders`
+)
+
+func (r *sqliteEquitySnapshotRepository) Save(ctx context.Context, snapshot *domain.EquitySnapshot) error {
+	if _, err := r.db.NamedExecContext(ctx, upsertEquitySnapshotQuery, newEquitySnapshotRow(snapshot)); err != nil {
+		return errors.Wrap(domain.ErrInternal, err, "could not save equity snapshot", errors.WithMetadata("bot_id", snapshot.BotID))
+	}
+
+	return nil
+}
+
+// Downsample merges only the buckets that ended before the given time, so a
+// bucket is never merged while it may still get snapshots.
+func (r *sqliteEquitySnapshotRepository) Downsample(ctx context.Context, from string, to string, before time.Time) (int, error) {
+	duration, err := domain.EquityResolutionDuration(to)
+	if err != nil {
+		return 0, err
+	}
+	before = before.UTC().Truncate(duration)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(domain.ErrInternal, err, "could not begin transaction")
+	}
+	defer tx.Rollback()
+
+	var rows []equitySnapshotRow
+	err = tx.SelectContext(
+		ctx,
+		&rows,
+		"SELECT * FROM equity_snapshots WHERE resolution = ? AND time < ? ORDER BY bot_id, time",
+		from,
+		before,
+	)
+	if err != nil {
+		return 0, errors.Wrap(domain.ErrInternal, err, "could not find equity snapshots", errors.WithMetadata("resolution", from))
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	snapshots, err := equitySnapshotRowsToEntities(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	downsampled, err := domain.DownsampleEquitySnapshots(snapshots, to)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM equity_snapshots WHERE resolution = ? AND time < ?", from, before)
+	if err != nil {
+		return 0, errors.Wrap(domain.ErrInternal, err, "could not delete equity snapshots", errors.WithMetadata("resolution", from))
+	}
+
+	for _, snapshot := range downsampled {
+		if _, err := sqlx.NamedExecContext(ctx, tx, upsertEquitySnapshotQuery, newEquitySnapshotRow(snapshot)); err != nil {
+			return 0, errors.Wrap(domain.ErrInternal, err, "could not save equity snapshot", errors.WithMetadata("bot_id", snapshot.BotID))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(domain.ErrInternal, err, "could not commit transaction")
+	}
+
+	return len(rows), nil
+}
+
+type equitySnapshotRow struct {
+	BotID            string          `db:"bot_id"`
+	Resolution       string          `db:"resolution"`
+	Time             time.Time       `db:"time"`
+	AvailableCapital decimal.Decimal `db:"available_capital"`
+	InvestedCapital  decimal.Decimal `db:"invested_capital"`
+	TotalCapital     decimal.Decimal `db:"total_capital"`
+	MarkToMarket     decimal.Decimal `db:"mark_to_market"`
+	LowMarkToMarket  decimal.Decimal `db:"low_mark_to_market"`
+	HighMarkToMarket decimal.Decimal `db:"high_mark_to_market"`
+	OpenOrders       int             `db:"open_orders"`
+}
+
+func newEquitySnapshotRow(snapshot *domain.EquitySnapshot) equitySnapshotRow {
+	return equitySnapshotRow{
+		BotID:            snapshot.BotID.String(),
+		Resolution:       snapshot.Resolution,
+		Time:             snapshot.Time.UTC(),
+		AvailableCapital: snapshot.AvailableCapital,
+		InvestedCapital:  snapshot.InvestedCapital,
+		TotalCapital:     snapshot.TotalCapital,
+		MarkToMarket:     snapshot.MarkToMarket,
+		LowMarkToMarket:  snapshot.LowMarkToMarket,
+		HighMarkToMarket: snapshot.HighMarkToMarket,
+		OpenOrders:       snapshot.OpenOrders,
+	}
+}
+
+func (row equitySnapshotRow) toEntity() (*domain.EquitySnapshot, error) {
+	snapshot, err := domain.NewEquitySnapshot(
+		models.ID(row.BotID),
+		row.Time.UTC(),
+		row.Resolution,
+		row.AvailableCapital,
+		row.InvestedCapital,
+		row.TotalCapital,
+		row.MarkToMarket,
+		row.LowMarkToMarket,
+		row.HighMarkToMarket,
+		row.OpenOrders,
+	)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "invalid equity snapshot", errors.WithMetadata("bot_id", row.BotID))
+	}
+
+	return snapshot, nil
+}
+
+func equitySnapshotRowsToEntities(rows []equitySnapshotRow) ([]*domain.EquitySnapshot, error) {
+	snapshots := make([]*domain.EquitySnapshot, 0, len(rows))
+	for _, row := range rows {
+		snapshot, err := row.toEntity()
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, nil
+}
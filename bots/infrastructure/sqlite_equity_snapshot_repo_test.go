@@ -0,0 +1,59 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteEquitySnapshotRepo(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewSQLiteEquitySnapshotRepo(newTestDB(t))
+	assert.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 120; i++ {
+		value := decimal.NewFromInt(int64(1000 + i))
+		if i == 30 {
+			value = decimal.NewFromInt(900)
+		}
+
+		snapshot, err := domain.NewEquitySnapshot("bot", start.Add(time.Duration(i)*time.Minute), domain.EquityResolutionMinute, value, decimal.Zero, value, value, value, value, i%3)
+		assert.NoError(t, err)
+		assert.NoError(t, repo.Save(ctx, snapshot))
+	}
+
+	minutes, err := repo.FindByBotID(ctx, "bot", domain.EquityResolutionMinute, start, start.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, minutes, 120)
+
+	/** The drop to 900 from the peak of 1029 */
+	assert.Equal(t, "0.1254", domain.EquityMaxDrawdown(minutes).StringFixed(4))
+
+	/** Only the first hour is over before 01:30 */
+	replaced, err := repo.Downsample(ctx, domain.EquityResolutionMinute, domain.EquityResolutionHour, start.Add(90*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 60, replaced)
+
+	hours, err := repo.FindByBotID(ctx, "bot", domain.EquityResolutionHour, start, start.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, hours, 1)
+	assert.Equal(t, start, hours[0].Time)
+	assert.Equal(t, "1059", hours[0].MarkToMarket.String())
+	assert.Equal(t, "900", hours[0].LowMarkToMarket.String())
+	assert.Equal(t, "1059", hours[0].HighMarkToMarket.String())
+	assert.Equal(t, 59%3, hours[0].OpenOrders)
+
+	minutes, err = repo.FindByBotID(ctx, "bot", domain.EquityResolutionMinute, start, start.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, minutes, 60)
+	assert.Equal(t, start.Add(time.Hour), minutes[0].Time)
+
+	replaced, err = repo.Downsample(ctx, domain.EquityResolutionMinute, domain.EquityResolutionHour, start.Add(90*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, replaced)
+}
@@ -0,0 +1,307 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+	"github.com/shopspring/decimal"
+)
+
+type sqliteOrderRepository struct {
+	db *sqlx.DB
+}
+
+func NewSQLiteOrderRepo(db *sqlx.DB) (*sqliteOrderRepository, error) {
+	return &sqliteOrderRepository{
+		db: db,
+	}, nil
+}
+
+func (r *sqliteOrderRepository) FindByID(ctx context.Context, id models.ID) (*domain.Order, error) {
+	var row orderRow
+	err := r.db.GetContext(ctx, &row, "SELECT * FROM orders WHERE id = ? AND deleted_at IS NULL", id.String())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New(domain.ErrNotFound, "order not found", errors.WithMetadata("id", id))
+		}
+
+		return nil, errors.Wrap(domain.ErrInternal, err, "could not find order", errors.WithMetadata("id", id))
+	}
+
+	return row.toEntity()
+}
+
+func (r *sqliteOrderRepository) FindByBotID(ctx context.Context, botID models.ID) ([]*domain.Order, error) {
+	var rows []orderRow
+	err := r.db.SelectContext(ctx, &rows, "SELECT * FROM orders WHERE bot_id = ? AND deleted_at IS NULL ORDER BY created_at", botID.String())
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "could not find orders", errors.WithMetadata("bot_id", botID))
+	}
+
+	return orderRowsToEntities(rows)
+}
+
+// FindPaperOrders returns the orders placed in the paper exchange, whatever the
+// mode of their bot is now, to restore it after a restart.
+func (r *sqliteOrderRepository) FindPaperOrders(ctx context.Context) ([]*domain.Order, error) {
+	var rows []orderRow
+	err := r.db.SelectContext(ctx, &rows, "SELECT * FROM orders WHERE external_id LIKE ? AND deleted_at IS NULL ORDER BY created_at", paperOrderIdPrefix+"%")
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "could not find paper orders")
+	}
+
+	return orderRowsToEntities(rows)
+}
+
+func (r *sqliteOrderRepository) Save(ctx context.Context, order *domain.Order) error {
+	if err := saveOrder(ctx, r.db, order); err != nil {
+		return err
+	}
+
+	order.Version = persistedVersion(order.Version)
+
+	return nil
+}
+
+/** Shared with the bot repository, which saves orders in its own transaction */
+const (
+	insertOrderQuery = `
+		INSERT INTO orders (
+			id, bot_id, parent_id, position_id, symbol, side, type, quantity, initial_quote_amount, final_quote_amount,
+			entry_price, take_profit_price, executed_quantity, executed_quote_amount, fee, fee_currency,
+			fee_quote_amount, realized_pnl, stop_loss_price, exit_reason, external_id, status, price_range, delta, closed_at,
+			created_at, updated_at, deleted_at, version
+		) VALUES (
+			:id, :bot_id, :parent_id, :position_id, :symbol, :side, :type, :quantity, :initial_quote_amount, :final_quote_amount,
+			:entry_price, :take_profit_price, :executed_quantity, :executed_quote_amount, :fee, :fee_currency,
+			:fee_quote_amount, :realized_pnl, :stop_loss_price, :exit_reason, :external_id, :status, :price_range, :delta, :closed_at,
+			:created_at, :updated_at, :deleted_at, :version
+		)`
+
+	updateOrderQuery = `
+		UPDATE orders SET
+			position_id = :position_id,
+			symbol = :symbol,
+			side = :side,
+			type = :type,
+			quantity = :quantity,
+			initial_quote_amount = :initial_quote_amount,
+			final_quote_amount = :final_quote_amount,
+			entry_price = :entry_price,
+			take_profit_price = :take_profit_price,
+			executed_quantity = :executed_quantity,
+			executed_quote_amount = :executed_quote_amount,
+			fee = :fee,
+			fee_currency = :fee_currency,
+			fee_quote_amount = :fee_quote_amount,
+			realized_pnl = :realized_pnl,
+			stop_loss_price = :stop_loss_price,
+			exit_reason = :exit_reason,
+			external_id = :external_id,
+			status = :status,
+			price_range = :price_range,
+			delta = :delta,
+			closed_at = :closed_at,
+			updated_at = :updated_at,
+			deleted_at = :deleted_at,
+			version = :version
+		WHERE id = :id AND version = :version - 1`
+)
+
+func saveOrder(ctx context.Context, ext sqlx.ExtContext, order *domain.Order) error {
+	if !order.Version.IsUpdated() {
+		return nil
+	}
+
+	query := updateOrderQuery
+	if order.Version.Value == 1 {
+		query = insertOrderQuery
+	}
+
+	res, err := sqlx.NamedExecContext(ctx, ext, query, newOrderRow(order))
+	if err != nil {
+		return errors.Wrap(domain.ErrInternal, err, "could not save order", errors.WithMetadata("id", order.ID))
+	}
+
+	return checkAffectedRows(res, "order", order.ID, order.Version)
+}
+
+func checkAffectedRows(res sql.Result, entity string, id models.ID, version models.Version) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(domain.ErrInternal, err, fmt.Sprintf("could not save %s", entity), errors.WithMetadata("id", id))
+	}
+
+	if affected == 0 {
+		return errors.New(
+			domain.ErrConflict,
+			fmt.Sprintf("%s was modified by another writer", entity),
+			errors.WithMetadata("id", id),
+			errors.WithMetadata("version", version.Value),
+		)
+	}
+
+	return nil
+}
+
+// persistedVersion drops the pending update flag once the entity is stored,
+// so the next change bumps the version again.
+func persistedVersion(version models.Version) models.Version {
+	persisted, err := models.NewVersion(version.Value)
+	if err != nil {
+		return version
+	}
+
+	return persisted
+}
+
+type orderRow struct {
+	ID                  string          `db:"id"`
+	BotID               string          `db:"bot_id"`
+	ParentID            *string         `db:"parent_id"`
+	PositionID          *string         `db:"position_id"`
+	Symbol              string          `db:"symbol"`
+	Side                string          `db:"side"`
+	Type                string          `db:"type"`
+	Quantity            decimal.Decimal `db:"quantity"`
+	InitialQuoteAmount  decimal.Decimal `db:"initial_quote_amount"`
+	FinalQuoteAmount    decimal.Decimal `db:"final_quote_amount"`
+	EntryPrice          decimal.Decimal `db:"entry_price"`
+	TakeProfitPrice     decimal.Decimal `db:"take_profit_price"`
+	ExecutedQuantity    decimal.Decimal `db:"executed_quantity"`
+	ExecutedQuoteAmount decimal.Decimal `db:"executed_quote_amount"`
+	Fee                 decimal.Decimal `db:"fee"`
+	FeeCurrency         string          `db:"fee_currency"`
+	FeeQuoteAmount      decimal.Decimal `db:"fee_quote_amount"`
+	RealizedPnL         decimal.Decimal `db:"realized_pnl"`
+	StopLossPrice       decimal.Decimal `db:"stop_loss_price"`
+	ExitReason          string          `db:"exit_reason"`
+	ExternalID          *string         `db:"external_id"`
+	Status              string          `db:"status"`
+	PriceRange          int             `db:"price_range"`
+	Delta               decimal.Decimal `db:"delta"`
+	ClosedAt            *time.Time      `db:"closed_at"`
+	CreatedAt           time.Time       `db:"created_at"`
+	UpdatedAt           time.Time       `db:"updated_at"`
+	DeletedAt           *time.Time      `db:"deleted_at"`
+	Version             int             `db:"version"`
+}
+
+func newOrderRow(order *domain.Order) orderRow {
+	var parentID *string
+	if order.ParentID != nil {
+		id := order.ParentID.String()
+		parentID = &id
+	}
+
+	var positionID *string
+	if order.PositionID != nil {
+		id := order.PositionID.String()
+		positionID = &id
+	}
+
+	return orderRow{
+		ID:                  order.ID.String(),
+		BotID:               order.BotID.String(),
+		ParentID:            parentID,
+		PositionID:          positionID,
+		Symbol:              order.Symbol,
+		Side:                order.Side,
+		Type:                order.Type,
+		Quantity:            order.Quantity,
+		InitialQuoteAmount:  order.InitialQuoteAmount,
+		FinalQuoteAmount:    order.FinalQuoteAmount,
+		EntryPrice:          order.EntryPrice,
+		TakeProfitPrice:     order.TakeProfitPrice,
+		ExecutedQuantity:    order.ExecutedQuantity,
+		ExecutedQuoteAmount: order.ExecutedQuoteAmount,
+		Fee:                 order.Fee,
+		FeeCurrency:         order.FeeCurrency,
+		FeeQuoteAmount:      order.FeeQuoteAmount,
+		RealizedPnL:         order.RealizedPnL,
+		StopLossPrice:       order.StopLossPrice,
+		ExitReason:          order.ExitReason,
+		ExternalID:          order.ExternalId,
+		Status:              order.Status,
+		PriceRange:          order.PriceRange,
+		Delta:               order.Delta,
+		ClosedAt:            order.ClosedAt,
+		CreatedAt:           order.Timestamps.CreatedAt,
+		UpdatedAt:           order.Timestamps.UpdatedAt,
+		DeletedAt:           order.Timestamps.DeletedAt,
+		Version:             order.Version.Value,
+	}
+}
+
+func (row orderRow) toEntity() (*domain.Order, error) {
+	timestamps, err := models.NewTimestamps(row.CreatedAt, row.UpdatedAt, row.DeletedAt)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "invalid order timestamps", errors.WithMetadata("id", row.ID))
+	}
+
+	version, err := models.NewVersion(row.Version)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "invalid order version", errors.WithMetadata("id", row.ID))
+	}
+
+	var parentID *models.ID
+	if row.ParentID != nil {
+		id := models.ID(*row.ParentID)
+		parentID = &id
+	}
+
+	var positionID *models.ID
+	if row.PositionID != nil {
+		id := models.ID(*row.PositionID)
+		positionID = &id
+	}
+
+	return domain.NewOrder(
+		models.ID(row.ID),
+		models.ID(row.BotID),
+		parentID,
+		positionID,
+		row.Symbol,
+		row.Side,
+		row.Type,
+		row.Quantity,
+		row.InitialQuoteAmount,
+		row.FinalQuoteAmount,
+		row.EntryPrice,
+		row.TakeProfitPrice,
+		row.ExecutedQuantity,
+		row.ExecutedQuoteAmount,
+		row.Fee,
+		row.FeeCurrency,
+		row.FeeQuoteAmount,
+		row.RealizedPnL,
+		row.StopLossPrice,
+		row.ExitReason,
+		row.ExternalID,
+		row.Status,
+		row.PriceRange,
+		row.Delta,
+		row.ClosedAt,
+		timestamps,
+		version,
+	)
+}
+
+func orderRowsToEntities(rows []orderRow) ([]*domain.Order, error) {
+	orders := make([]*domain.Order, 0, len(rows))
+	for _, row := range rows {
+		order, err := row.toEntity()
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,112 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/juankohler/crypto-bot/libs/go/models"
+	"github.com/shopspring/decimal"
+)
+
+type sqlitePnLRepository struct {
+	db *sqlx.DB
+}
+
+func NewSQLitePnLRepo(db *sqlx.DB) (*sqlitePnLRepository, error) {
+	return &sqlitePnLRepository{
+		db: db,
+	}, nil
+}
+
+func (r *sqlitePnLRepository) FindByBotID(ctx context.Context, botID models.ID, start time.Time, end time.Time) ([]*domain.PnL, error) {
+	var rows []pnlRow
+	err := r.db.SelectContext(
+		ctx,
+		&rows,
+		"SELECT * FROM pnl_snapshots WHERE bot_id = ? AND time >= ? AND time < ? ORDER BY time",
+		botID.String(),
+		start.UTC(),
+		end.UTC(),
+	)
+	if err != nil {
+		return nil, errors.Wrap(domain.ErrInternal, err, "could not find pnl snapshots", errors.WithMetadata("bot_id", botID))
+	}
+
+	pnls := make([]*domain.PnL, 0, len(rows))
+	for _, row := range rows {
+		pnls = append(pnls, row.toEntity())
+	}
+
+	return pnls, nil
+}
+
+const (
+	upsertPnLQuery = `
+		INSERT INTO pnl_snapshots (
+			bot_id, time, currency, price, initial_capital, realized_pnl, unrealized_pnl, equity, fees_paid, roi
+		) VALUES (
+			:bot_id, :time, :currency, :price, :initial_capital, :realized_pnl, :unrealized_pnl, :equity, :fees_paid, :roi
+		)
+		ON CONFLICT (bot_id, time) DO UPDATE SET
+			currency = excluded.currency,
+			price = excluded.price,
+			initial_capital = excluded.initial_capital,
+			realized_pnl = excluded.realized_pnl,
+			unrealized_pnl = excluded.unrealized_pnl,
+			equity = excluded.equity,
+			fees_paid = excluded.fees_paid,
+			roi = excluded.roi`
+)
+
+func (r *sqlitePnLRepository) Save(ctx context.Context, pnl *domain.PnL) error {
+	if _, err := r.db.NamedExecContext(ctx, upsertPnLQuery, newPnLRow(pnl)); err != nil {
+		return errors.Wrap(domain.ErrInternal, err, "could not save pnl snapshot", errors.WithMetadata("bot_id", pnl.BotID))
+	}
+
+	return nil
+}
+
+type pnlRow struct {
+	BotID          string          `db:"bot_id"`
+	Time           time.Time       `db:"time"`
+	Currency       string          `db:"currency"`
+	Price          decimal.Decimal `db:"price"`
+	InitialCapital decimal.Decimal `db:"initial_capital"`
+	RealizedPnL    decimal.Decimal `db:"realized_pnl"`
+	UnrealizedPnL  decimal.Decimal `db:"unrealized_pnl"`
+	Equity         decimal.Decimal `db:"equity"`
+	FeesPaid       decimal.Decimal `db:"fees_paid"`
+	ROI            decimal.Decimal `db:"roi"`
+}
+
+func newPnLRow(pnl *domain.PnL) pnlRow {
+	return pnlRow{
+		BotID:          pnl.BotID.String(),
+		Time:           pnl.Time.UTC(),
+		Currency:       pnl.Currency,
+		Price:          pnl.Price,
+		InitialCapital: pnl.InitialCapital,
+		RealizedPnL:    pnl.RealizedPnL,
+		UnrealizedPnL:  pnl.UnrealizedPnL,
+		Equity:         pnl.Equity,
+		FeesPaid:       pnl.FeesPaid,
+		ROI:            pnl.ROI,
+	}
+}
+
+func (row pnlRow) toEntity() *domain.PnL {
+	return domain.NewPnL(
+		models.ID(row.BotID),
+		row.Currency,
+		row.Time.UTC(),
+		row.Price,
+		row.InitialCapital,
+		row.RealizedPnL,
+		row.UnrealizedPnL,
+		row.Equity,
+		row.FeesPaid,
+	)
+}
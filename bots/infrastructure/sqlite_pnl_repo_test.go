@@ -0,0 +1,40 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juankohler/crypto-bot/bots/domain"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLitePnLRepo(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewSQLitePnLRepo(newTestDB(t))
+	assert.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	capital := decimal.NewFromInt(1000)
+	for i, realized := range []string{"10", "15", "12"} {
+		pnl := domain.NewPnL("bot", "USDT", start.Add(time.Duration(i)*time.Hour), decimal.NewFromInt(42000), capital, decimal.RequireFromString(realized), decimal.NewFromInt(-5), capital, decimal.NewFromInt(1))
+		assert.NoError(t, repo.Save(ctx, pnl))
+	}
+
+	/** A snapshot at the same time replaces the stored one */
+	assert.NoError(t, repo.Save(ctx, domain.NewPnL("bot", "USDT", start, decimal.NewFromInt(42000), capital, decimal.NewFromInt(20), decimal.Zero, capital, decimal.Zero)))
+
+	pnls, err := repo.FindByBotID(ctx, "bot", start, start.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, pnls, 2)
+	assert.Equal(t, start, pnls[0].Time)
+	assert.Equal(t, "0.02", pnls[0].ROI.String())
+	assert.Equal(t, "15", pnls[1].RealizedPnL.String())
+	assert.Equal(t, "10", pnls[1].TotalPnL().String())
+	assert.Equal(t, "0.01", pnls[1].ROI.String())
+
+	pnls, err = repo.FindByBotID(ctx, "other", start, start.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, pnls)
+}
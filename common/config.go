@@ -1,15 +1,97 @@
 package common
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/juankohler/crypto-bot/libs/go/config"
 	"github.com/juankohler/crypto-bot/libs/go/restclient"
+	"github.com/juankohler/crypto-bot/libs/go/wsclient"
+	"github.com/shopspring/decimal"
 )
 
 type Config struct {
-	Env         config.Env
-	Port        int
-	Database    string
-	BinanceRepo restclient.Config
+	Env                 config.Env
+	Port                int
+	Database            string
+	BinanceRepo         restclient.Config
+	BinanceApiKey       string
+	BinanceSecretKey    string
+	BinanceRecvWindowMs int
+	BinanceRateLimits   BinanceRateLimitsConfig
+	// Market data pushed by Binance, when enabled, to react between the
+	// executions of the bots.
+	BinanceStreamEnabled bool
+	BinanceStream        wsclient.Config
+	Prices               PricesConfig
+	// Cadence of the snapshots of the profit of the bots.
+	PnLSnapshotInterval time.Duration
+	EquitySnapshots     EquitySnapshotsConfig
+	Fees                FeesConfig
+	Risk                RiskConfig
+	Paper               PaperConfig
+}
+
+// BinanceRateLimitsConfig keeps the requests under the limits of Binance,
+// 6000 of weight per minute and 50 orders every 10 seconds, with some margin
+// for other clients of the same account.
+type BinanceRateLimitsConfig struct {
+	MaxWeightPerMinute    int
+	MaxOrdersPer10Seconds int
+	// Longest wait for budget before a request fails.
+	MaxWait time.Duration
+}
+
+// PricesConfig configures the price cache shared by the bots.
+type PricesConfig struct {
+	// Age up to which a price is reused without asking the exchange.
+	MaxAge time.Duration
+	// Age up to which a price is reused when the exchange fails.
+	MaxStaleness time.Duration
+	// Wait to fetch the prices asked for at once in a single request.
+	BatchWindow time.Duration
+}
+
+// EquitySnapshotsConfig configures the history of the capital of the bots.
+type EquitySnapshotsConfig struct {
+	// Cadence of the snapshots, at least a minute.
+	Interval time.Duration
+	// Age after which the snapshots are downsampled to hour resolution.
+	MinuteRetention time.Duration
+	// Age after which the snapshots are downsampled to day resolution.
+	HourRetention time.Duration
+}
+
+// FeesConfig is the fee schedule of the account, used to place take profits
+// that are profitable after fees and to value the fees paid in BNB. Paper
+// trading and backtests charge it too.
+type FeesConfig struct {
+	MakerFee    decimal.Decimal
+	TakerFee    decimal.Decimal
+	BNBDiscount decimal.Decimal
+	// The account pays the fees in BNB, with the discount.
+	PayWithBNB bool
+}
+
+// RiskConfig are the limits every buy of the bots is checked against, zero to
+// disable them. Amounts are in the quote currency of the pair.
+type RiskConfig struct {
+	MaxPositionSize     decimal.Decimal
+	MaxOpenOrders       int
+	MaxInvestedFraction decimal.Decimal
+	// Amount invested in a pair by all the bots together.
+	MaxSymbolExposure decimal.Decimal
+	// Drop of the value of a bot since the start of the day, as a fraction.
+	MaxDailyLoss decimal.Decimal
+}
+
+// PaperConfig configures the simulated exchange used by bots in paper mode.
+type PaperConfig struct {
+	// Run every bot in paper mode, regardless of its own mode.
+	Enabled  bool
+	Slippage decimal.Decimal
+	Balances map[string]decimal.Decimal
 }
 
 func GetConfig() (*Config, error) {
@@ -20,14 +102,147 @@ func GetConfig() (*Config, error) {
 
 	timeOut := 9000
 
+	fees, err := getFeesConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	risk, err := getRiskConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	paper, err := getPaperConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
 		Env:      env,
 		Port:     config.GetEnvAsInt("PORT", 8080),
 		Database: config.GetEnv("DATABASE", "database/local.db"),
 		BinanceRepo: restclient.Config{
-			BaseUrl:   "https://api.binance.com/api",
+			BaseUrl:   config.GetEnv("BINANCE_BASE_URL", "https://api.binance.com/api"),
 			Retries:   1,
 			TimeoutMs: &timeOut,
 		},
+		BinanceApiKey:       config.GetEnv("BINANCE_API_KEY", ""),
+		BinanceSecretKey:    config.GetEnv("BINANCE_SECRET_KEY", ""),
+		BinanceRecvWindowMs: config.GetEnvAsInt("BINANCE_RECV_WINDOW_MS", 5000),
+		BinanceRateLimits: BinanceRateLimitsConfig{
+			MaxWeightPerMinute:    config.GetEnvAsInt("BINANCE_MAX_WEIGHT_1M", 5000),
+			MaxOrdersPer10Seconds: config.GetEnvAsInt("BINANCE_MAX_ORDERS_10S", 40),
+			MaxWait:               time.Duration(config.GetEnvAsInt("BINANCE_RATE_LIMIT_MAX_WAIT_MS", 10000)) * time.Millisecond,
+		},
+		BinanceStreamEnabled: config.GetEnvAsBool("BINANCE_STREAM_ENABLED", true),
+		BinanceStream: wsclient.Config{
+			Url: config.GetEnv("BINANCE_STREAM_URL", "wss://stream.binance.com:9443/stream"),
+		},
+		Prices: PricesConfig{
+			MaxAge:       time.Duration(config.GetEnvAsInt("PRICE_MAX_AGE_MS", 1000)) * time.Millisecond,
+			MaxStaleness: time.Duration(config.GetEnvAsInt("PRICE_MAX_STALENESS_MS", 30000)) * time.Millisecond,
+			BatchWindow:  time.Duration(config.GetEnvAsInt("PRICE_BATCH_WINDOW_MS", 20)) * time.Millisecond,
+		},
+		PnLSnapshotInterval: time.Duration(config.GetEnvAsInt("PNL_SNAPSHOT_INTERVAL_MS", 900000)) * time.Millisecond,
+		EquitySnapshots: EquitySnapshotsConfig{
+			Interval:        time.Duration(config.GetEnvAsInt("EQUITY_SNAPSHOT_INTERVAL_MS", 60000)) * time.Millisecond,
+			MinuteRetention: time.Duration(config.GetEnvAsInt("EQUITY_MINUTE_RETENTION_HOURS", 24)) * time.Hour,
+			HourRetention:   time.Duration(config.GetEnvAsInt("EQUITY_HOUR_RETENTION_DAYS", 30)) * 24 * time.Hour,
+		},
+		Fees:  *fees,
+		Risk:  *risk,
+		Paper: *paper,
 	}, nil
 }
+
+func getFeesConfig() (*FeesConfig, error) {
+	makerFee, err := getEnvAsDecimal("FEE_MAKER", "0.001")
+	if err != nil {
+		return nil, err
+	}
+
+	takerFee, err := getEnvAsDecimal("FEE_TAKER", "0.001")
+	if err != nil {
+		return nil, err
+	}
+
+	bnbDiscount, err := getEnvAsDecimal("FEE_BNB_DISCOUNT", "0.25")
+	if err != nil {
+		return nil, err
+	}
+
+	return &FeesConfig{
+		MakerFee:    makerFee,
+		TakerFee:    takerFee,
+		BNBDiscount: bnbDiscount,
+		PayWithBNB:  config.GetEnvAsBool("FEE_PAY_WITH_BNB", false),
+	}, nil
+}
+
+func getRiskConfig() (*RiskConfig, error) {
+	maxPositionSize, err := getEnvAsDecimal("RISK_MAX_POSITION_SIZE", "0")
+	if err != nil {
+		return nil, err
+	}
+
+	maxInvestedFraction, err := getEnvAsDecimal("RISK_MAX_INVESTED_FRACTION", "0")
+	if err != nil {
+		return nil, err
+	}
+
+	maxSymbolExposure, err := getEnvAsDecimal("RISK_MAX_SYMBOL_EXPOSURE", "0")
+	if err != nil {
+		return nil, err
+	}
+
+	maxDailyLoss, err := getEnvAsDecimal("RISK_MAX_DAILY_LOSS", "0")
+	if err != nil {
+		return nil, err
+	}
+
+	return &RiskConfig{
+		MaxPositionSize:     maxPositionSize,
+		MaxOpenOrders:       config.GetEnvAsInt("RISK_MAX_OPEN_ORDERS", 0),
+		MaxInvestedFraction: maxInvestedFraction,
+		MaxSymbolExposure:   maxSymbolExposure,
+		MaxDailyLoss:        maxDailyLoss,
+	}, nil
+}
+
+func getPaperConfig() (*PaperConfig, error) {
+	slippage, err := getEnvAsDecimal("PAPER_SLIPPAGE", "0.0005")
+	if err != nil {
+		return nil, err
+	}
+
+	/** Format: USDT:10000,BTC:0.5 */
+	balances := make(map[string]decimal.Decimal)
+	for _, balance := range strings.Split(config.GetEnv("PAPER_BALANCES", "USDT:10000"), ",") {
+		currency, amount, ok := strings.Cut(strings.TrimSpace(balance), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid PAPER_BALANCES entry: %q", balance)
+		}
+
+		value, err := decimal.NewFromString(amount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PAPER_BALANCES amount for %s: %w", currency, err)
+		}
+
+		balances[strings.ToUpper(currency)] = value
+	}
+
+	return &PaperConfig{
+		Enabled:  config.GetEnvAsBool("PAPER_TRADING", false),
+		Slippage: slippage,
+		Balances: balances,
+	}, nil
+}
+
+func getEnvAsDecimal(key string, defaultValue string) (decimal.Decimal, error) {
+	value, err := decimal.NewFromString(config.GetEnv(key, defaultValue))
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,56 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/juankohler/crypto-bot/database"
+	"github.com/juankohler/crypto-bot/libs/go/migrations"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Wait for the lock of the database held by another process before failing
+// with "database is locked".
+const databaseBusyTimeoutMs = 5000
+
+// ConnectDatabase opens the SQLite database shared by the bot workers, the
+// recorders and the API. SQLite has a single writer, so every query goes
+// through one connection, queued by database/sql instead of failing while
+// another one writes. Other processes, like the migrate subcommand, are
+// waited for up to the busy timeout, and transactions take the write lock
+// when they begin so they never fail halfway upgrading a read lock.
+func ConnectDatabase(cfg *Config) (*sqlx.DB, error) {
+	separator := "?"
+	if strings.Contains(cfg.Database, "?") {
+		separator = "&"
+	}
+	dsn := fmt.Sprintf("%s%s_busy_timeout=%d&_txlock=immediate", cfg.Database, separator, databaseBusyTimeoutMs)
+
+	db, err := sqlx.Connect("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+func NewMigrator(db *sqlx.DB) (*migrations.Migrator, error) {
+	return migrations.New(db, database.Migrations())
+}
+
+func MigrateDatabase(ctx context.Context, db *sqlx.DB) error {
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	executed, err := migrator.Up(ctx)
+	for _, migration := range executed {
+		fmt.Printf("Migration %04d_%s applied\n", migration.Version, migration.Name)
+	}
+
+	return err
+}
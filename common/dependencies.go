@@ -1,23 +1,34 @@
 package common
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/mattn/go-sqlite3"
 )
 
 type Dependencies struct {
 	Mux *http.ServeMux
 	DB  *sqlx.DB
+
+	shutdownHooks []ShutdownHook
 }
 
+// ShutdownHook stops a part of the application, giving up when the context is
+// done.
+type ShutdownHook = func(ctx context.Context) error
+
 func BuildDependencies(cfg *Config) (*Dependencies, error) {
-	db, err := sqlx.Connect("sqlite3", cfg.Database)
+	db, err := ConnectDatabase(cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := MigrateDatabase(context.Background(), db); err != nil {
+		return nil, err
+	}
+
 	mux := http.NewServeMux()
 
 	return &Dependencies{
@@ -25,3 +36,26 @@ func BuildDependencies(cfg *Config) (*Dependencies, error) {
 		DB:  db,
 	}, nil
 }
+
+// OnShutdown registers a hook to run on Shutdown, after the ones registered
+// later.
+func (d *Dependencies) OnShutdown(hook ShutdownHook) {
+	d.shutdownHooks = append(d.shutdownHooks, hook)
+}
+
+// Shutdown runs the hooks in reverse order of registration and closes the
+// database.
+func (d *Dependencies) Shutdown(ctx context.Context) error {
+	var errs []error
+	for i := len(d.shutdownHooks) - 1; i >= 0; i-- {
+		if err := d.shutdownHooks[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := d.DB.Close(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
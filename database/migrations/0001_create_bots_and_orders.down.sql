@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS orders_bot_id_status_idx;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS bots;
@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS bots (
+	id varchar(64) PRIMARY KEY,
+	name varchar(255) NOT NULL,
+	take_profit_percentaje text NOT NULL,
+	initial_capital text NOT NULL,
+	available_capital text NOT NULL,
+	invested_capital text NOT NULL,
+	total_capital text NOT NULL,
+	currency varchar(32) NOT NULL,
+	target_currency varchar(32) NOT NULL,
+	delta text NOT NULL,
+	monitor_interval_ms integer NOT NULL,
+	strategy varchar(64) NOT NULL,
+	strategy_params text NOT NULL,
+	last_sale_price text,
+	created_at datetime NOT NULL,
+	updated_at datetime NOT NULL,
+	deleted_at datetime,
+	version integer NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+	id varchar(64) PRIMARY KEY,
+	bot_id varchar(64) NOT NULL REFERENCES bots (id),
+	symbol varchar(32) NOT NULL,
+	quantity text NOT NULL,
+	initial_quote_amount text NOT NULL,
+	final_quote_amount text NOT NULL,
+	entry_price text NOT NULL,
+	take_profit_price text NOT NULL,
+	external_id varchar(255),
+	status varchar(32) NOT NULL,
+	price_range integer NOT NULL,
+	created_at datetime NOT NULL,
+	updated_at datetime NOT NULL,
+	deleted_at datetime,
+	version integer NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS orders_bot_id_status_idx ON orders (bot_id, status);
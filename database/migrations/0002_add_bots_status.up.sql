@@ -0,0 +1 @@
+ALTER TABLE bots ADD COLUMN status varchar(32) NOT NULL DEFAULT 'ACTIVE';
@@ -0,0 +1,2 @@
+ALTER TABLE orders DROP COLUMN type;
+ALTER TABLE orders DROP COLUMN side;
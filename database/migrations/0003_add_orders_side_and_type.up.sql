@@ -0,0 +1,2 @@
+ALTER TABLE orders ADD COLUMN side varchar(16) NOT NULL DEFAULT 'BUY';
+ALTER TABLE orders ADD COLUMN type varchar(16) NOT NULL DEFAULT 'MARKET';
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS orders_bot_id_closed_at_idx;
+
+ALTER TABLE orders DROP COLUMN closed_at;
+ALTER TABLE orders DROP COLUMN fee_currency;
+ALTER TABLE orders DROP COLUMN fee;
+ALTER TABLE orders DROP COLUMN executed_quote_amount;
+ALTER TABLE orders DROP COLUMN executed_quantity;
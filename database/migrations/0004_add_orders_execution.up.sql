@@ -0,0 +1,10 @@
+ALTER TABLE orders ADD COLUMN executed_quantity text NOT NULL DEFAULT '0';
+ALTER TABLE orders ADD COLUMN executed_quote_amount text NOT NULL DEFAULT '0';
+ALTER TABLE orders ADD COLUMN fee text NOT NULL DEFAULT '0';
+ALTER TABLE orders ADD COLUMN fee_currency varchar(32) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN closed_at datetime;
+
+UPDATE orders SET executed_quantity = quantity, executed_quote_amount = initial_quote_amount;
+UPDATE orders SET closed_at = updated_at WHERE status = 'COMPLETED';
+
+CREATE INDEX IF NOT EXISTS orders_bot_id_closed_at_idx ON orders (bot_id, closed_at);
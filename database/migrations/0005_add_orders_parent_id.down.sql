@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS orders_parent_id_idx;
+
+ALTER TABLE orders DROP COLUMN parent_id;
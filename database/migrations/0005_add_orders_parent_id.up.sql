@@ -0,0 +1,3 @@
+ALTER TABLE orders ADD COLUMN parent_id varchar(64) REFERENCES orders (id);
+
+CREATE INDEX IF NOT EXISTS orders_parent_id_idx ON orders (parent_id);
@@ -0,0 +1 @@
+ALTER TABLE bots ADD COLUMN mode varchar(16) NOT NULL DEFAULT 'LIVE';
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS candles (
+	symbol varchar(32) NOT NULL,
+	interval varchar(8) NOT NULL,
+	open_time datetime NOT NULL,
+	close_time datetime NOT NULL,
+	open text NOT NULL,
+	high text NOT NULL,
+	low text NOT NULL,
+	close text NOT NULL,
+	volume text NOT NULL,
+	PRIMARY KEY (symbol, interval, open_time)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE bots DROP COLUMN fees_paid;
+ALTER TABLE bots DROP COLUMN realized_pnl;
+ALTER TABLE orders DROP COLUMN realized_pnl;
+ALTER TABLE orders DROP COLUMN fee_quote_amount;
@@ -0,0 +1,6 @@
+ALTER TABLE orders ADD COLUMN fee_quote_amount text NOT NULL DEFAULT '0';
+ALTER TABLE orders ADD COLUMN realized_pnl text NOT NULL DEFAULT '0';
+ALTER TABLE bots ADD COLUMN realized_pnl text NOT NULL DEFAULT '0';
+ALTER TABLE bots ADD COLUMN fees_paid text NOT NULL DEFAULT '0';
+
+UPDATE orders SET fee_quote_amount = fee WHERE fee_currency = substr(symbol, instr(symbol, '/') + 1);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS pnl_snapshots (
+	bot_id varchar(32) NOT NULL,
+	time datetime NOT NULL,
+	currency varchar(32) NOT NULL,
+	price text NOT NULL,
+	initial_capital text NOT NULL,
+	realized_pnl text NOT NULL,
+	unrealized_pnl text NOT NULL,
+	equity text NOT NULL,
+	fees_paid text NOT NULL,
+	roi text NOT NULL,
+	PRIMARY KEY (bot_id, time)
+);
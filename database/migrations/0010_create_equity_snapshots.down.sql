@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS equity_snapshots_resolution_time_idx;
+DROP TABLE IF EXISTS equity_snapshots;
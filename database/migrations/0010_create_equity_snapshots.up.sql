@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS equity_snapshots (
+	bot_id varchar(32) NOT NULL,
+	resolution varchar(8) NOT NULL,
+	time datetime NOT NULL,
+	available_capital text NOT NULL,
+	invested_capital text NOT NULL,
+	total_capital text NOT NULL,
+	mark_to_market text NOT NULL,
+	low_mark_to_market text NOT NULL,
+	high_mark_to_market text NOT NULL,
+	open_orders integer NOT NULL,
+	PRIMARY KEY (bot_id, resolution, time)
+);
+
+CREATE INDEX IF NOT EXISTS equity_snapshots_resolution_time_idx ON equity_snapshots (resolution, time);
@@ -0,0 +1,5 @@
+ALTER TABLE orders DROP COLUMN exit_reason;
+ALTER TABLE orders DROP COLUMN stop_loss_price;
+ALTER TABLE bots DROP COLUMN stop_loss_trailing;
+ALTER TABLE bots DROP COLUMN stop_loss_value;
+ALTER TABLE bots DROP COLUMN stop_loss_type;
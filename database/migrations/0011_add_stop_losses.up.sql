@@ -0,0 +1,11 @@
+ALTER TABLE bots ADD COLUMN stop_loss_type varchar(16);
+ALTER TABLE bots ADD COLUMN stop_loss_value text;
+ALTER TABLE bots ADD COLUMN stop_loss_trailing integer NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN stop_loss_price text NOT NULL DEFAULT '0';
+ALTER TABLE orders ADD COLUMN exit_reason varchar(16) NOT NULL DEFAULT '';
+
+UPDATE orders SET exit_reason = 'TAKE_PROFIT' WHERE parent_id IS NOT NULL;
+UPDATE orders SET exit_reason = 'TAKE_PROFIT'
+WHERE parent_id IS NULL AND closed_at IS NOT NULL AND EXISTS (
+	SELECT 1 FROM orders AS sells WHERE sells.parent_id = orders.id AND sells.status = 'COMPLETED'
+);
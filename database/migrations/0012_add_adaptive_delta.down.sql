@@ -0,0 +1,7 @@
+ALTER TABLE orders DROP COLUMN delta;
+ALTER TABLE bots DROP COLUMN adaptive_delta_hysteresis;
+ALTER TABLE bots DROP COLUMN adaptive_delta_max;
+ALTER TABLE bots DROP COLUMN adaptive_delta_min;
+ALTER TABLE bots DROP COLUMN adaptive_delta_multiplier;
+ALTER TABLE bots DROP COLUMN adaptive_delta_period;
+ALTER TABLE bots DROP COLUMN adaptive_delta_interval;
@@ -0,0 +1,9 @@
+ALTER TABLE bots ADD COLUMN adaptive_delta_interval varchar(8);
+ALTER TABLE bots ADD COLUMN adaptive_delta_period integer NOT NULL DEFAULT 0;
+ALTER TABLE bots ADD COLUMN adaptive_delta_multiplier text;
+ALTER TABLE bots ADD COLUMN adaptive_delta_min text;
+ALTER TABLE bots ADD COLUMN adaptive_delta_max text;
+ALTER TABLE bots ADD COLUMN adaptive_delta_hysteresis text;
+ALTER TABLE orders ADD COLUMN delta text NOT NULL DEFAULT '0';
+
+UPDATE orders SET delta = (SELECT bots.delta FROM bots WHERE bots.id = orders.bot_id);
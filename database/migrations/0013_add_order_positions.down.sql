@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS orders_position_id_idx;
+
+ALTER TABLE orders DROP COLUMN position_id;
@@ -0,0 +1,3 @@
+ALTER TABLE orders ADD COLUMN position_id varchar(64) REFERENCES orders (id);
+
+CREATE INDEX IF NOT EXISTS orders_position_id_idx ON orders (position_id);
@@ -0,0 +1 @@
+UPDATE orders SET symbol = replace(symbol, '-', '/');
@@ -0,0 +1,48 @@
+package indicators
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// ATR is the average true range of Wilder, the volatility of candles including
+// the gaps between them.
+type ATR struct {
+	period    int
+	prevClose *decimal.Decimal
+	ranges    int
+	value     decimal.Decimal
+}
+
+func NewATR(period int) (*ATR, error) {
+	if err := validatePeriod(period); err != nil {
+		return nil, err
+	}
+
+	return &ATR{
+		period: period,
+	}, nil
+}
+
+// Update adds a candle and returns the average, a simple one of the true
+// ranges until there are period of them.
+func (i *ATR) Update(high decimal.Decimal, low decimal.Decimal, close decimal.Decimal) decimal.Decimal {
+	trueRange := high.Sub(low)
+	if i.prevClose != nil {
+		trueRange = decimal.Max(trueRange, high.Sub(*i.prevClose).Abs(), low.Sub(*i.prevClose).Abs())
+	}
+	i.prevClose = &close
+
+	i.ranges++
+	i.value = smooth(i.value, trueRange, min(i.ranges, i.period))
+
+	return i.value
+}
+
+func (i *ATR) Value() decimal.Decimal {
+	return i.value
+}
+
+// Ready is true once there are period candles.
+func (i *ATR) Ready() bool {
+	return i.ranges >= i.period
+}
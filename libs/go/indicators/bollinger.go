@@ -0,0 +1,51 @@
+package indicators
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// BollingerBands are the simple moving average of the last period values with
+// bands k standard deviations above and below it, usually 20 and 2.
+type BollingerBands struct {
+	window *window
+	k      decimal.Decimal
+}
+
+type BollingerBandsValue struct {
+	Upper  decimal.Decimal
+	Middle decimal.Decimal
+	Lower  decimal.Decimal
+}
+
+func NewBollingerBands(period int, k decimal.Decimal) (*BollingerBands, error) {
+	if err := validatePeriod(period); err != nil {
+		return nil, err
+	}
+
+	return &BollingerBands{
+		window: newWindow(period),
+		k:      k,
+	}, nil
+}
+
+func (i *BollingerBands) Update(value decimal.Decimal) BollingerBandsValue {
+	i.window.push(value)
+
+	return i.Value()
+}
+
+func (i *BollingerBands) Value() BollingerBandsValue {
+	middle := i.window.mean()
+	width := i.window.stdDev().Mul(i.k)
+
+	return BollingerBandsValue{
+		Upper:  middle.Add(width),
+		Middle: middle,
+		Lower:  middle.Sub(width),
+	}
+}
+
+// Ready is true once the window is full.
+func (i *BollingerBands) Ready() bool {
+	return i.window.full
+}
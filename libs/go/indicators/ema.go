@@ -0,0 +1,50 @@
+package indicators
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// EMA is the exponential moving average with a smoothing of 2 / (period + 1),
+// seeded with the simple average of the first period values.
+type EMA struct {
+	period int
+	alpha  decimal.Decimal
+	seed   *SMA
+	value  decimal.Decimal
+	ready  bool
+}
+
+func NewEMA(period int) (*EMA, error) {
+	seed, err := NewSMA(period)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EMA{
+		period: period,
+		alpha:  two.Div(decimal.NewFromInt(int64(period + 1))),
+		seed:   seed,
+	}, nil
+}
+
+// Update adds a value and returns the average. Until there are period values
+// it is their simple average.
+func (i *EMA) Update(value decimal.Decimal) decimal.Decimal {
+	if !i.ready {
+		i.value = i.seed.Update(value)
+		i.ready = i.seed.Ready()
+		return i.value
+	}
+
+	i.value = value.Sub(i.value).Mul(i.alpha).Add(i.value)
+
+	return i.value
+}
+
+func (i *EMA) Value() decimal.Decimal {
+	return i.value
+}
+
+func (i *EMA) Ready() bool {
+	return i.ready
+}
@@ -0,0 +1,110 @@
+// Package indicators computes technical indicators over price series one value
+// at a time, so a strategy can update them on every tick without going over
+// the whole window again.
+package indicators
+
+import (
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrInvalidPeriod = errors.Define("indicators.invalid_period")
+)
+
+var (
+	one     = decimal.NewFromInt(1)
+	two     = decimal.NewFromInt(2)
+	hundred = decimal.NewFromInt(100)
+)
+
+func validatePeriod(period int) error {
+	if period < 1 {
+		return errors.New(ErrInvalidPeriod, "period must be positive", errors.WithMetadata("period", period))
+	}
+
+	return nil
+}
+
+// smooth adds a value to the average of n - 1 values. With n fixed to the
+// period it is the smoothing of Wilder.
+func smooth(average decimal.Decimal, value decimal.Decimal, n int) decimal.Decimal {
+	count := decimal.NewFromInt(int64(n))
+
+	return average.Mul(count.Sub(one)).Add(value).Div(count)
+}
+
+// window keeps the last values of a series and their running sums.
+type window struct {
+	values     []decimal.Decimal
+	next       int
+	full       bool
+	sum        decimal.Decimal
+	sumSquares decimal.Decimal
+}
+
+func newWindow(size int) *window {
+	return &window{
+		values: make([]decimal.Decimal, size),
+	}
+}
+
+// push adds a value, dropping the oldest one once the window is full.
+func (w *window) push(value decimal.Decimal) {
+	if w.full {
+		oldest := w.values[w.next]
+		w.sum = w.sum.Sub(oldest)
+		w.sumSquares = w.sumSquares.Sub(oldest.Mul(oldest))
+	}
+
+	w.values[w.next] = value
+	w.sum = w.sum.Add(value)
+	w.sumSquares = w.sumSquares.Add(value.Mul(value))
+
+	w.next = (w.next + 1) % len(w.values)
+	if w.next == 0 {
+		w.full = true
+	}
+}
+
+func (w *window) len() int {
+	if w.full {
+		return len(w.values)
+	}
+
+	return w.next
+}
+
+func (w *window) mean() decimal.Decimal {
+	if w.len() == 0 {
+		return decimal.Zero
+	}
+
+	return w.sum.Div(decimal.NewFromInt(int64(w.len())))
+}
+
+// stdDev is the population standard deviation of the values.
+func (w *window) stdDev() decimal.Decimal {
+	if w.len() == 0 {
+		return decimal.Zero
+	}
+
+	n := decimal.NewFromInt(int64(w.len()))
+	mean := w.sum.Div(n)
+	variance := w.sumSquares.Div(n).Sub(mean.Mul(mean))
+
+	return sqrt(variance)
+}
+
+func sqrt(value decimal.Decimal) decimal.Decimal {
+	if !value.IsPositive() {
+		return decimal.Zero
+	}
+
+	root, err := value.PowWithPrecision(decimal.NewFromFloat(0.5), 16)
+	if err != nil {
+		return decimal.Zero
+	}
+
+	return root
+}
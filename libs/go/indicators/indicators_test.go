@@ -0,0 +1,146 @@
+package indicators
+
+import (
+	"testing"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var closes = decimals(
+	"44.34", "44.09", "44.15", "43.61", "44.33", "44.83", "45.10", "45.42", "45.84", "46.08",
+	"45.89", "46.03", "45.61", "46.28", "46.28", "46.00", "46.03", "46.41", "46.22", "45.64",
+)
+
+func decimals(values ...string) []decimal.Decimal {
+	result := make([]decimal.Decimal, 0, len(values))
+	for _, value := range values {
+		result = append(result, decimal.RequireFromString(value))
+	}
+
+	return result
+}
+
+func assertDecimal(t *testing.T, expected string, actual decimal.Decimal) {
+	t.Helper()
+
+	diff := actual.Sub(decimal.RequireFromString(expected)).Abs()
+	assert.True(t, diff.LessThan(decimal.RequireFromString("0.000001")), "expected %s, got %s", expected, actual.String())
+}
+
+func TestInvalidPeriod(t *testing.T) {
+	_, err := NewSMA(0)
+	assert.True(t, errors.Is(err, ErrInvalidPeriod))
+
+	_, err = NewRSI(-1)
+	assert.True(t, errors.Is(err, ErrInvalidPeriod))
+
+	_, err = NewMACD(26, 12, 9)
+	assert.True(t, errors.Is(err, ErrInvalidPeriod))
+}
+
+func TestSMA(t *testing.T) {
+	sma, err := NewSMA(5)
+	assert.NoError(t, err)
+
+	/** Averages what it has until the window is full */
+	assertDecimal(t, "44.34", sma.Update(closes[0]))
+	assertDecimal(t, "44.215", sma.Update(closes[1]))
+	assert.False(t, sma.Ready())
+
+	for _, value := range closes[2:] {
+		sma.Update(value)
+	}
+
+	assert.True(t, sma.Ready())
+	assertDecimal(t, "46.06", sma.Value())
+}
+
+func TestEMA(t *testing.T) {
+	ema, err := NewEMA(5)
+	assert.NoError(t, err)
+
+	for i, value := range closes {
+		ema.Update(value)
+		assert.Equal(t, i >= 4, ema.Ready())
+	}
+
+	assertDecimal(t, "45.99605361941506", ema.Value())
+}
+
+func TestRSI(t *testing.T) {
+	rsi, err := NewRSI(14)
+	assert.NoError(t, err)
+
+	assertDecimal(t, "50", rsi.Update(closes[0]))
+	for i, value := range closes[1:] {
+		rsi.Update(value)
+		assert.Equal(t, i >= 13, rsi.Ready())
+	}
+
+	assertDecimal(t, "57.91502067008556", rsi.Value())
+
+	/** Only gains */
+	rsi, _ = NewRSI(3)
+	for _, value := range decimals("1", "2", "3", "4") {
+		rsi.Update(value)
+	}
+	assertDecimal(t, "100", rsi.Value())
+}
+
+func TestMACD(t *testing.T) {
+	macd, err := NewMACD(3, 6, 4)
+	assert.NoError(t, err)
+
+	for i, value := range closes {
+		macd.Update(value)
+		assert.Equal(t, i >= 8, macd.Ready())
+	}
+
+	value := macd.Value()
+	assertDecimal(t, "-0.06354852124444932", value.MACD)
+	assertDecimal(t, "0.0417042779648594", value.Signal)
+	assertDecimal(t, "-0.10525279920890872", value.Histogram)
+}
+
+func TestBollingerBands(t *testing.T) {
+	bands, err := NewBollingerBands(5, decimal.NewFromInt(2))
+	assert.NoError(t, err)
+
+	for _, value := range closes {
+		bands.Update(value)
+	}
+
+	assert.True(t, bands.Ready())
+	value := bands.Value()
+	assertDecimal(t, "46.573030213535226", value.Upper)
+	assertDecimal(t, "46.06", value.Middle)
+	assertDecimal(t, "45.54696978646478", value.Lower)
+
+	/** Flat prices have no width */
+	bands, _ = NewBollingerBands(3, decimal.NewFromInt(2))
+	for _, value := range decimals("10", "10", "10") {
+		bands.Update(value)
+	}
+	assertDecimal(t, "10", bands.Value().Upper)
+	assertDecimal(t, "10", bands.Value().Lower)
+}
+
+func TestATR(t *testing.T) {
+	highs := decimals("48.70", "48.72", "48.90", "48.87", "48.82", "49.05", "49.20", "49.35", "49.92", "50.19")
+	lows := decimals("47.79", "48.14", "48.39", "48.37", "48.24", "48.64", "48.94", "48.86", "49.50", "49.87")
+	closes := decimals("48.16", "48.61", "48.75", "48.63", "48.74", "49.03", "49.07", "49.32", "49.91", "50.13")
+
+	atr, err := NewATR(5)
+	assert.NoError(t, err)
+
+	/** The first true range is the range of the candle */
+	assertDecimal(t, "0.91", atr.Update(highs[0], lows[0], closes[0]))
+	for i := 1; i < len(highs); i++ {
+		atr.Update(highs[i], lows[i], closes[i])
+		assert.Equal(t, i >= 4, atr.Ready())
+	}
+
+	assertDecimal(t, "0.48478208", atr.Value())
+}
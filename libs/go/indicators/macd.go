@@ -0,0 +1,81 @@
+package indicators
+
+import (
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/shopspring/decimal"
+)
+
+// MACD is the difference between a fast and a slow EMA, with an EMA of it as
+// the signal line, usually 12, 26 and 9.
+type MACD struct {
+	fast   *EMA
+	slow   *EMA
+	signal *EMA
+	value  MACDValue
+}
+
+type MACDValue struct {
+	MACD      decimal.Decimal
+	Signal    decimal.Decimal
+	Histogram decimal.Decimal
+}
+
+func NewMACD(fastPeriod int, slowPeriod int, signalPeriod int) (*MACD, error) {
+	if fastPeriod >= slowPeriod {
+		return nil, errors.New(
+			ErrInvalidPeriod,
+			"fast period must be shorter than the slow one",
+			errors.WithMetadata("fast_period", fastPeriod),
+			errors.WithMetadata("slow_period", slowPeriod),
+		)
+	}
+
+	fast, err := NewEMA(fastPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	slow, err := NewEMA(slowPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	signal, err := NewEMA(signalPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MACD{
+		fast:   fast,
+		slow:   slow,
+		signal: signal,
+	}, nil
+}
+
+// Update adds a value and returns the lines. The signal starts once the slow
+// EMA is ready.
+func (i *MACD) Update(value decimal.Decimal) MACDValue {
+	fast, slow := i.fast.Update(value), i.slow.Update(value)
+	if !i.slow.Ready() {
+		return i.value
+	}
+
+	macd := fast.Sub(slow)
+	signal := i.signal.Update(macd)
+	i.value = MACDValue{
+		MACD:      macd,
+		Signal:    signal,
+		Histogram: macd.Sub(signal),
+	}
+
+	return i.value
+}
+
+func (i *MACD) Value() MACDValue {
+	return i.value
+}
+
+// Ready is true once the signal line is.
+func (i *MACD) Ready() bool {
+	return i.signal.Ready()
+}
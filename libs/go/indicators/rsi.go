@@ -0,0 +1,63 @@
+package indicators
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// RSI is the relative strength index of Wilder, from 0 to 100, over the
+// changes between consecutive values.
+type RSI struct {
+	period   int
+	previous *decimal.Decimal
+	changes  int
+	avgGain  decimal.Decimal
+	avgLoss  decimal.Decimal
+}
+
+func NewRSI(period int) (*RSI, error) {
+	if err := validatePeriod(period); err != nil {
+		return nil, err
+	}
+
+	return &RSI{
+		period: period,
+	}, nil
+}
+
+// Update adds a value and returns the index. The first averages of the gains
+// and losses are simple ones, until there are period changes.
+func (i *RSI) Update(value decimal.Decimal) decimal.Decimal {
+	if i.previous == nil {
+		i.previous = &value
+		return i.Value()
+	}
+
+	change := value.Sub(*i.previous)
+	i.previous = &value
+
+	i.changes++
+	n := min(i.changes, i.period)
+	i.avgGain = smooth(i.avgGain, decimal.Max(change, decimal.Zero), n)
+	i.avgLoss = smooth(i.avgLoss, decimal.Max(change.Neg(), decimal.Zero), n)
+
+	return i.Value()
+}
+
+// Value is 50 while there are no changes.
+func (i *RSI) Value() decimal.Decimal {
+	if !i.avgLoss.IsPositive() {
+		if !i.avgGain.IsPositive() {
+			return decimal.NewFromInt(50)
+		}
+		return hundred
+	}
+
+	rs := i.avgGain.Div(i.avgLoss)
+
+	return hundred.Sub(hundred.Div(rs.Add(one)))
+}
+
+// Ready is true once there are period changes, period + 1 values.
+func (i *RSI) Ready() bool {
+	return i.changes >= i.period
+}
@@ -0,0 +1,37 @@
+package indicators
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// SMA is the simple moving average of the last period values.
+type SMA struct {
+	window *window
+}
+
+func NewSMA(period int) (*SMA, error) {
+	if err := validatePeriod(period); err != nil {
+		return nil, err
+	}
+
+	return &SMA{
+		window: newWindow(period),
+	}, nil
+}
+
+// Update adds a value and returns the average, of the values seen so far until
+// there are period of them.
+func (i *SMA) Update(value decimal.Decimal) decimal.Decimal {
+	i.window.push(value)
+
+	return i.Value()
+}
+
+func (i *SMA) Value() decimal.Decimal {
+	return i.window.mean()
+}
+
+// Ready is true once the window is full.
+func (i *SMA) Ready() bool {
+	return i.window.full
+}
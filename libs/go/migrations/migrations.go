@@ -0,0 +1,309 @@
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+)
+
+var (
+	ErrInvalidMigration = errors.Define("migrations.invalid")
+	ErrChecksumMismatch = errors.Define("migrations.checksum_mismatch")
+	ErrMigrationFailed  = errors.Define("migrations.failed")
+)
+
+const createMigrationsTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version integer PRIMARY KEY,
+		name varchar(255) NOT NULL,
+		checksum varchar(64) NOT NULL,
+		applied_at datetime NOT NULL
+	)`
+
+// Files must be named <version>_<name>.up.sql and <version>_<name>.down.sql,
+// e.g. 0001_create_bots.up.sql.
+var fileNameRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+func New(db *sqlx.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidMigration, err, "could not read migrations")
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		matches := fileNameRegexp.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, errors.New(
+				ErrInvalidMigration,
+				"invalid migration file name",
+				errors.WithMetadata("file", entry.Name()),
+			)
+		}
+
+		version, _ := strconv.Atoi(matches[1])
+		name := matches[2]
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidMigration, err, "could not read migration", errors.WithMetadata("file", entry.Name()))
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != name {
+			return nil, errors.New(
+				ErrInvalidMigration,
+				"duplicated migration version",
+				errors.WithMetadata("version", version),
+			)
+		}
+
+		if matches[3] == "up" {
+			migration.Up = string(content)
+			migration.Checksum = checksum(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, errors.New(
+				ErrInvalidMigration,
+				"missing up migration",
+				errors.WithMetadata("version", migration.Version),
+			)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func checksum(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+type appliedMigration struct {
+	Version   int       `db:"version"`
+	Name      string    `db:"name"`
+	Checksum  string    `db:"checksum"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// applied returns the applied migrations by version, failing if any of them was
+// modified or removed after being applied.
+func (m *Migrator) applied(ctx context.Context) (map[int]appliedMigration, error) {
+	if _, err := m.db.ExecContext(ctx, createMigrationsTable); err != nil {
+		return nil, errors.Wrap(ErrMigrationFailed, err, "could not create migrations table")
+	}
+
+	var rows []appliedMigration
+	if err := m.db.SelectContext(ctx, &rows, "SELECT * FROM schema_migrations ORDER BY version"); err != nil {
+		return nil, errors.Wrap(ErrMigrationFailed, err, "could not read applied migrations")
+	}
+
+	known := make(map[int]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	applied := make(map[int]appliedMigration, len(rows))
+	for _, row := range rows {
+		migration, ok := known[row.Version]
+		if !ok {
+			return nil, errors.New(
+				ErrInvalidMigration,
+				"applied migration not found",
+				errors.WithMetadata("version", row.Version),
+				errors.WithMetadata("name", row.Name),
+			)
+		}
+
+		if migration.Checksum != row.Checksum {
+			return nil, errors.New(
+				ErrChecksumMismatch,
+				"applied migration was modified",
+				errors.WithMetadata("version", row.Version),
+				errors.WithMetadata("name", row.Name),
+			)
+		}
+
+		applied[row.Version] = row
+	}
+
+	return applied, nil
+}
+
+// Up applies every pending migration in order, each one in its own transaction.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var executed []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err := m.exec(ctx, migration, migration.Up, func(tx *sqlx.Tx) error {
+			_, err := tx.ExecContext(
+				ctx,
+				"INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+				migration.Version,
+				migration.Name,
+				migration.Checksum,
+				time.Now(),
+			)
+			return err
+		})
+		if err != nil {
+			return executed, err
+		}
+
+		executed = append(executed, migration)
+	}
+
+	return executed, nil
+}
+
+// Down reverts the last steps applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var executed []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(executed) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		if migration.Down == "" {
+			return executed, errors.New(
+				ErrInvalidMigration,
+				"missing down migration",
+				errors.WithMetadata("version", migration.Version),
+			)
+		}
+
+		err := m.exec(ctx, migration, migration.Down, func(tx *sqlx.Tx) error {
+			_, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+			return err
+		})
+		if err != nil {
+			return executed, err
+		}
+
+		executed = append(executed, migration)
+	}
+
+	return executed, nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (m *Migrator) exec(ctx context.Context, migration Migration, query string, record func(tx *sqlx.Tx) error) error {
+	metadata := errors.WithMetadata("version", migration.Version).And("name", migration.Name)
+
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(ErrMigrationFailed, err, "could not begin transaction", metadata)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return errors.Wrap(ErrMigrationFailed, err, fmt.Sprintf("could not run migration %d_%s", migration.Version, migration.Name), metadata)
+	}
+
+	if err := record(tx); err != nil {
+		return errors.Wrap(ErrMigrationFailed, err, "could not record migration", metadata)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(ErrMigrationFailed, err, "could not commit migration", metadata)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDB(t *testing.T) *sqlx.DB {
+	db, err := sqlx.Connect("sqlite3", t.TempDir()+"/test.db")
+	assert.NoError(t, err)
+
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"0001_create_items.up.sql":   {Data: []byte("CREATE TABLE items (id integer PRIMARY KEY);")},
+		"0001_create_items.down.sql": {Data: []byte("DROP TABLE items;")},
+		"0002_add_name.up.sql":       {Data: []byte("ALTER TABLE items ADD COLUMN name text;")},
+		"0002_add_name.down.sql":     {Data: []byte("ALTER TABLE items DROP COLUMN name;")},
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("up and down", func(t *testing.T) {
+		db := newDB(t)
+
+		migrator, err := New(db, testFS())
+		assert.NoError(t, err)
+
+		executed, err := migrator.Up(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, executed, 2)
+		assert.Equal(t, 1, executed[0].Version)
+		assert.Equal(t, "add_name", executed[1].Name)
+
+		_, err = db.Exec("INSERT INTO items (id, name) VALUES (1, 'name')")
+		assert.NoError(t, err)
+
+		executed, err = migrator.Up(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, executed, 0, "already applied")
+
+		executed, err = migrator.Down(ctx, 1)
+		assert.NoError(t, err)
+		assert.Len(t, executed, 1)
+		assert.Equal(t, 2, executed[0].Version)
+
+		statuses, err := migrator.Status(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, statuses, 2)
+		assert.NotNil(t, statuses[0].AppliedAt)
+		assert.Nil(t, statuses[1].AppliedAt)
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		db := newDB(t)
+
+		migrator, err := New(db, testFS())
+		assert.NoError(t, err)
+
+		_, err = migrator.Up(ctx)
+		assert.NoError(t, err)
+
+		modified := testFS()
+		modified["0001_create_items.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE items (id text PRIMARY KEY);")}
+
+		migrator, err = New(db, modified)
+		assert.NoError(t, err)
+
+		_, err = migrator.Up(ctx)
+		assert.True(t, errors.Is(err, ErrChecksumMismatch))
+	})
+
+	t.Run("failed migration is rolled back", func(t *testing.T) {
+		db := newDB(t)
+
+		broken := testFS()
+		broken["0003_broken.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE other (id integer); INVALID SQL;")}
+
+		migrator, err := New(db, broken)
+		assert.NoError(t, err)
+
+		executed, err := migrator.Up(ctx)
+		assert.True(t, errors.Is(err, ErrMigrationFailed))
+		assert.Len(t, executed, 2)
+
+		statuses, err := migrator.Status(ctx)
+		assert.NoError(t, err)
+		assert.Nil(t, statuses[2].AppliedAt)
+	})
+
+	t.Run("invalid file name", func(t *testing.T) {
+		_, err := New(newDB(t), fstest.MapFS{
+			"create_items.sql": {Data: []byte("CREATE TABLE items (id integer);")},
+		})
+		assert.True(t, errors.Is(err, ErrInvalidMigration))
+	})
+}
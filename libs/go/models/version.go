@@ -47,6 +47,10 @@ func (v Version) Update() Version {
 	return v
 }
 
+func (v Version) IsUpdated() bool {
+	return v.updated
+}
+
 // Serialization
 func (v Version) MarshalJSON() ([]byte, error) {
 	return json.Marshal(v.Value)
@@ -2,6 +2,7 @@ package restclient
 
 import (
 	"context"
+	"net/http"
 	"testing"
 
 	"github.com/jarcoal/httpmock"
@@ -35,3 +36,22 @@ func TestCustomTransport(t *testing.T) {
 		mockedTransport.GetCallCountInfo(),
 	)
 }
+
+func TestRawQuery(t *testing.T) {
+	mockedTransport := httpmock.NewMockTransport()
+	mockedTransport.RegisterResponder("GET", "https://example.com/get", func(req *http.Request) (*http.Response, error) {
+		return httpmock.NewStringResponse(200, req.URL.RawQuery), nil
+	})
+
+	client := New(Config{
+		BaseUrl:         "https://example.com",
+		CustomTransport: mockedTransport,
+	})
+
+	res := client.GET("/get").DoRequest(
+		context.Background(),
+		RawQuery("b=2&a=1&signature=abc"),
+	)
+	assert.Nil(t, res.Err())
+	assert.Equal(t, "b=2&a=1&signature=abc", string(res.Body()))
+}
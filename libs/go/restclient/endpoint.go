@@ -45,6 +45,22 @@ func QueryParamList(key string, values []string) EndpointOption {
 	}
 }
 
+// RawQuery appends an already encoded query string to the url, keeping the
+// params in the given order. Useful when the query is signed.
+func RawQuery(query string) EndpointOption {
+	return func(p *request) {
+		if query == "" {
+			return
+		}
+
+		separator := "?"
+		if strings.Contains(p.URL, "?") {
+			separator = "&"
+		}
+		p.URL = p.URL + separator + query
+	}
+}
+
 func SetFormData(data map[string]string) EndpointOption {
 	return func(p *request) {
 		p.SetFormData(data)
@@ -0,0 +1,240 @@
+package restclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+)
+
+var (
+	ErrTypeRateLimited = errors.Define("rate_limited")
+)
+
+const (
+	// Backoff after a 429 or 418 without Retry-After.
+	defaultRetryAfter = time.Minute
+)
+
+// Config
+type RateLimiterConfig struct {
+	Limits []RateLimit
+	// Wait until there is budget for the request when it takes up to MaxWait.
+	// Requests that would wait longer, or any request when MaxWait is zero, are
+	// rejected.
+	MaxWait time.Duration
+}
+
+// RateLimit is a budget of weight for a fixed window, like Binance's, which
+// restarts at every multiple of Interval.
+type RateLimit struct {
+	Name     string
+	Limit    int
+	Interval time.Duration
+	// Optional response header where the server reports the weight used in the
+	// current window, e.g. X-MBX-USED-WEIGHT-1M. It takes precedence over our
+	// own count, since other clients may share the budget.
+	UsageHeader string
+}
+
+// RateWeights is the weight of a request for each limit, by name. Limits not
+// listed are not consumed.
+type RateWeights map[string]int
+
+// RateLimiter tracks the budgets shared by every endpoint of a server and the
+// backoff it asks for with 429 or 418 responses.
+type RateLimiter struct {
+	config RateLimiterConfig
+
+	mu          sync.Mutex
+	windows     []rateWindow
+	bannedUntil time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	used  int
+}
+
+func NewRateLimiter(config RateLimiterConfig) *RateLimiter {
+	return &RateLimiter{
+		config:  config,
+		windows: make([]rateWindow, len(config.Limits)),
+	}
+}
+
+// Wrapper for endpoints with rate limiter
+type rateLimiterEndpoint struct {
+	endpoint Endpoint
+	limiter  *RateLimiter
+	weights  RateWeights
+}
+
+func EndpointWithRateLimiter(
+	limiter *RateLimiter,
+	weights RateWeights,
+	endpoint Endpoint,
+) Endpoint {
+	return &rateLimiterEndpoint{
+		endpoint: endpoint,
+		limiter:  limiter,
+		weights:  weights,
+	}
+}
+
+func (e *rateLimiterEndpoint) DoRequest(ctx context.Context, opts ...EndpointOption) Response {
+	if err := e.limiter.acquire(ctx, e.weights); err != nil {
+		return &rateLimiterErrorResponse{err: err}
+	}
+
+	res := e.endpoint.DoRequest(ctx, opts...)
+	e.limiter.update(res)
+
+	return res
+}
+
+func (e *rateLimiterEndpoint) Request() Request {
+	return e.endpoint.Request()
+}
+
+// acquire reserves the weight of a request, waiting for the next window when
+// allowed to.
+func (l *RateLimiter) acquire(ctx context.Context, weights RateWeights) error {
+	for {
+		wait, err := l.reserve(weights)
+		if err != nil || wait == 0 {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ErrTypeRateLimited, ctx.Err(), "canceled while waiting for the rate limit")
+		case <-time.After(wait):
+		}
+	}
+}
+
+// reserve consumes the weights if every limit has budget for them, otherwise
+// it returns how long to wait for it or an error if that is too long.
+func (l *RateLimiter) reserve(weights RateWeights) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	var wait time.Duration
+	if l.bannedUntil.After(now) {
+		wait = l.bannedUntil.Sub(now)
+	}
+
+	for i, limit := range l.config.Limits {
+		weight := weights[limit.Name]
+		if weight == 0 {
+			continue
+		}
+
+		window := l.window(i, now)
+		if window.used+weight > limit.Limit {
+			if reset := window.start.Add(limit.Interval).Sub(now); reset > wait {
+				wait = reset
+			}
+		}
+	}
+
+	if wait > 0 {
+		if wait > l.config.MaxWait {
+			return 0, errors.New(
+				ErrTypeRateLimited,
+				"rate limit exhausted",
+				errors.WithMetadata("retry_after", wait.String()),
+			)
+		}
+
+		return wait, nil
+	}
+
+	for i, limit := range l.config.Limits {
+		l.windows[i].used += weights[limit.Name]
+	}
+
+	return 0, nil
+}
+
+// window returns the current window of a limit, starting a new one when the
+// previous one is over.
+func (l *RateLimiter) window(i int, now time.Time) *rateWindow {
+	start := now.Truncate(l.config.Limits[i].Interval)
+	if !l.windows[i].start.Equal(start) {
+		l.windows[i] = rateWindow{start: start}
+	}
+
+	return &l.windows[i]
+}
+
+// update takes the usage reported by the server and backs off when it
+// answers 429, too many requests, or 418, banned for ignoring the 429.
+func (l *RateLimiter) update(res Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	header := res.Header()
+
+	for i, limit := range l.config.Limits {
+		if limit.UsageHeader == "" || header == nil {
+			continue
+		}
+
+		used, err := strconv.Atoi(header.Get(limit.UsageHeader))
+		if err != nil {
+			continue
+		}
+
+		if window := l.window(i, now); used > window.used {
+			window.used = used
+		}
+	}
+
+	if res.StatusCode() != http.StatusTooManyRequests && res.StatusCode() != http.StatusTeapot {
+		return
+	}
+
+	retryAfter := defaultRetryAfter
+	if header != nil {
+		if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil && seconds > 0 {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if until := now.Add(retryAfter); until.After(l.bannedUntil) {
+		l.bannedUntil = until
+	}
+}
+
+// Response with rate limiter error, the request was not sent.
+type rateLimiterErrorResponse struct {
+	err error
+}
+
+func (r *rateLimiterErrorResponse) Body() []byte {
+	return []byte{}
+}
+
+func (r *rateLimiterErrorResponse) Status() string {
+	return ""
+}
+
+func (r *rateLimiterErrorResponse) StatusCode() int {
+	return -1
+}
+
+func (r *rateLimiterErrorResponse) Header() http.Header {
+	return http.Header{}
+}
+
+func (r *rateLimiterErrorResponse) Err() error {
+	return r.err
+}
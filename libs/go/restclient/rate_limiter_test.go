@@ -0,0 +1,76 @@
+package restclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	mockedTransport := httpmock.NewMockTransport()
+	mockedTransport.RegisterResponder("GET", "https://example.com/get", func(req *http.Request) (*http.Response, error) {
+		res := httpmock.NewStringResponse(200, "Test")
+		res.Header.Set("X-Used-Weight", "90")
+		return res, nil
+	})
+
+	client := New(Config{
+		BaseUrl:         "https://example.com",
+		CustomTransport: mockedTransport,
+	})
+
+	limiter := NewRateLimiter(RateLimiterConfig{
+		Limits: []RateLimit{
+			{Name: "WEIGHT", Limit: 100, Interval: time.Hour, UsageHeader: "X-Used-Weight"},
+		},
+	})
+	endpoint := EndpointWithRateLimiter(limiter, RateWeights{"WEIGHT": 6}, client.GET("/get"))
+
+	res := endpoint.DoRequest(context.Background())
+	assert.Nil(t, res.Err())
+
+	/** The usage reported by the server is taken over our own count */
+	res = endpoint.DoRequest(context.Background())
+	assert.Nil(t, res.Err())
+
+	res = endpoint.DoRequest(context.Background())
+	assert.True(t, errors.Is(res.Err(), ErrTypeRateLimited))
+	assert.Equal(t, 2, mockedTransport.GetTotalCallCount())
+}
+
+func TestRateLimiterRetryAfter(t *testing.T) {
+	mockedTransport := httpmock.NewMockTransport()
+	mockedTransport.RegisterResponder("GET", "https://example.com/get", func(req *http.Request) (*http.Response, error) {
+		res := httpmock.NewStringResponse(429, "Too many requests")
+		res.Header.Set("Retry-After", "1")
+		return res, nil
+	})
+
+	client := New(Config{
+		BaseUrl:         "https://example.com",
+		CustomTransport: mockedTransport,
+	})
+
+	limiter := NewRateLimiter(RateLimiterConfig{MaxWait: 2 * time.Second})
+	endpoint := EndpointWithRateLimiter(limiter, RateWeights{}, client.GET("/get"))
+
+	res := endpoint.DoRequest(context.Background())
+	assert.Equal(t, 429, res.StatusCode())
+
+	/** Waits for the Retry-After before sending the next one */
+	start := time.Now()
+	endpoint.DoRequest(context.Background())
+	assert.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond)
+	assert.Equal(t, 2, mockedTransport.GetTotalCallCount())
+
+	/** Or rejects it when it can't wait that long */
+	limiter.config.MaxWait = 0
+	res = endpoint.DoRequest(context.Background())
+	assert.True(t, errors.Is(res.Err(), ErrTypeRateLimited))
+	assert.Equal(t, 2, mockedTransport.GetTotalCallCount())
+}
@@ -0,0 +1,223 @@
+package wsclient
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/juankohler/crypto-bot/libs/go/errors"
+	"golang.org/x/net/websocket"
+)
+
+var (
+	ErrNotConnected = errors.Define("wsclient.not_connected")
+	ErrConnection   = errors.Define("wsclient.connection")
+)
+
+const (
+	defaultReadTimeout = time.Minute
+	defaultMinBackoff  = time.Second
+	defaultMaxBackoff  = time.Minute
+)
+
+type Config struct {
+	Url    string `json:"url"`
+	Origin string `json:"origin"`
+	// The connection is considered dead and replaced when nothing, not even a
+	// ping, is received for this long. Defaults to a minute.
+	ReadTimeoutMs *int `json:"read_timeout_ms"`
+	// Wait before the first reconnection, doubled on every failed attempt up to
+	// MaxBackoffMs. Default to a second and a minute.
+	MinBackoffMs *int `json:"min_backoff_ms"`
+	MaxBackoffMs *int `json:"max_backoff_ms"`
+}
+
+// MessageHandler receives every data message, in the order they arrive.
+type MessageHandler func(ctx context.Context, message []byte)
+
+// ConnectHandler is called after every (re)connection, before any message is
+// read, so the caller can subscribe again.
+type ConnectHandler func(ctx context.Context) error
+
+// DisconnectHandler is called with the cause every time the connection is
+// lost or can't be established, before waiting to reconnect.
+type DisconnectHandler func(ctx context.Context, err error)
+
+type Client interface {
+	// Run connects and keeps the connection alive, reconnecting with
+	// exponential backoff, until the context is canceled.
+	Run(ctx context.Context) error
+	// SendJSON writes a message to the current connection.
+	SendJSON(v any) error
+}
+
+type Option func(c *client)
+
+func WithConnectHandler(handler ConnectHandler) Option {
+	return func(c *client) {
+		c.onConnect = handler
+	}
+}
+
+func WithDisconnectHandler(handler DisconnectHandler) Option {
+	return func(c *client) {
+		c.onDisconnect = handler
+	}
+}
+
+// client answers the pings of the server with pongs, which the websocket
+// package does while reading, and detects silent connections with a read
+// deadline.
+type client struct {
+	config       *websocket.Config
+	readTimeout  time.Duration
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	onMessage    MessageHandler
+	onConnect    ConnectHandler
+	onDisconnect DisconnectHandler
+
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+func New(cfg Config, onMessage MessageHandler, opts ...Option) (Client, error) {
+	origin := cfg.Origin
+	if origin == "" {
+		origin = "http://localhost/"
+	}
+
+	config, err := websocket.NewConfig(cfg.Url, origin)
+	if err != nil {
+		return nil, errors.Wrap(ErrConnection, err, "invalid url", errors.WithMetadata("url", cfg.Url))
+	}
+
+	c := &client{
+		config:      config,
+		readTimeout: durationMs(cfg.ReadTimeoutMs, defaultReadTimeout),
+		minBackoff:  durationMs(cfg.MinBackoffMs, defaultMinBackoff),
+		maxBackoff:  durationMs(cfg.MaxBackoffMs, defaultMaxBackoff),
+		onMessage:   onMessage,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+func (c *client) Run(ctx context.Context) error {
+	attempt := 0
+	for {
+		connected, err := c.connect(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if c.onDisconnect != nil {
+			c.onDisconnect(ctx, err)
+		}
+
+		if connected {
+			attempt = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.backoff(attempt)):
+		}
+		attempt++
+	}
+}
+
+func (c *client) SendJSON(v any) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn == nil {
+		return errors.New(ErrNotConnected, "not connected", errors.WithMetadata("url", c.config.Location.String()))
+	}
+
+	if err := websocket.JSON.Send(conn, v); err != nil {
+		return errors.Wrap(ErrConnection, err, "could not send message", errors.WithMetadata("url", c.config.Location.String()))
+	}
+
+	return nil
+}
+
+// connect dials, reads until the connection fails and reports whether it was
+// established.
+func (c *client) connect(ctx context.Context) (bool, error) {
+	conn, err := c.config.DialContext(ctx)
+	if err != nil {
+		return false, errors.Wrap(ErrConnection, err, "could not connect", errors.WithMetadata("url", c.config.Location.String()))
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+
+	/** Unblocks the read when the context is canceled */
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	defer func() {
+		c.mu.Lock()
+		c.conn = nil
+		c.mu.Unlock()
+		conn.Close()
+	}()
+
+	if c.onConnect != nil {
+		if err := c.onConnect(ctx); err != nil {
+			return true, err
+		}
+	}
+
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(c.readTimeout)); err != nil {
+			return true, errors.Wrap(ErrConnection, err, "could not set read deadline")
+		}
+
+		var message []byte
+		if err := websocket.Message.Receive(conn, &message); err != nil {
+			return true, errors.Wrap(ErrConnection, err, "connection lost", errors.WithMetadata("url", c.config.Location.String()))
+		}
+
+		c.onMessage(ctx, message)
+	}
+}
+
+// backoff doubles the wait on every attempt, with up to 50% of jitter so many
+// clients don't reconnect at once.
+func (c *client) backoff(attempt int) time.Duration {
+	wait := c.minBackoff
+	for i := 0; i < attempt && wait < c.maxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > c.maxBackoff {
+		wait = c.maxBackoff
+	}
+
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+func durationMs(value *int, defaultValue time.Duration) time.Duration {
+	if value == nil || *value <= 0 {
+		return defaultValue
+	}
+
+	return time.Duration(*value) * time.Millisecond
+}
@@ -0,0 +1,84 @@
+package wsclient
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+func TestReconnect(t *testing.T) {
+	/** Every connection echoes the first message it receives and is closed */
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		var message []byte
+		if err := websocket.Message.Receive(conn, &message); err != nil {
+			return
+		}
+		_ = websocket.Message.Send(conn, message)
+	}))
+	defer server.Close()
+
+	backoff := 10
+	connections := 0
+	messages := make(chan string, 10)
+
+	var client Client
+	client, err := New(
+		Config{
+			Url:          "ws" + strings.TrimPrefix(server.URL, "http"),
+			MinBackoffMs: &backoff,
+			MaxBackoffMs: &backoff,
+		},
+		func(ctx context.Context, message []byte) {
+			messages <- string(message)
+		},
+		WithConnectHandler(func(ctx context.Context) error {
+			connections++
+			return client.SendJSON(map[string]int{"connection": connections})
+		}),
+	)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- client.Run(ctx)
+	}()
+
+	for _, expected := range []string{`{"connection":1}`, `{"connection":2}`} {
+		select {
+		case message := <-messages:
+			assert.Equal(t, expected, strings.TrimSpace(message))
+		case <-time.After(5 * time.Second):
+			t.Fatal("message not received")
+		}
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestSendJSONNotConnected(t *testing.T) {
+	client, err := New(Config{Url: "ws://localhost:1"}, func(ctx context.Context, message []byte) {})
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, client.SendJSON("ping"), ErrNotConnected)
+}
+
+func TestBackoff(t *testing.T) {
+	min, max := 100, 1000
+	c := &client{
+		minBackoff: durationMs(&min, defaultMinBackoff),
+		maxBackoff: durationMs(&max, defaultMaxBackoff),
+	}
+
+	for attempt, expected := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		wait := c.backoff(attempt)
+		assert.GreaterOrEqual(t, wait, expected*time.Millisecond/2)
+		assert.LessOrEqual(t, wait, expected*time.Millisecond)
+	}
+}
@@ -1,28 +1,66 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/juankohler/crypto-bot/bots"
 	"github.com/juankohler/crypto-bot/common"
 	"github.com/juankohler/crypto-bot/libs/go/http/server"
+	"github.com/juankohler/crypto-bot/libs/go/logs"
 )
 
 var bootables = []common.Bootable{
 	bots.Boot,
 }
 
+const (
+	// Time to finish the requests and the bot executions in progress.
+	shutdownTimeout = 30 * time.Second
+)
+
+// boot serves the API until SIGINT or SIGTERM and then shuts down gracefully:
+// the server stops taking requests and every bot saves its state.
 func boot(cfg *common.Config, deps *common.Dependencies) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	for _, bootable := range bootables {
 		if err := bootable(cfg, deps); err != nil {
 			return err
 		}
 	}
 
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.Port),
+		Handler: logs.ContextWithLoggerMiddleware(deps.Mux),
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
 	fmt.Printf("Server running on port %d\n", cfg.Port)
 
-	return http.ListenAndServe(fmt.Sprintf(":%d", cfg.Port), deps.Mux)
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	fmt.Println("Shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(logs.ContextWithLogger(context.Background()), shutdownTimeout)
+	defer cancel()
+
+	return errors.Join(httpServer.Shutdown(shutdownCtx), deps.Shutdown(shutdownCtx))
 }
 
 func main() {
@@ -31,6 +69,20 @@ func main() {
 		panic(err)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(cfg, os.Args[2:]); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "backtest" {
+		if err := backtest(cfg, os.Args[2:]); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	deps, err := common.BuildDependencies(cfg)
 	if err != nil {
 		panic(err)